@@ -0,0 +1,267 @@
+// Package client is a Go client for the url-shortener JSON API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+var (
+	// ErrAliasExists is returned by Create if the alias is already taken.
+	ErrAliasExists = errors.New("alias exists")
+	// ErrNotFound is returned if the alias doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is returned if the credentials are missing or wrong.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// maxErrorBodySize limits how much of a non-JSON error response is read.
+const maxErrorBodySize = 4 << 10
+
+// APIError is an error response of the API. It matches the sentinel
+// errors of the package with errors.Is by its code.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api error (status %d): %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("api error (status %d, code %s): %s", e.StatusCode, e.Code, e.Message)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrAliasExists:
+		return e.Code == resp.CodeAliasExists
+	case ErrNotFound:
+		return e.Code == resp.CodeNotFound || e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Code == resp.CodeUnauthorized || e.StatusCode == http.StatusUnauthorized
+	}
+
+	return false
+}
+
+// ShortURL is a saved url.
+type ShortURL struct {
+	Alias string
+	ID    int64
+	URL   string
+}
+
+// CreateOptions are optional properties of a created url.
+type CreateOptions struct {
+	// Alias is generated by the server if empty.
+	Alias string
+	// Password, if set, is required to follow the alias.
+	Password string
+	// OneTime makes the alias expire after the first redirect.
+	OneTime bool
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	auth       func(r *http.Request)
+}
+
+// Option configures the client.
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		cl.httpClient = c
+	}
+}
+
+// WithBasicAuth authenticates requests with the user and password.
+func WithBasicAuth(user string, password string) Option {
+	return func(cl *Client) {
+		cl.auth = func(r *http.Request) {
+			r.SetBasicAuth(user, password)
+		}
+	}
+}
+
+// WithBearerToken authenticates requests with the token, e.g. a JWT.
+func WithBearerToken(token string) Option {
+	return func(cl *Client) {
+		cl.auth = func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// New returns a client of the API served at baseURL, e.g. "http://localhost:8082".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Create saves urlToSave and returns its alias.
+func (c *Client) Create(ctx context.Context, urlToSave string, opts CreateOptions) (ShortURL, error) {
+	const op = "client.Create"
+
+	req := struct {
+		URL      string `json:"url"`
+		Alias    string `json:"alias,omitempty"`
+		Password string `json:"password,omitempty"`
+		OneTime  bool   `json:"one_time,omitempty"`
+	}{
+		URL:      urlToSave,
+		Alias:    opts.Alias,
+		Password: opts.Password,
+		OneTime:  opts.OneTime,
+	}
+
+	var res struct {
+		resp.Response
+		Alias string      `json:"alias"`
+		ID    json.Number `json:"id"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/url", req, &res); err != nil {
+		return ShortURL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	short := ShortURL{Alias: res.Alias, URL: urlToSave}
+
+	if res.ID != "" {
+		id, err := res.ID.Int64()
+		if err != nil {
+			return ShortURL{}, fmt.Errorf("%s: invalid id: %w", op, err)
+		}
+
+		short.ID = id
+	}
+
+	return short, nil
+}
+
+// Resolve returns the url saved under alias.
+func (c *Client) Resolve(ctx context.Context, alias string) (string, error) {
+	const op = "client.Resolve"
+
+	req := struct {
+		Aliases []string `json:"aliases"`
+	}{
+		Aliases: []string{alias},
+	}
+
+	var res struct {
+		resp.Response
+		URLs map[string]string `json:"urls"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/urls/resolve", req, &res); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	u, ok := res.URLs[alias]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", op, ErrNotFound)
+	}
+
+	return u, nil
+}
+
+// Delete deletes alias.
+func (c *Client) Delete(ctx context.Context, alias string) error {
+	const op = "client.Delete"
+
+	var res resp.Response
+
+	if err := c.do(ctx, http.MethodDelete, "/url/"+url.PathEscape(alias), nil, &res); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// do sends body as JSON and decodes the response into res, which must
+// embed resp.Response. Error responses are returned as *APIError.
+func (c *Client) do(ctx context.Context, method string, path string, body any, res any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if !strings.HasPrefix(httpResp.Header.Get("Content-Type"), "application/json") {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrorBodySize))
+
+		return &APIError{
+			StatusCode: httpResp.StatusCode,
+			Message:    strings.TrimSpace(string(msg)),
+		}
+	}
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	var envelope resp.Response
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	if envelope.Status != resp.StatusOK {
+		return &APIError{
+			StatusCode: httpResp.StatusCode,
+			Code:       envelope.Code,
+			Message:    envelope.Error,
+		}
+	}
+
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
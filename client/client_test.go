@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/client"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/resolve"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	log := slogdiscard.NewDiscardLogger()
+	basicAuth := middleware.BasicAuth("url-shortener", map[string]string{"user": "pass"})
+
+	router := chi.NewRouter()
+	router.Route("/url", func(r chi.Router) {
+		r.Use(basicAuth)
+		r.Post("/", save.New(log, storage))
+		r.Delete("/{alias}", delete.New(log, storage))
+	})
+	router.Route("/urls", func(r chi.Router) {
+		r.Use(basicAuth)
+		r.Post("/resolve", resolve.New(log, storage))
+	})
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newServer(t)
+	ctx := context.Background()
+
+	c := client.New(srv.URL, client.WithBasicAuth("user", "pass"))
+
+	short, err := c.Create(ctx, "https://example.com", client.CreateOptions{Alias: "example"})
+	require.NoError(t, err)
+	assert.Equal(t, "example", short.Alias)
+	assert.NotZero(t, short.ID)
+
+	generated, err := c.Create(ctx, "https://example.com/other", client.CreateOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, generated.Alias)
+
+	_, err = c.Create(ctx, "https://example.com", client.CreateOptions{Alias: "example"})
+	assert.ErrorIs(t, err, client.ErrAliasExists)
+
+	u, err := c.Resolve(ctx, "example")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", u)
+
+	require.NoError(t, c.Delete(ctx, "example"))
+
+	_, err = c.Resolve(ctx, "example")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	err = c.Delete(ctx, "example")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClient_InvalidRequest(t *testing.T) {
+	srv := newServer(t)
+
+	c := client.New(srv.URL, client.WithBasicAuth("user", "pass"))
+
+	_, err := c.Create(context.Background(), "not a url", client.CreateOptions{})
+
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "field URL is not a valid URL", apiErr.Message)
+	assert.NotErrorIs(t, err, client.ErrAliasExists)
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	srv := newServer(t)
+	ctx := context.Background()
+
+	for name, c := range map[string]*client.Client{
+		"No credentials":    client.New(srv.URL),
+		"Wrong credentials": client.New(srv.URL, client.WithBasicAuth("user", "wrong")),
+		"Bearer token":      client.New(srv.URL, client.WithBearerToken("token")),
+	} {
+		_, err := c.Create(ctx, "https://example.com", client.CreateOptions{})
+		assert.ErrorIs(t, err, client.ErrUnauthorized, name)
+	}
+}
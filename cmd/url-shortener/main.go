@@ -2,23 +2,96 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/exp/slog"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
+	"url-shortener/internal/cache"
+	"url-shortener/internal/cache/memory"
+	cacheRedis "url-shortener/internal/cache/redis"
+	"url-shortener/internal/cache/warmer"
 	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/errorpage"
+	"url-shortener/internal/http-server/handlers/adminui"
+	"url-shortener/internal/http-server/handlers/allow"
+	"url-shortener/internal/http-server/handlers/audit/list"
+	"url-shortener/internal/http-server/handlers/debug/runtimeinfo"
+	"url-shortener/internal/http-server/handlers/health/ready"
 	"url-shortener/internal/http-server/handlers/redirect"
+	"url-shortener/internal/http-server/handlers/routes"
+	"url-shortener/internal/http-server/handlers/stats/collisions"
+	"url-shortener/internal/http-server/handlers/stats/summary"
+	"url-shortener/internal/http-server/handlers/url/clicksync"
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/follow"
+	"url-shortener/internal/http-server/handlers/url/importer"
+	urlList "url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/lookup"
+	"url-shortener/internal/http-server/handlers/url/reserve"
+	"url-shortener/internal/http-server/handlers/url/resolve"
 	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/search"
+	urlTags "url-shortener/internal/http-server/handlers/url/tags"
+	"url-shortener/internal/http-server/handlers/url/upsert"
+	"url-shortener/internal/http-server/middleware/admin"
+	"url-shortener/internal/http-server/middleware/bodylog"
+	"url-shortener/internal/http-server/middleware/connlimit"
+	"url-shortener/internal/http-server/middleware/forcehttps"
+	"url-shortener/internal/http-server/middleware/headerlimit"
+	"url-shortener/internal/http-server/middleware/idempotency"
+	"url-shortener/internal/http-server/middleware/jsoncase"
 	mwLogger "url-shortener/internal/http-server/middleware/logger"
+	mwMetrics "url-shortener/internal/http-server/middleware/metrics"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/http-server/middleware/prettyjson"
+	"url-shortener/internal/http-server/middleware/readonly"
+	"url-shortener/internal/http-server/middleware/recoverer"
+	"url-shortener/internal/http-server/middleware/requestid"
+	"url-shortener/internal/http-server/middleware/secheaders"
+	"url-shortener/internal/http-server/middleware/securecookies"
+	mwTracing "url-shortener/internal/http-server/middleware/tracing"
+	"url-shortener/internal/lib/aliaslen"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/clickevents"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/cookie"
+	"url-shortener/internal/lib/lifecycle"
 	"url-shortener/internal/lib/logger/handlers/slogpretty"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/profanity"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/realip"
+	"url-shortener/internal/lib/redirectrule"
+	"url-shortener/internal/lib/redis"
+	"url-shortener/internal/lib/routeconflict"
+	"url-shortener/internal/lib/scheduler"
+	"url-shortener/internal/lib/tracing"
+	"url-shortener/internal/lib/wordlist"
+	"url-shortener/internal/storage/cached"
+	"url-shortener/internal/storage/composite"
+	"url-shortener/internal/storage/slowlog"
 	"url-shortener/internal/storage/sqlite"
+	"url-shortener/internal/storage/static"
+	"url-shortener/internal/storage/traced"
 )
 
 const (
@@ -27,10 +100,43 @@ const (
 	envProd  = "prod"
 )
 
+const (
+	cacheTypeMemory = "memory"
+	cacheTypeRedis  = "redis"
+)
+
+const (
+	clickSinkLog     = "log"
+	clickSinkFile    = "file"
+	clickSinkWebhook = "webhook"
+)
+
+const (
+	aliasStrategyRandom     = "random"
+	aliasStrategyWordlist   = "wordlist"
+	aliasStrategySequential = "sequential"
+
+	profanityOff = "off"
+)
+
 func main() {
+	os.Exit(run())
+}
+
+// run starts the service and returns the exit code. It returns instead
+// of exiting, so that deferred cleanup runs on every path.
+func run() int {
+	optimize := flag.Bool("optimize", false, "optimize the storage and exit")
+	checkConfig := flag.Bool("check-config", false, "validate the config at CONFIG_PATH and exit")
+	flag.Parse()
+
+	if *checkConfig {
+		return runConfigCheck(os.Stdout, os.Getenv("CONFIG_PATH"))
+	}
+
 	cfg := config.MustLoad()
 
-	log := setupLogger(cfg.Env)
+	log := setupLogger(cfg.Env, cfg.Log)
 
 	log.Info(
 		"starting url-shortener",
@@ -39,46 +145,386 @@ func main() {
 	)
 	log.Debug("debug messages are enabled")
 
-	storage, err := sqlite.New(cfg.StoragePath)
+	if cfg.CreateDirs {
+		if err := sqlite.CreateDir(cfg.StoragePath); err != nil {
+			log.Error("failed to create storage directory", sl.Err(err))
+			return 1
+		}
+	}
+
+	clk := clock.Real{}
+
+	storage, err := sqlite.New(cfg.StoragePath,
+		sqlite.WithLogger(log),
+		sqlite.WithClock(clk),
+		sqlite.WithConnMaxLifetime(cfg.DB.ConnMaxLifetime),
+		sqlite.WithConnMaxIdleTime(cfg.DB.ConnMaxIdleTime),
+	)
 	if err != nil {
-		log.Error("failed to init storage", sl.Err(err))
-		os.Exit(1)
+		if hint := sqlite.Hint(cfg.StoragePath, err); hint != "" {
+			log.Error("failed to init storage", sl.Err(err), slog.String("hint", hint))
+		} else {
+			log.Error("failed to init storage", sl.Err(err))
+		}
+		return 1
+	}
+
+	if *optimize {
+		if err := storage.Optimize(context.Background()); err != nil {
+			log.Error("failed to optimize storage", sl.Err(err))
+			return 1
+		}
+
+		log.Info("storage optimized")
+
+		return 0
+	}
+
+	workers := lifecycle.New()
+
+	jobs := scheduler.New(log, storage)
+	jobs.Add(optimizeJob(log, storage, cfg.Maintenance))
+	jobs.Add(purgeExpiredJob(log, storage, cfg.Maintenance))
+	jobs.Add(auditRetentionJob(log, storage, cfg.Maintenance, clk))
+
+	var urlStorage cached.URLStorage = storage
+	if cfg.Log.SlowlogThreshold > 0 {
+		urlStorage = slowlog.New(log, storage, cfg.Log.SlowlogThreshold)
+	}
+
+	if cfg.Fallback.StoragePath != "" {
+		fallback, err := sqlite.OpenReadOnly(cfg.Fallback.StoragePath)
+		if err != nil {
+			log.Error("failed to open fallback storage", sl.Err(err))
+			return 1
+		}
+		defer func() { _ = fallback.Close() }()
+
+		urlStorage = composite.New(log, urlStorage, fallback, cfg.Fallback.MigrateOnRead)
+	}
+
+	if c := setupCache(log, workers, cfg.Cache); c != nil {
+		urlStorage = cached.New(log, urlStorage, storage, c)
+
+		if cfg.Cache.WarmTopN > 0 {
+			workers.Go("cache warmer", func(ctx context.Context) {
+				if err := warmer.Warm(ctx, log, c, storage, cfg.Cache.WarmTopN); err != nil {
+					log.Error("failed to warm cache", sl.Err(err))
+				}
+			})
+
+			jobs.Add(scheduler.Job{
+				Name:     "cache_warm",
+				Interval: cfg.Maintenance.CacheWarmInterval,
+				Run: func(ctx context.Context) error {
+					return warmer.Warm(ctx, log, c, storage, cfg.Cache.WarmTopN)
+				},
+			})
+		}
+	}
+
+	if names := jobs.Jobs(); len(names) > 0 {
+		log.Info("maintenance jobs scheduled", slog.Any("jobs", names))
+
+		workers.Go("scheduler", jobs.Run)
+	}
+
+	var staticAliases *static.Storage
+	if cfg.StaticAliases != "" {
+		staticAliases, err = static.New(cfg.StaticAliases, urlStorage)
+		if err != nil {
+			log.Error("failed to load static aliases", sl.Err(err))
+			return 1
+		}
+
+		log.Info("static aliases loaded", slog.Int("count", staticAliases.Len()))
+
+		urlStorage = staticAliases
+	}
+
+	tracer := setupTracer(log, cfg.Tracing)
+	if tracer != nil {
+		urlStorage = traced.New(urlStorage, tracer)
+	}
+
+	metricsSink, metricsHandler, err := setupMetrics(cfg.Metrics)
+	if err != nil {
+		log.Error("failed to init metrics", sl.Err(err))
+		return 1
+	}
+
+	auditLog := audit.New(log, storage, clk)
+
+	readOnly := readonly.NewMode(cfg.ReadOnly)
+
+	ipResolver, err := realip.New(cfg.HTTPServer.TrustedProxies)
+	if err != nil {
+		log.Error("invalid trusted proxies", sl.Err(err))
+		return 1
 	}
 
 	router := chi.NewRouter()
 
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
-	router.Use(mwLogger.New(log))
-	router.Use(middleware.Recoverer)
+	router.Use(secheaders.New(cfg.HTTPServer.SecurityHeaders.Map()))
+	router.Use(requestid.New(cfg.HTTPServer.RequestIDHeader))
+	router.Use(headerlimit.New(log, cfg.HTTPServer.MaxHeaderCount, cfg.HTTPServer.MaxHeaderBytes))
+	if mw := trailingSlash(cfg.TrailingSlash); mw != nil {
+		router.Use(mw)
+	}
+	if cfg.HTTPServer.ForceHTTPS {
+		router.Use(forcehttps.New(ipResolver.FromTrustedProxy))
+	}
+
+	if cfg.HTTPServer.MaxConnsPerIP > 0 {
+		router.Use(connlimit.New(log, cfg.HTTPServer.MaxConnsPerIP, ipResolver.ClientIP))
+	}
+	router.Use(mwTracing.New(tracer))
+	router.Use(mwMetrics.New(metricsSink))
+	router.Use(requestLoggers(log, cfg.Log)...)
+	router.Use(recoverer.New(log))
+	if cfg.Env != envProd {
+		router.Use(prettyjson.New(cfg.Debug.PrettyJSON))
+	}
+	// aliases are the keys of resolve results
+	router.Use(jsoncase.New(jsoncase.Naming(cfg.JSONNaming), "urls"))
 	router.Use(middleware.URLFormat)
 
-	router.Route("/url", func(r chi.Router) {
-		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
-			cfg.HTTPServer.User: cfg.HTTPServer.Password,
-		}))
+	credentials := map[string]string{
+		cfg.HTTPServer.User: cfg.HTTPServer.Password,
+	}
+	namespaces := make(map[string]string, len(cfg.Users))
+	// the admin is never limited
+	quotas := quota.Limits{Default: cfg.AliasQuota, Users: map[string]int64{cfg.HTTPServer.User: 0}}
+
+	for _, u := range cfg.Users {
+		credentials[u.Name] = u.Password
+		namespaces[u.Name] = u.Namespace
+
+		if u.Quota != nil {
+			quotas.Users[u.Name] = *u.Quota
+		}
+	}
+
+	basicAuth := middleware.BasicAuth("url-shortener", credentials)
+
+	aliasValidator := setupAliasValidator(cfg.Alias)
+
+	// aliases which are not passed through the save handler
+	// must already have the check character
+	checksummedValidator := aliasValidator
+	if cfg.Alias.Checksum {
+		checksummedValidator = aliaspolicy.Chain(aliasValidator, aliaspolicy.Checksum())
+	}
+
+	aliasGenerator, err := setupAliasGenerator(cfg.Alias)
+	if err != nil {
+		log.Error("failed to init alias generator", sl.Err(err))
+		return 1
+	}
+
+	logAliasEntropy(log, cfg.Alias)
+
+	aliasStrategies, err := setupAliasStrategies(cfg.Alias, storage)
+	if err != nil {
+		log.Error("failed to init alias strategies", sl.Err(err))
+		return 1
+	}
+
+	aliasFilter, err := setupAliasFilter(cfg.Alias)
+	if err != nil {
+		log.Error("failed to init alias filter", sl.Err(err))
+		return 1
+	}
+
+	router.Route("/url", func(public chi.Router) {
+		public.Group(func(r chi.Router) {
+			r.Use(basicAuth)
+			r.Use(namespace.New(namespaces))
+			r.Use(readonly.New(log, readOnly))
+
+			if cfg.Log.Bodies {
+				if cfg.Env == envProd {
+					log.Warn("body logging is not allowed in prod, ignoring it")
+				} else {
+					r.Use(bodylog.New(log, bodylog.DefaultMaxBodySize))
+				}
+			}
+
+			saveHandler := save.New(log, urlStorage,
+				save.WithAuditor(auditLog),
+				save.WithIDAsString(cfg.IDsAsStrings),
+				save.WithAliasLength(cfg.Alias.Length),
+				save.WithAliasCharset(cfg.Alias.Charset),
+				save.WithAliasValidator(aliasValidator),
+				save.WithCollisionRecorder(collisionCounter{CollisionRecorder: storage, sink: metricsSink}),
+				save.WithAliasAutoscaler(setupAliasAutoscaler(log, cfg.Alias, storage)),
+				save.WithAliasGenerator(aliasGenerator),
+				save.WithSequentialAliases(setupSequentialAliases(cfg.Alias, storage)),
+				save.WithStrategies(aliasStrategies),
+				save.WithAliasFilter(aliasFilter),
+				save.WithAliasChecksum(cfg.Alias.Checksum),
+				save.WithQuota(quotas),
+				save.WithMaxTags(cfg.MaxTags),
+				save.WithRedirectRules(maxRedirectRules(cfg.RedirectRules)),
+				save.WithStrictStatus(cfg.StrictHTTPStatus),
+				save.WithClock(clk),
+				save.WithBaseURL(cfg.BaseURL),
+				save.WithTrustedProxy(ipResolver.FromTrustedProxy),
+				save.WithAllowedSchemes(cfg.AllowedSchemes),
+				save.WithReachabilityChecker(api.NewChecker(cfg.Verify.Timeout, cfg.Verify.AllowPrivate)),
+			)
+			var saveRoute http.Handler = saveHandler
+			if cfg.IdempotencyTTL > 0 {
+				saveRoute = idempotency.New(log, storage, cfg.IdempotencyTTL, cfg.IdempotencyClaimTimeout, clk)(saveHandler)
+			}
+
+			urlRoutes(public, r, cfg.Handlers, urlHandlers{
+				save: saveRoute,
+				upsert: upsert.New(log, urlStorage,
+					upsert.WithAuditor(auditLog),
+					upsert.WithAliasValidator(checksummedValidator),
+					upsert.WithAllowedSchemes(cfg.AllowedSchemes),
+					upsert.WithQuota(quotas),
+				),
+				delete: delete.New(log, urlStorage, delete.WithAuditor(auditLog)),
+				tags: urlTags.New(log, storage,
+					urlTags.WithAuditor(auditLog),
+					urlTags.WithMaxTags(cfg.MaxTags),
+				),
+				reserve: reserve.New(log, storage,
+					reserve.WithTTL(cfg.ReservationTTL),
+					reserve.WithAliasValidator(aliasValidator),
+				),
+				release: reserve.NewRelease(log, storage),
+			})
+
+			if cfg.MaxResolveHops > 0 {
+				r.Get("/{alias}/resolve", follow.New(log, urlStorage, storage, cfg.MaxResolveHops))
+			}
+		})
+	})
+
+	router.Route("/urls", func(r chi.Router) {
+		r.Use(basicAuth)
 
-		r.Post("/", save.New(log, storage))
-		// TODO: add DELETE /url/{id}
+		r.With(admin.New(log, cfg.HTTPServer.User)).Get("/", urlList.New(log, storage))
+		r.With(namespace.New(namespaces)).Post("/resolve", resolve.New(log, storage))
+		if config.Enabled(cfg.Handlers.ImportEnabled) {
+			r.With(namespace.New(namespaces), readonly.New(log, readOnly)).Post("/import", importer.New(log, urlStorage,
+				importer.WithAuditor(auditLog),
+				importer.WithAliasValidator(checksummedValidator),
+				importer.WithResolver(
+					api.NewResolver(cfg.Import.ResolveTimeout, cfg.Import.MaxRedirects, cfg.Import.AllowPrivate),
+					cfg.Import.SkipUnresolved,
+				),
+				importer.WithDuplicates(importer.Duplicates(cfg.Import.Duplicates)),
+				importer.WithQuota(quotas),
+				importer.WithAllowedSchemes(cfg.AllowedSchemes),
+			))
+		}
+		r.With(namespace.New(namespaces)).Get("/by-target", lookup.New(log, storage))
+		r.With(admin.New(log, cfg.HTTPServer.User)).Get("/search", search.New(log, storage))
+		if config.Enabled(cfg.Handlers.ClicksSyncEnabled) {
+			r.With(admin.New(log, cfg.HTTPServer.User), readonly.New(log, readOnly)).
+				Post("/clicks/sync", clicksync.New(log, storage))
+		}
 	})
 
-	router.Get("/{alias}", redirect.New(log, storage))
+	router.With(basicAuth, admin.New(log, cfg.HTTPServer.User)).
+		Get("/audit", list.New(log, storage, list.WithMaxLimit(cfg.AuditMaxLimit)))
+	router.With(basicAuth).Get("/stats/collisions", collisions.New(log, storage, collisions.WithClock(clk)))
+	router.With(basicAuth, admin.New(log, cfg.HTTPServer.User)).Get("/stats/summary", summary.New(log, storage))
+
+	if cfg.Debug.Pprof {
+		router.With(basicAuth).Mount("/debug", middleware.Profiler())
+	}
+	if cfg.Debug.Runtime {
+		router.With(basicAuth, admin.New(log, cfg.HTTPServer.User)).Get("/debug/runtime", runtimeinfo.New(log))
+	}
+
+	router.Get("/readyz", ready.New(log, storage, cfg.Health.Timeout))
+	if metricsHandler != nil {
+		router.Handle("/metrics", metricsHandler)
+	}
+	router.Get("/api/routes", routes.New(log, router))
+
+	errorPages, err := errorpage.New(cfg.ErrorPagesDir)
+	if err != nil {
+		log.Error("failed to load error pages", sl.Err(err))
+		return 1
+	}
+
+	redirectOpts := []redirect.Option{
+		redirect.WithNotFoundRedirect(cfg.NotFoundRedirect),
+		redirect.WithErrorPages(errorPages),
+		redirect.WithPasswords(storage),
+		redirect.WithOneTime(storage),
+		redirect.WithClicks(storage),
+		redirect.WithCacheTTL(storage),
+		redirect.WithAliasChecksum(cfg.Alias.Checksum),
+		redirect.WithNotYetActivePage(cfg.NotYetActivePage),
+		redirect.WithAliasHeader(cfg.RedirectAliasHeader),
+		redirect.WithDelays(redirect.Delays{
+			Default: cfg.RedirectDelay.Default,
+			Hosts:   cfg.RedirectDelay.Hosts,
+			Aliases: cfg.RedirectDelay.Aliases,
+		}, clk),
+	}
+	if cfg.AccelRedirect.Enabled {
+		redirectOpts = append(redirectOpts, redirect.WithAccelRedirect(cfg.AccelRedirect.Header, cfg.AccelRedirect.Prefix))
+	}
+	if cfg.RedirectRules.Enabled {
+		redirectOpts = append(redirectOpts, redirect.WithRedirectRules(storage, redirectrule.Matcher{
+			CountryHeader: cfg.RedirectRules.CountryHeader,
+		}))
+	}
+
+	clickEvents, err := setupClickEvents(log, workers, cfg.ClickEvents, clk)
+	if err != nil {
+		log.Error("failed to init click events", sl.Err(err))
+		return 1
+	}
+	if clickEvents != nil {
+		redirectOpts = append(redirectOpts, redirect.WithClickEvents(clickEvents))
+	}
+
+	redirectHandler := redirect.New(log, urlStorage, redirectOpts...)
+
+	if cfg.AdminUI.InsecureCookies && cfg.Env == envProd {
+		log.Warn("insecure admin cookies are not allowed in prod, ignoring it")
+
+		cfg.AdminUI.InsecureCookies = false
+	}
+	adminUIRoutes(router, cfg.AdminUI, basicAuth)
+	redirectRoutes(router, redirectHandler, cfg.RedirectTrailingSlash)
+
+	if err := checkRouteConflicts(log, router, storage, cfg.FailOnRouteConflict); err != nil {
+		log.Error("aliases are shadowed by routes", sl.Err(err))
+		return 1
+	}
 
 	log.Info("starting server", slog.String("address", cfg.Address))
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	srv := &http.Server{
-		Addr:         cfg.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.Timeout,
-		WriteTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	go func() {
+		for range reload {
+			reloadConfig(log, readOnly, errorPages, staticAliases)
+		}
+	}()
+
+	srv, err := newServer(cfg.HTTPServer, router)
+	if err != nil {
+		log.Error("failed to init server", sl.Err(err))
+		return 1
 	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil {
+		if err := listenAndServe(srv, cfg.HTTPServer.TLS); err != nil {
 			log.Error("failed to start server")
 		}
 	}()
@@ -88,27 +534,200 @@ func main() {
 	<-done
 	log.Info("stopping server")
 
-	// TODO: move timeout to config
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Error("failed to stop server", sl.Err(err))
 
+		return 1
+	}
+
+	log.Info("server stopped")
+
+	workersCtx, cancelWorkers := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelWorkers()
+
+	if err := workers.Shutdown(workersCtx); err != nil {
+		log.Error("failed to stop background workers", sl.Err(err))
+	}
+
+	if err := tracer.Shutdown(ctx); err != nil {
+		log.Error("failed to flush traces", sl.Err(err))
+	}
+
+	if c, ok := metricsSink.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Error("failed to close metrics sink", sl.Err(err))
+		}
+	}
+
+	auditLog.Close()
+
+	return closeStorage(log, storage, cfg.FailOnStorageCloseError)
+}
+
+// optimizeJob periodically optimizes the storage. A run is skipped
+// if the storage handled too many writes since the previous one,
+// since VACUUM blocks writers until it is done.
+func optimizeJob(log *slog.Logger, storage *sqlite.Storage, cfg config.Maintenance) scheduler.Job {
+	log = log.With(slog.String("component", "optimizer"))
+
+	lastWrites := storage.Writes()
+
+	return scheduler.Job{
+		Name:     "optimize",
+		Interval: cfg.OptimizeInterval,
+		Run: func(ctx context.Context) error {
+			writes := storage.Writes()
+			recent := writes - lastWrites
+			lastWrites = writes
+
+			if recent > cfg.OptimizeMaxWrites {
+				log.Info("optimization skipped due to write load", slog.Int64("writes", recent))
+
+				return nil
+			}
+
+			return storage.Optimize(ctx)
+		},
+	}
+}
+
+// purgeExpiredJob periodically deletes expired urls.
+func purgeExpiredJob(log *slog.Logger, storage *sqlite.Storage, cfg config.Maintenance) scheduler.Job {
+	return scheduler.Job{
+		Name:     "purge_expired",
+		Interval: cfg.PurgeExpiredInterval,
+		Run: func(ctx context.Context) error {
+			n, err := storage.PurgeExpired(ctx)
+			if err != nil {
+				return err
+			}
+
+			log.Info("expired urls purged", slog.Int64("count", n))
+
+			return nil
+		},
+	}
+}
+
+// auditRetentionJob periodically deletes audit entries older than
+// the retention period.
+func auditRetentionJob(log *slog.Logger, storage *sqlite.Storage, cfg config.Maintenance, clock clock.Clock) scheduler.Job {
+	return scheduler.Job{
+		Name:     "audit_retention",
+		Interval: cfg.AuditRetentionInterval,
+		Run: func(ctx context.Context) error {
+			n, err := storage.PurgeAudit(ctx, clock.Now().Add(-cfg.AuditRetention))
+			if err != nil {
+				return err
+			}
+
+			log.Info("old audit entries purged", slog.Int64("count", n))
+
+			return nil
+		},
+	}
+}
+
+// newServer creates the HTTP server. With H2C enabled the server also
+// accepts HTTP/2 over cleartext connections.
+func newServer(cfg config.HTTPServer, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:           cfg.Address,
+		Handler:        handler,
+		ReadTimeout:    cfg.Timeout,
+		WriteTimeout:   cfg.Timeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	}
+
+	if cfg.TLS.Enabled() {
+		tlsConfig, err := cfg.TLS.Config()
+		if err != nil {
+			return nil, fmt.Errorf("tls config: %w", err)
+		}
+
+		srv.TLSConfig = tlsConfig
+	}
+
+	if !cfg.H2C {
+		return srv, nil
+	}
+
+	h2s := &http2.Server{
+		IdleTimeout: cfg.IdleTimeout,
+	}
+
+	// registers h2s for graceful shutdown along with srv
+	if err := http2.ConfigureServer(srv, h2s); err != nil {
+		return nil, fmt.Errorf("configure http2: %w", err)
+	}
+
+	srv.Handler = h2c.NewHandler(handler, h2s)
+
+	return srv, nil
+}
+
+// listenAndServe serves HTTPS if TLS is enabled and HTTP otherwise.
+func listenAndServe(srv *http.Server, cfg config.TLS) error {
+	if cfg.Enabled() {
+		return srv.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+	}
+
+	return srv.ListenAndServe()
+}
+
+// reloadConfig re-reads the config file and applies the settings
+// which may be changed without a restart.
+func reloadConfig(log *slog.Logger, readOnly *readonly.Mode, errorPages *errorpage.Pages, staticAliases *static.Storage) {
+	cfg, err := config.Load(os.Getenv("CONFIG_PATH"))
+	if err != nil {
+		log.Error("failed to reload config", sl.Err(err))
+
 		return
 	}
 
-	// TODO: close storage
+	readOnly.Set(cfg.ReadOnly)
 
-	log.Info("server stopped")
+	if err := errorPages.Reload(); err != nil {
+		log.Error("failed to reload error pages", sl.Err(err))
+	}
+
+	if staticAliases != nil {
+		if err := staticAliases.Reload(); err != nil {
+			log.Error("failed to reload static aliases", sl.Err(err))
+		}
+	}
+
+	log.Info("config reloaded", slog.Bool("read_only", cfg.ReadOnly))
 }
 
-func setupLogger(env string) *slog.Logger {
+// closeStorage closes the storage and returns the process exit code.
+// A close error is only fatal if failOnError is set.
+func closeStorage(log *slog.Logger, storage io.Closer, failOnError bool) int {
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage", sl.Err(err))
+
+		if failOnError {
+			return 1
+		}
+
+		return 0
+	}
+
+	log.Info("storage closed")
+
+	return 0
+}
+
+func setupLogger(env string, cfg config.Log) *slog.Logger {
 	var log *slog.Logger
 
 	switch env {
 	case envLocal:
-		log = setupPrettySlog()
+		log = setupPrettySlog(os.Stdout, cfg.FieldsFormat, cfg.Source)
 	case envDev:
 		log = slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}),
@@ -126,14 +745,453 @@ func setupLogger(env string) *slog.Logger {
 	return log
 }
 
-func setupPrettySlog() *slog.Logger {
+func setupCache(log *slog.Logger, workers *lifecycle.Manager, cfg config.Cache) cache.Cache {
+	switch cfg.Type {
+	case cacheTypeMemory:
+		return memory.New(cfg.TTL)
+	case cacheTypeRedis:
+		client := redis.New(cfg.Redis.Address, cfg.Redis.Timeout)
+		c := cacheRedis.New(client, cfg.TTL, cfg.LocalTTL, cfg.Redis.Channel)
+
+		workers.Go("cache invalidation", func(ctx context.Context) {
+			c.Listen(ctx, log)
+		})
+
+		return c
+	default:
+		return nil
+	}
+}
+
+// setupClickEvents starts sending sampled click events to the configured
+// sink. It returns nil if the events are disabled.
+func setupClickEvents(log *slog.Logger, workers *lifecycle.Manager, cfg config.ClickEvents, clock clock.Clock) (*clickevents.Sampler, error) {
+	var sink clickevents.Sink
+
+	switch cfg.Sink {
+	case clickSinkLog:
+		sink = clickevents.NewLogSink(log)
+	case clickSinkFile:
+		fileSink, err := clickevents.NewFileSink(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		sink = fileSink
+	case clickSinkWebhook:
+		sink = clickevents.NewWebhookSink(cfg.WebhookURL, cfg.WebhookTimeout)
+	default:
+		return nil, nil
+	}
+
+	sampler := clickevents.New(log, sink, cfg.SampleRate, clock)
+
+	workers.Go("click events", func(ctx context.Context) {
+		sampler.Run(ctx)
+
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Error("failed to close click events sink", sl.Err(err))
+			}
+		}
+	})
+
+	return sampler, nil
+}
+
+// allowRoutes answers HEAD and OPTIONS on pattern with the Allow header
+// listing methods.
+func allowRoutes(r chi.Router, pattern string, methods ...string) {
+	handler := allow.New(methods...)
+
+	r.Head(pattern, handler)
+	r.Options(pattern, handler)
+}
+
+// urlHandlers are the handlers changing urls under /url.
+type urlHandlers struct {
+	save    http.Handler
+	upsert  http.Handler
+	delete  http.Handler
+	tags    http.Handler
+	reserve http.Handler
+	release http.Handler
+}
+
+// urlRoutes registers the enabled handlers on protected and answers
+// HEAD and OPTIONS for them on public, without credentials, e.g. for
+// CORS preflights. Disabled routes are not registered at all.
+func urlRoutes(public chi.Router, protected chi.Router, cfg config.Handlers, h urlHandlers) {
+	if config.Enabled(cfg.SaveEnabled) {
+		allowRoutes(public, "/", http.MethodPost)
+		protected.Post("/", h.save.ServeHTTP)
+	}
+
+	var aliasMethods []string
+	if config.Enabled(cfg.UpsertEnabled) {
+		aliasMethods = append(aliasMethods, http.MethodPut)
+		protected.Put("/{alias}", h.upsert.ServeHTTP)
+	}
+	if config.Enabled(cfg.DeleteEnabled) {
+		aliasMethods = append(aliasMethods, http.MethodDelete)
+		protected.Delete("/{alias}", h.delete.ServeHTTP)
+	}
+	if len(aliasMethods) > 0 {
+		allowRoutes(public, "/{alias}", aliasMethods...)
+	}
+
+	if config.Enabled(cfg.TagsEnabled) {
+		allowRoutes(public, "/{alias}/tags", http.MethodPatch)
+		protected.Patch("/{alias}/tags", h.tags.ServeHTTP)
+	}
+
+	if config.Enabled(cfg.ReserveEnabled) {
+		allowRoutes(public, "/{alias}/reserve", http.MethodPost, http.MethodDelete)
+		protected.Post("/{alias}/reserve", h.reserve.ServeHTTP)
+		protected.Delete("/{alias}/reserve", h.release.ServeHTTP)
+	}
+}
+
+// adminUIRoutes serves the admin dashboard behind auth if it is enabled.
+// The static /admin routes take precedence over the alias ones.
+func adminUIRoutes(router chi.Router, cfg config.AdminUI, auth func(http.Handler) http.Handler) {
+	if !cfg.Enabled {
+		return
+	}
+
+	ui := auth(securecookies.New(cookie.NewPolicy(!cfg.InsecureCookies))(adminui.New()))
+
+	router.Handle(adminui.Prefix, ui)
+	router.Handle(adminui.Prefix+"/*", ui)
+}
+
+// maxRouteConflicts bounds the number of shadowed aliases reported on startup.
+const maxRouteConflicts = 100
+
+// checkRouteConflicts warns about stored aliases which are never
+// redirected because a route takes precedence, e.g. one added after
+// they were saved. With fail, such aliases are an error.
+func checkRouteConflicts(log *slog.Logger, router chi.Routes, finder routeconflict.AliasFinder, fail bool) error {
+	aliases, err := routeconflict.Find(router, finder, maxRouteConflicts)
+	if err != nil {
+		log.Error("failed to check aliases shadowed by routes", sl.Err(err))
+
+		return nil
+	}
+
+	for _, alias := range aliases {
+		log.Warn("alias is shadowed by a route", slog.String("alias", alias))
+	}
+
+	if fail && len(aliases) > 0 {
+		return fmt.Errorf("%d aliases, e.g. %q, are shadowed by routes", len(aliases), aliases[0])
+	}
+
+	return nil
+}
+
+// trailingSlash returns the middleware applying the trailing slash
+// policy to all routes, or nil if paths are routed as they are.
+func trailingSlash(policy string) func(http.Handler) http.Handler {
+	switch policy {
+	case config.TrailingSlashStrip:
+		return middleware.StripSlashes
+	case config.TrailingSlashRedirect:
+		return middleware.RedirectSlashes
+	default:
+		return nil
+	}
+}
+
+// redirectRoutes registers the alias redirects. With trailingSlash
+// a single trailing slash is ignored, e.g. "/abc/" is served as "/abc".
+func redirectRoutes(router chi.Router, handler http.HandlerFunc, trailingSlash bool) {
+	router.Get("/{alias}", handler)
+	router.Get("/{namespace}/{alias}", handler)
+
+	if trailingSlash {
+		router.Get("/{alias}/", handler)
+		router.Get("/{namespace}/{alias}/", handler)
+	}
+}
+
+// requestLoggers returns the middlewares logging every request.
+func requestLoggers(log *slog.Logger, cfg config.Log) []func(http.Handler) http.Handler {
+	var mws []func(http.Handler) http.Handler
+
+	if cfg.UseChiLogger {
+		mws = append(mws, middleware.Logger)
+	}
+
+	return append(mws, mwLogger.New(log, mwLogger.WithSlowThreshold(cfg.SlowlogThreshold)))
+}
+
+// setupAliasFilter returns nil if the profanity filter is off.
+func setupAliasFilter(cfg config.Alias) (save.AliasFilter, error) {
+	if cfg.ProfanityMode == profanityOff {
+		return nil, nil
+	}
+
+	if cfg.ProfanityFile != "" {
+		return profanity.Load(cfg.ProfanityFile, cfg.ProfanityMode, cfg.ProfanityWords...)
+	}
+
+	return profanity.New(cfg.ProfanityMode, cfg.ProfanityWords...)
+}
+
+// setupAliasGenerator returns nil for random aliases, which are
+// generated by the save handler itself.
+func setupAliasGenerator(cfg config.Alias) (save.AliasGenerator, error) {
+	switch cfg.Strategy {
+	case aliasStrategyRandom, aliasStrategySequential, "":
+		return nil, nil
+	case aliasStrategyWordlist:
+		if cfg.WordlistFile != "" {
+			return wordlist.Load(cfg.WordlistFile, cfg.Words)
+		}
+
+		return wordlist.New(cfg.Words), nil
+	default:
+		return nil, fmt.Errorf("unknown alias strategy %q", cfg.Strategy)
+	}
+}
+
+// logAliasEntropy reports the entropy and namespace size of random
+// aliases and warns if they are short enough to be guessed. Check
+// characters add no entropy and are not counted.
+func logAliasEntropy(log *slog.Logger, cfg config.Alias) {
+	usesRandom := cfg.Strategy == aliasStrategyRandom || cfg.Strategy == "" || cfg.StrategyOverride
+	if !usesRandom {
+		return
+	}
+
+	charset := cfg.Charset
+	if charset == "" {
+		charset = random.DefaultCharset
+	}
+
+	bits := random.Entropy(cfg.Length, charset)
+
+	attrs := []any{
+		slog.Int("length", cfg.Length),
+		slog.Int("charset_size", utf8.RuneCountInString(charset)),
+		slog.String("entropy_bits", strconv.FormatFloat(bits, 'f', 1, 64)),
+		slog.String("namespace", random.Namespace(cfg.Length, charset).String()),
+	}
+
+	log.Info("alias entropy", attrs...)
+
+	if bits < cfg.MinEntropyBits {
+		log.Warn("random aliases may be guessed, increase the length or charset",
+			slog.Float64("min_entropy_bits", cfg.MinEntropyBits),
+		)
+	}
+}
+
+// setupSequentialAliases returns nil unless aliases are sequential.
+func setupSequentialAliases(cfg config.Alias, saver save.SequentialSaver) save.SequentialSaver {
+	if cfg.Strategy != aliasStrategySequential {
+		return nil
+	}
+
+	return saver
+}
+
+// setupAliasStrategies returns the strategies clients may pick per
+// request, nil unless overrides are enabled.
+func setupAliasStrategies(cfg config.Alias, saver save.SequentialSaver) (map[string]save.Strategy, error) {
+	if !cfg.StrategyOverride {
+		return nil, nil
+	}
+
+	wordlistCfg := cfg
+	wordlistCfg.Strategy = aliasStrategyWordlist
+
+	generator, err := setupAliasGenerator(wordlistCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]save.Strategy{
+		aliasStrategyRandom:     {},
+		aliasStrategyWordlist:   {Generator: generator},
+		aliasStrategySequential: {Sequential: saver},
+	}, nil
+}
+
+// setupAliasAutoscaler returns nil if autoscaling is disabled.
+func setupAliasAutoscaler(log *slog.Logger, cfg config.Alias, store aliaslen.Store) save.AliasAutoscaler {
+	if !cfg.Autoscale {
+		return nil
+	}
+
+	return aliaslen.New(log, cfg.Length, cfg.AutoscaleMax, cfg.AutoscaleThreshold, cfg.AutoscaleWindow,
+		aliaslen.WithStore(store),
+	)
+}
+
+func setupAliasValidator(cfg config.Alias) aliaspolicy.Validator {
+	v := aliaspolicy.Default(cfg.CustomMin, cfg.CustomMax)
+
+	if len(cfg.Blocklist) > 0 {
+		v = aliaspolicy.Chain(v, aliaspolicy.Blocklist(cfg.Blocklist...))
+	}
+
+	return v
+}
+
+// collisionsRecorded is the metric counting alias collisions.
+const collisionsRecorded = "alias_collisions_total"
+
+// collisionCounter counts alias collisions in the metrics sink
+// in addition to recording them.
+type collisionCounter struct {
+	save.CollisionRecorder
+	sink metrics.Sink
+}
+
+func (c collisionCounter) RecordCollision(at time.Time) error {
+	c.sink.Count(collisionsRecorded, 1)
+
+	return c.CollisionRecorder.RecordCollision(at)
+}
+
+// setupMetrics returns the sink of cfg.Backend and, for backends scraped
+// over HTTP, the handler to serve at /metrics.
+func setupMetrics(cfg config.Metrics) (metrics.Sink, http.Handler, error) {
+	switch cfg.Backend {
+	case config.MetricsPrometheus:
+		p := metrics.NewPrometheus(cfg.Prefix)
+
+		return p, p, nil
+	case config.MetricsStatsD:
+		s, err := metrics.NewStatsD(cfg.StatsDAddr, cfg.Prefix)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return s, nil, nil
+	default:
+		return metrics.Nop{}, nil, nil
+	}
+}
+
+// setupTracer returns nil if tracing is disabled, which makes all tracing a no-op.
+func setupTracer(log *slog.Logger, cfg config.Tracing) *tracing.Tracer {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	exporter := tracing.NewOTLPExporter(cfg.Endpoint, cfg.Timeout)
+
+	return tracing.New(log, exporter, cfg.SampleRatio)
+}
+
+// maxRedirectRules is the number of redirect rules a url may be saved
+// with, zero if they are disabled.
+func maxRedirectRules(cfg config.RedirectRules) int {
+	if !cfg.Enabled {
+		return 0
+	}
+
+	return cfg.Max
+}
+
+// runConfigCheck loads and validates the config at path without starting
+// anything, printing the problems found to w. It returns the exit code.
+func runConfigCheck(w io.Writer, path string) int {
+	if path == "" {
+		fmt.Fprintln(w, "CONFIG_PATH is not set")
+
+		return 1
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		fmt.Fprintf(w, "config %s is invalid:\n  - %v\n", path, err)
+
+		return 1
+	}
+
+	problems := configProblems(cfg)
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "config %s is invalid:\n", path)
+		for _, p := range problems {
+			fmt.Fprintf(w, "  - %v\n", p)
+		}
+
+		return 1
+	}
+
+	fmt.Fprintf(w, "config %s is valid\n", path)
+
+	return 0
+}
+
+// configProblems finds settings which config.Load accepts, but which
+// would fail or be ignored on startup.
+func configProblems(cfg *config.Config) []error {
+	var problems []error
+
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		problems = append(problems, fmt.Errorf("http_server.address: %w", err))
+	}
+
+	if err := slogpretty.FieldsFormat(cfg.Log.FieldsFormat).Validate(); err != nil {
+		problems = append(problems, fmt.Errorf("log.fields_format: %w", err))
+	}
+
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"http_server.timeout", cfg.HTTPServer.Timeout},
+		{"http_server.idle_timeout", cfg.HTTPServer.IdleTimeout},
+		{"http_server.shutdown_timeout", cfg.ShutdownTimeout},
+		{"verify.timeout", cfg.Verify.Timeout},
+		{"import.resolve_timeout", cfg.Import.ResolveTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			problems = append(problems, fmt.Errorf("%s must be positive, got %v", t.name, t.value))
+		}
+	}
+
+	if cfg.BaseURL != "" {
+		if u, err := url.Parse(cfg.BaseURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Errorf("base_url must be an absolute http(s) URL, got %q", cfg.BaseURL))
+		}
+	}
+
+	return problems
+}
+
+// setupPrettySlog falls back to the json fields format if format is invalid,
+// since otherwise every log line would fail to be written.
+func setupPrettySlog(out io.Writer, format string, source bool) *slog.Logger {
+	fieldsFormat := slogpretty.FieldsFormat(format)
+
+	formatErr := fieldsFormat.Validate()
+	if formatErr != nil {
+		fieldsFormat = slogpretty.FieldsFormatJSON
+	}
+
 	opts := slogpretty.PrettyHandlerOptions{
 		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
+			Level:     slog.LevelDebug,
+			AddSource: source,
 		},
+		FieldsFormat:    fieldsFormat,
+		SourceRequestID: source,
+		SourceGoroutine: source,
 	}
 
-	handler := opts.NewPrettyHandler(os.Stdout)
+	handler := opts.NewPrettyHandler(out)
+
+	log := slog.New(handler)
 
-	return slog.New(handler)
+	if formatErr != nil {
+		log.Warn("invalid log fields format, falling back to json", sl.Err(formatErr))
+	}
+
+	return log
 }
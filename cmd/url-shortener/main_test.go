@@ -0,0 +1,726 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	stdlog "log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+	"golang.org/x/net/http2"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/metrics"
+	"url-shortener/internal/lib/scheduler"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func TestCloseStorage(t *testing.T) {
+	failing := closerFunc(func() error { return errors.New("checkpoint failed") })
+	ok := closerFunc(func() error { return nil })
+
+	log := slogdiscard.NewDiscardLogger()
+
+	assert.Equal(t, 0, closeStorage(log, ok, true))
+	assert.Equal(t, 0, closeStorage(log, failing, false))
+	assert.Equal(t, 1, closeStorage(log, failing, true))
+}
+
+func TestNewServer_H2C(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	srv, err := newServer(config.HTTPServer{
+		Timeout:     time.Second,
+		IdleTimeout: time.Second,
+		H2C:         true,
+	}, handler)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = srv.Serve(ln) }()
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.Equal(t, "HTTP/2.0", string(body))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, srv.Shutdown(ctx))
+}
+
+func TestNewServer_TLS(t *testing.T) {
+	srv, err := newServer(config.HTTPServer{
+		TLS: config.TLS{CertFile: "cert.pem", KeyFile: "key.pem", MinVersion: "1.3"},
+	}, http.NotFoundHandler())
+	require.NoError(t, err)
+	require.NotNil(t, srv.TLSConfig)
+	assert.Equal(t, uint16(tls.VersionTLS13), srv.TLSConfig.MinVersion)
+
+	_, err = newServer(config.HTTPServer{
+		TLS: config.TLS{CertFile: "cert.pem", KeyFile: "key.pem", MinVersion: "1.1"},
+	}, http.NotFoundHandler())
+	assert.Error(t, err)
+
+	srv, err = newServer(config.HTTPServer{}, http.NotFoundHandler())
+	require.NoError(t, err)
+	assert.Nil(t, srv.TLSConfig)
+}
+
+func TestNewServer_MaxHeaderBytes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv, err := newServer(config.HTTPServer{
+		Timeout:        time.Second,
+		IdleTimeout:    time.Second,
+		MaxHeaderBytes: 1024,
+	}, handler)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = srv.Serve(ln) }()
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String(), nil)
+	require.NoError(t, err)
+	// The server allows some slack over MaxHeaderBytes, so go well past it.
+	req.Header.Set("X-Big", strings.Repeat("a", 16<<10))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, srv.Shutdown(ctx))
+}
+
+func TestSetupPrettySlog_InvalidFieldsFormat(t *testing.T) {
+	var out bytes.Buffer
+
+	log := setupPrettySlog(&out, "xml", false)
+
+	assert.Contains(t, out.String(), "invalid log fields format, falling back to json")
+	assert.Contains(t, out.String(), `unknown fields format \"xml\"`)
+
+	out.Reset()
+	log.Info("hello", slog.String("key", "value"))
+
+	assert.Contains(t, out.String(), "hello")
+	assert.Contains(t, out.String(), `"key": "value"`)
+}
+
+func TestSetupPrettySlog_TextFieldsFormat(t *testing.T) {
+	var out bytes.Buffer
+
+	log := setupPrettySlog(&out, "text", false)
+	log.Info("hello", slog.String("key", "value"))
+
+	assert.NotContains(t, out.String(), "falling back")
+	assert.Contains(t, out.String(), "key=value")
+}
+
+func TestRequestLoggers(t *testing.T) {
+	var out bytes.Buffer
+
+	defaultLogger := middleware.DefaultLogger
+	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{
+		Logger:  stdlog.New(&out, "", 0),
+		NoColor: true,
+	})
+	t.Cleanup(func() { middleware.DefaultLogger = defaultLogger })
+
+	log := slog.New(slog.NewJSONHandler(&out, nil))
+
+	cases := []struct {
+		name         string
+		useChiLogger bool
+		wantLines    int
+	}{
+		{name: "Chi logger disabled", wantLines: 1},
+		{name: "Chi logger enabled", useChiLogger: true, wantLines: 2},
+	}
+
+	for _, tc := range cases {
+		out.Reset()
+
+		router := chi.NewRouter()
+		router.Use(requestLoggers(log, config.Log{UseChiLogger: tc.useChiLogger})...)
+		router.Get("/ping", func(w http.ResponseWriter, r *http.Request) {})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+		assert.Equal(t, tc.wantLines, strings.Count(out.String(), "/ping"), tc.name)
+	}
+}
+
+func TestRedirectRoutes_TrailingSlash(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		alias := chi.URLParam(r, "alias")
+		if ns := chi.URLParam(r, "namespace"); ns != "" {
+			alias = ns + "/" + alias
+		}
+
+		_, _ = w.Write([]byte(alias))
+	}
+
+	cases := []struct {
+		name          string
+		trailingSlash bool
+		path          string
+		wantCode      int
+		wantAlias     string
+	}{
+		{name: "Alias", path: "/abc", wantCode: http.StatusOK, wantAlias: "abc"},
+		{name: "Alias with slash", trailingSlash: true, path: "/abc/", wantCode: http.StatusOK, wantAlias: "abc"},
+		{name: "Namespaced alias with slash", trailingSlash: true, path: "/ns/abc/", wantCode: http.StatusOK, wantAlias: "ns/abc"},
+		{name: "Disabled", path: "/abc/", wantCode: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		router := chi.NewRouter()
+		redirectRoutes(router, handler, tc.trailingSlash)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		assert.Equal(t, tc.wantCode, rr.Code, tc.name)
+		if tc.wantAlias != "" {
+			assert.Equal(t, tc.wantAlias, rr.Body.String(), tc.name)
+		}
+	}
+}
+
+func TestTrailingSlash(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(chi.URLParam(r, "alias")))
+	}
+	save := func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("saved"))
+	}
+
+	cases := []struct {
+		name         string
+		policy       string
+		method       string
+		path         string
+		wantCode     int
+		wantBody     string
+		wantLocation string
+	}{
+		{name: "None alias", policy: config.TrailingSlashNone, method: http.MethodGet, path: "/abc/", wantCode: http.StatusNotFound},
+		{name: "None API", policy: config.TrailingSlashNone, method: http.MethodPost, path: "/url/", wantCode: http.StatusNotFound},
+		{name: "Strip alias", policy: config.TrailingSlashStrip, method: http.MethodGet, path: "/abc/", wantCode: http.StatusOK, wantBody: "abc"},
+		{name: "Strip API", policy: config.TrailingSlashStrip, method: http.MethodPost, path: "/url/", wantCode: http.StatusOK, wantBody: "saved"},
+		{name: "Strip keeps plain paths", policy: config.TrailingSlashStrip, method: http.MethodGet, path: "/abc", wantCode: http.StatusOK, wantBody: "abc"},
+		{name: "Redirect alias", policy: config.TrailingSlashRedirect, method: http.MethodGet, path: "/abc/", wantCode: http.StatusMovedPermanently, wantLocation: "//example.com/abc"},
+		{name: "Redirect API", policy: config.TrailingSlashRedirect, method: http.MethodPost, path: "/url/", wantCode: http.StatusMovedPermanently, wantLocation: "//example.com/url"},
+		{name: "Redirect keeps query", policy: config.TrailingSlashRedirect, method: http.MethodGet, path: "/abc/?pw=1", wantCode: http.StatusMovedPermanently, wantLocation: "//example.com/abc?pw=1"},
+	}
+
+	for _, tc := range cases {
+		router := chi.NewRouter()
+		if mw := trailingSlash(tc.policy); mw != nil {
+			router.Use(mw)
+		}
+		router.Post("/url", save)
+		redirectRoutes(router, handler, false)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		assert.Equal(t, tc.wantCode, rr.Code, tc.name)
+		if tc.wantBody != "" {
+			assert.Equal(t, tc.wantBody, rr.Body.String(), tc.name)
+		}
+		if tc.wantLocation != "" {
+			assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"), tc.name)
+		}
+	}
+}
+
+func TestLogAliasEntropy(t *testing.T) {
+	cases := []struct {
+		name      string
+		cfg       config.Alias
+		wantBits  string
+		wantSpace string
+		wantWarn  bool
+	}{
+		{
+			name:      "Default",
+			cfg:       config.Alias{Strategy: aliasStrategyRandom, Length: 6, MinEntropyBits: 32},
+			wantBits:  "35.7",
+			wantSpace: "56800235584",
+		},
+		{
+			name:      "Too short",
+			cfg:       config.Alias{Strategy: aliasStrategyRandom, Length: 4, MinEntropyBits: 32},
+			wantBits:  "23.8",
+			wantSpace: "14776336",
+			wantWarn:  true,
+		},
+		{
+			name:      "Custom charset",
+			cfg:       config.Alias{Strategy: aliasStrategyRandom, Length: 8, Charset: "0123456789abcdef", MinEntropyBits: 32},
+			wantBits:  "32.0",
+			wantSpace: "4294967296",
+		},
+		{
+			name:      "Override allows random aliases",
+			cfg:       config.Alias{Strategy: aliasStrategyWordlist, StrategyOverride: true, Length: 5, MinEntropyBits: 40},
+			wantBits:  "29.8",
+			wantSpace: "916132832",
+			wantWarn:  true,
+		},
+		{
+			name: "Wordlist",
+			cfg:  config.Alias{Strategy: aliasStrategyWordlist, Length: 1, MinEntropyBits: 32},
+		},
+	}
+
+	for _, tc := range cases {
+		var logs bytes.Buffer
+		logAliasEntropy(slog.New(slog.NewJSONHandler(&logs, nil)), tc.cfg)
+
+		if tc.wantBits == "" {
+			assert.Empty(t, logs.String(), tc.name)
+
+			continue
+		}
+
+		assert.Contains(t, logs.String(), `"entropy_bits":"`+tc.wantBits+`"`, tc.name)
+		assert.Contains(t, logs.String(), `"namespace":"`+tc.wantSpace+`"`, tc.name)
+		assert.Equal(t, tc.wantWarn, strings.Contains(logs.String(), `"level":"WARN"`), tc.name)
+	}
+}
+
+func TestSetupAliasGenerator(t *testing.T) {
+	g, err := setupAliasGenerator(config.Alias{Strategy: "random"})
+	require.NoError(t, err)
+	assert.Nil(t, g)
+
+	g, err = setupAliasGenerator(config.Alias{Strategy: "wordlist", Words: 2})
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z]+-[a-z]+-\d+$`, g.Generate())
+
+	_, err = setupAliasGenerator(config.Alias{Strategy: "uuid"})
+	assert.EqualError(t, err, `unknown alias strategy "uuid"`)
+}
+
+func TestSetupAliasStrategies(t *testing.T) {
+	strategies, err := setupAliasStrategies(config.Alias{Strategy: "random"}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, strategies)
+
+	strategies, err = setupAliasStrategies(config.Alias{Strategy: "random", StrategyOverride: true, Words: 2}, nil)
+	require.NoError(t, err)
+	assert.Len(t, strategies, 3)
+	assert.Regexp(t, `^[a-z]+-[a-z]+-\d+$`, strategies["wordlist"].Generator.Generate())
+}
+
+func TestSetupMetrics(t *testing.T) {
+	sink, handler, err := setupMetrics(config.Metrics{Backend: config.MetricsNone})
+	require.NoError(t, err)
+	assert.Equal(t, metrics.Nop{}, sink)
+	assert.Nil(t, handler)
+
+	sink, handler, err = setupMetrics(config.Metrics{Backend: config.MetricsPrometheus, Prefix: "shortener"})
+	require.NoError(t, err)
+	require.NotNil(t, handler)
+
+	sink.Count(collisionsRecorded, 1)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), "shortener_alias_collisions_total 1")
+}
+
+func TestMaintenanceJobs(t *testing.T) {
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	log := slogdiscard.NewDiscardLogger()
+
+	add := func(cfg config.Maintenance) []string {
+		jobs := scheduler.New(log, storage)
+		jobs.Add(optimizeJob(log, storage, cfg))
+		jobs.Add(purgeExpiredJob(log, storage, cfg))
+		jobs.Add(auditRetentionJob(log, storage, cfg, clock.Real{}))
+
+		return jobs.Jobs()
+	}
+
+	assert.Empty(t, add(config.Maintenance{}))
+	assert.Equal(t, []string{"purge_expired", "audit_retention"}, add(config.Maintenance{
+		PurgeExpiredInterval:   time.Hour,
+		AuditRetentionInterval: time.Hour,
+		AuditRetention:         time.Hour,
+	}))
+}
+
+func TestAuditRetentionJob(t *testing.T) {
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for _, age := range []time.Duration{2 * time.Hour, 30 * time.Minute} {
+		require.NoError(t, store.AuditLog(storage.AuditEntry{
+			Actor:     "admin",
+			Action:    "save",
+			Alias:     "test_alias",
+			Result:    "success",
+			CreatedAt: now.Add(-age),
+		}))
+	}
+
+	job := auditRetentionJob(slogdiscard.NewDiscardLogger(), store, config.Maintenance{
+		AuditRetention: time.Hour,
+	}, clock.NewFake(now))
+	require.NoError(t, job.Run(context.Background()))
+
+	entries, err := store.AuditEntries(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].CreatedAt.Equal(now.Add(-30*time.Minute)))
+}
+
+func TestSharedClockExpiry(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	store, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+	defer store.Close()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), store, save.WithClock(clk))
+
+	req := httptest.NewRequest(http.MethodPost, "/url",
+		strings.NewReader(`{"url": "https://example.com", "alias": "expiring", "ttl": "1h"}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	_, err = store.GetURL("expiring")
+	require.NoError(t, err)
+
+	clk.Advance(time.Hour)
+
+	_, err = store.GetURL("expiring")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound, "expired by the same clock it was saved with")
+}
+
+func TestCheckRouteConflicts(t *testing.T) {
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	defer storage.Close()
+
+	_, err = storage.SaveURL("https://example.com", "stats")
+	require.NoError(t, err)
+	_, err = storage.SaveURL("https://example.com", "kept")
+	require.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Get("/stats", func(http.ResponseWriter, *http.Request) {})
+	router.Get("/{alias}", func(http.ResponseWriter, *http.Request) {})
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	require.NoError(t, checkRouteConflicts(log, router, storage, false))
+	assert.Contains(t, logs.String(), `"msg":"alias is shadowed by a route","alias":"stats"`)
+	assert.NotContains(t, logs.String(), "kept")
+
+	assert.EqualError(t, checkRouteConflicts(log, router, storage, true),
+		`1 aliases, e.g. "stats", are shadowed by routes`)
+}
+
+func TestSetupAliasFilter(t *testing.T) {
+	f, err := setupAliasFilter(config.Alias{ProfanityMode: "off"})
+	require.NoError(t, err)
+	assert.Nil(t, f)
+
+	f, err = setupAliasFilter(config.Alias{ProfanityMode: "substring", ProfanityWords: []string{"frak"}})
+	require.NoError(t, err)
+	assert.True(t, f.Blocked("xfrakx"))
+	assert.True(t, f.Blocked("sh1t"))
+
+	f, err = setupAliasFilter(config.Alias{ProfanityMode: "word"})
+	require.NoError(t, err)
+	assert.False(t, f.Blocked("scunthorpe"))
+
+	_, err = setupAliasFilter(config.Alias{ProfanityMode: "fuzzy"})
+	assert.Error(t, err)
+}
+
+func TestAllowRoutes(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	router := chi.NewRouter()
+	router.Route("/url", func(r chi.Router) {
+		allowRoutes(r, "/", http.MethodPost)
+		allowRoutes(r, "/{alias}", http.MethodPut, http.MethodDelete)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.BasicAuth("url-shortener", map[string]string{"user": "pass"}))
+			r.Post("/", ok)
+			r.Put("/{alias}", ok)
+			r.Delete("/{alias}", ok)
+		})
+	})
+
+	cases := []struct {
+		path      string
+		wantAllow string
+	}{
+		{path: "/url", wantAllow: "HEAD, OPTIONS, POST"},
+		{path: "/url/abc", wantAllow: "DELETE, HEAD, OPTIONS, PUT"},
+	}
+
+	for _, tc := range cases {
+		for _, method := range []string{http.MethodHead, http.MethodOptions} {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(method, tc.path, nil))
+
+			assert.Equal(t, http.StatusNoContent, rr.Code, method+" "+tc.path)
+			assert.Equal(t, tc.wantAllow, rr.Header().Get("Allow"), method+" "+tc.path)
+		}
+	}
+
+	// the other methods still require credentials
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/url", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestURLRoutes_Disabled(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	off := false
+
+	router := chi.NewRouter()
+	router.Route("/url", func(public chi.Router) {
+		public.Group(func(r chi.Router) {
+			urlRoutes(public, r, config.Handlers{SaveEnabled: &off, DeleteEnabled: &off}, urlHandlers{
+				save:    ok,
+				upsert:  ok,
+				delete:  ok,
+				tags:    ok,
+				reserve: ok,
+				release: ok,
+			})
+		})
+	})
+	redirectRoutes(router, func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	}, false)
+
+	cases := []struct {
+		method    string
+		path      string
+		wantCode  int
+		wantAllow string
+	}{
+		{method: http.MethodPost, path: "/url", wantCode: http.StatusNotFound},
+		{method: http.MethodOptions, path: "/url", wantCode: http.StatusNotFound},
+		{method: http.MethodDelete, path: "/url/abc", wantCode: http.StatusMethodNotAllowed},
+		{method: http.MethodPut, path: "/url/abc", wantCode: http.StatusOK},
+		{method: http.MethodOptions, path: "/url/abc", wantCode: http.StatusNoContent, wantAllow: "HEAD, OPTIONS, PUT"},
+		{method: http.MethodPatch, path: "/url/abc/tags", wantCode: http.StatusOK},
+		{method: http.MethodPost, path: "/url/abc/reserve", wantCode: http.StatusOK},
+		{method: http.MethodGet, path: "/abc", wantCode: http.StatusFound},
+	}
+
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		assert.Equal(t, tc.wantCode, rr.Code, tc.method+" "+tc.path)
+		if tc.wantAllow != "" {
+			assert.Equal(t, tc.wantAllow, rr.Header().Get("Allow"), tc.method+" "+tc.path)
+		}
+	}
+}
+
+func TestAdminUIRoutes(t *testing.T) {
+	auth := middleware.BasicAuth("url-shortener", map[string]string{"user": "pass"})
+	// only "abc" exists
+	alias := func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "namespace") != "" || chi.URLParam(r, "alias") != "abc" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("alias abc"))
+	}
+
+	newRouter := func(enabled bool) chi.Router {
+		router := chi.NewRouter()
+		adminUIRoutes(router, config.AdminUI{Enabled: enabled}, auth)
+		redirectRoutes(router, alias, true)
+
+		return router
+	}
+
+	get := func(router chi.Router, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth("user", "pass")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		router := newRouter(true)
+
+		rr := get(router, "/admin/")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), "<title>url-shortener admin</title>")
+
+		rr = get(router, "/admin/app.js")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		rr = get(router, "/admin")
+		assert.Equal(t, http.StatusMovedPermanently, rr.Code)
+		assert.Equal(t, "/admin/", rr.Header().Get("Location"))
+
+		// credentials are required
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		// aliases are not shadowed
+		rr = get(router, "/abc")
+		assert.Equal(t, "alias abc", rr.Body.String())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		router := newRouter(false)
+
+		for _, path := range []string{"/admin/", "/admin/app.js"} {
+			assert.Equal(t, http.StatusNotFound, get(router, path).Code, path)
+		}
+	})
+}
+
+func TestRunConfigCheck(t *testing.T) {
+	const base = `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+`
+
+	cases := []struct {
+		name      string
+		config    string
+		wantCode  int
+		wantInOut []string
+	}{
+		{
+			name:      "Valid",
+			config:    base,
+			wantCode:  0,
+			wantInOut: []string{"is valid"},
+		},
+		{
+			name:      "Load error",
+			config:    base + "metrics:\n  backend: graphite\n",
+			wantCode:  1,
+			wantInOut: []string{"is invalid", `unknown metrics backend "graphite"`},
+		},
+		{
+			name: "Startup problems",
+			config: `
+storage_path: "./storage.db"
+base_url: "sho.rt"
+http_server:
+  address: "8080"
+  user: "admin"
+  password: "secret"
+  timeout: -1s
+log:
+  fields_format: "xml"
+`,
+			wantCode: 1,
+			wantInOut: []string{
+				"http_server.address",
+				"log.fields_format",
+				"http_server.timeout must be positive",
+				"base_url must be an absolute http(s) URL",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.config), 0o600))
+
+			var out bytes.Buffer
+			code := runConfigCheck(&out, path)
+
+			assert.Equal(t, tc.wantCode, code)
+			for _, want := range tc.wantInOut {
+				assert.Contains(t, out.String(), want)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	assert.Equal(t, 1, runConfigCheck(&out, filepath.Join(t.TempDir(), "missing.yaml")))
+	assert.Equal(t, 1, runConfigCheck(&out, ""))
+}
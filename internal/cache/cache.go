@@ -0,0 +1,14 @@
+package cache
+
+import "errors"
+
+var ErrMiss = errors.New("cache miss")
+
+// Cache keeps alias -> url pairs in front of the storage.
+type Cache interface {
+	// Get returns ErrMiss if alias is not cached.
+	Get(alias string) (string, error)
+	Set(alias string, url string) error
+	// Delete evicts alias, including copies held by other replicas.
+	Delete(alias string) error
+}
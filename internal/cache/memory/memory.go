@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"url-shortener/internal/cache"
+)
+
+type entry struct {
+	url       string
+	expiresAt time.Time
+}
+
+// Cache is an in-process cache with per-entry TTL.
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]entry),
+	}
+}
+
+func (c *Cache) Get(alias string) (string, error) {
+	c.mu.RLock()
+	e, ok := c.entries[alias]
+	c.mu.RUnlock()
+
+	if !ok {
+		return "", cache.ErrMiss
+	}
+
+	if c.ttl > 0 && time.Now().After(e.expiresAt) {
+		c.mu.Lock()
+		delete(c.entries, alias)
+		c.mu.Unlock()
+
+		return "", cache.ErrMiss
+	}
+
+	return e.url, nil
+}
+
+func (c *Cache) Set(alias string, url string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[alias] = entry{
+		url:       url,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+
+	return nil
+}
+
+func (c *Cache) Delete(alias string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, alias)
+
+	return nil
+}
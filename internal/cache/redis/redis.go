@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/cache/memory"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/redis"
+)
+
+const keyPrefix = "url-shortener:alias:"
+
+// Client is the subset of redis commands used by the cache.
+type Client interface {
+	Get(key string) (string, error)
+	Set(key string, value string, ttl time.Duration) error
+	Del(key string) error
+	Publish(channel string, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// Cache shares aliases between replicas through redis. Every replica also
+// keeps a short-lived local copy of hot aliases; evictions are published
+// over a redis channel so that all replicas drop their local copies.
+type Cache struct {
+	client  Client
+	local   *memory.Cache
+	ttl     time.Duration
+	channel string
+}
+
+func New(client Client, ttl time.Duration, localTTL time.Duration, channel string) *Cache {
+	return &Cache{
+		client:  client,
+		local:   memory.New(localTTL),
+		ttl:     ttl,
+		channel: channel,
+	}
+}
+
+func (c *Cache) Get(alias string) (string, error) {
+	const op = "cache.redis.Get"
+
+	if url, err := c.local.Get(alias); err == nil {
+		return url, nil
+	}
+
+	url, err := c.client.Get(keyPrefix + alias)
+	if errors.Is(err, redis.ErrNil) {
+		return "", cache.ErrMiss
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	_ = c.local.Set(alias, url)
+
+	return url, nil
+}
+
+func (c *Cache) Set(alias string, url string) error {
+	const op = "cache.redis.Set"
+
+	_ = c.local.Set(alias, url)
+
+	if err := c.client.Set(keyPrefix+alias, url, c.ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *Cache) Delete(alias string) error {
+	const op = "cache.redis.Delete"
+
+	_ = c.local.Delete(alias)
+
+	if err := c.client.Del(keyPrefix + alias); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := c.client.Publish(c.channel, alias); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Listen evicts local copies of aliases invalidated by other replicas.
+// It blocks until ctx is done, re-subscribing after connection failures.
+func (c *Cache) Listen(ctx context.Context, log *slog.Logger) {
+	const op = "cache.redis.Listen"
+
+	log = log.With(slog.String("op", op))
+
+	for {
+		messages, err := c.client.Subscribe(ctx, c.channel)
+		if err != nil {
+			log.Warn("failed to subscribe to invalidations", sl.Err(err))
+		} else {
+			for alias := range messages {
+				_ = c.local.Delete(alias)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
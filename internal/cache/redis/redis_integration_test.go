@@ -0,0 +1,85 @@
+//go:build integration
+
+package redis_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	cacheRedis "url-shortener/internal/cache/redis"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/redis"
+)
+
+// Run with a redis container, e.g.
+//
+//	docker run -d -p 6379:6379 redis:7
+//	REDIS_ADDR=localhost:6379 go test -tags integration ./internal/cache/redis/
+func redisAddr(t *testing.T) string {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	return addr
+}
+
+func TestCache_RealRedis(t *testing.T) {
+	addr := redisAddr(t)
+
+	client := redis.New(addr, time.Second)
+	defer func() { _ = client.Close() }()
+
+	subscriber := redis.New(addr, time.Second)
+	defer func() { _ = subscriber.Close() }()
+
+	const channel = "url-shortener:test:invalidate"
+
+	replicaA := cacheRedis.New(client, time.Minute, time.Minute, channel)
+	replicaB := cacheRedis.New(subscriber, time.Minute, time.Minute, channel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go replicaB.Listen(ctx, slogdiscard.NewDiscardLogger())
+
+	require.NoError(t, replicaA.Set("test-alias", "https://example.com"))
+
+	got, err := replicaB.Get("test-alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	// give the subscription time to be established
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, replicaA.Delete("test-alias"))
+
+	require.Eventually(t, func() bool {
+		_, err := replicaB.Get("test-alias")
+		return err == cache.ErrMiss
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCache_RealRedisExpiry(t *testing.T) {
+	client := redis.New(redisAddr(t), time.Second)
+	defer func() { _ = client.Close() }()
+
+	require.NoError(t, client.Set("url-shortener:test:expiring", "https://example.com", 100*time.Millisecond))
+
+	got, err := client.Get("url-shortener:test:expiring")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	require.Eventually(t, func() bool {
+		_, err := client.Get("url-shortener:test:expiring")
+		return err == redis.ErrNil
+	}, time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,142 @@
+package redis_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	cacheRedis "url-shortener/internal/cache/redis"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/redis"
+)
+
+// fakeRedis is an in-memory redis shared by several clients.
+type fakeRedis struct {
+	mu          sync.Mutex
+	data        map[string]string
+	subscribers map[string][]chan string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{
+		data:        make(map[string]string),
+		subscribers: make(map[string][]chan string),
+	}
+}
+
+func (f *fakeRedis) Get(key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	v, ok := f.data[key]
+	if !ok {
+		return "", redis.ErrNil
+	}
+
+	return v, nil
+}
+
+func (f *fakeRedis) Set(key string, value string, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.data[key] = value
+
+	return nil
+}
+
+func (f *fakeRedis) Del(key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.data, key)
+
+	return nil
+}
+
+func (f *fakeRedis) Publish(channel string, message string) error {
+	f.mu.Lock()
+	subs := f.subscribers[channel]
+	f.mu.Unlock()
+
+	for _, sub := range subs {
+		sub <- message
+	}
+
+	return nil
+}
+
+func (f *fakeRedis) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	ch := make(chan string, 10)
+
+	f.mu.Lock()
+	f.subscribers[channel] = append(f.subscribers[channel], ch)
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		close(ch)
+		f.subscribers[channel] = nil
+	}()
+
+	return ch, nil
+}
+
+func (f *fakeRedis) subscribed(channel string, n int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.subscribers[channel]) == n
+}
+
+func TestCache_Hit(t *testing.T) {
+	c := cacheRedis.New(newFakeRedis(), time.Minute, time.Minute, "invalidate")
+
+	_, err := c.Get("alias")
+	require.ErrorIs(t, err, cache.ErrMiss)
+
+	require.NoError(t, c.Set("alias", "https://example.com"))
+
+	got, err := c.Get("alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+}
+
+func TestCache_CrossInstanceInvalidation(t *testing.T) {
+	const channel = "invalidate"
+
+	shared := newFakeRedis()
+
+	replicaA := cacheRedis.New(shared, time.Minute, time.Minute, channel)
+	replicaB := cacheRedis.New(shared, time.Minute, time.Minute, channel)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go replicaB.Listen(ctx, slogdiscard.NewDiscardLogger())
+
+	require.Eventually(t, func() bool { return shared.subscribed(channel, 1) }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, replicaA.Set("alias", "https://example.com"))
+
+	// replica B keeps a local copy after the first read
+	got, err := replicaB.Get("alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+
+	require.NoError(t, replicaA.Delete("alias"))
+
+	require.Eventually(t, func() bool {
+		_, err := replicaB.Get("alias")
+		return err == cache.ErrMiss
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,53 @@
+package warmer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/storage"
+)
+
+// Source is an interface for getting the most clicked urls.
+type Source interface {
+	MostClicked(ctx context.Context, limit int) ([]storage.URL, error)
+}
+
+// Warm preloads the topN most clicked aliases into c, so the first
+// redirects after startup don't all miss the cache.
+func Warm(ctx context.Context, log *slog.Logger, c cache.Cache, src Source, topN int) error {
+	const op = "cache.warmer.Warm"
+
+	log = log.With(slog.String("op", op))
+
+	start := time.Now()
+
+	urls, err := src.MostClicked(ctx, topN)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	warmed := 0
+
+	for _, u := range urls {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := c.Set(u.Alias, u.URL); err != nil {
+			return fmt.Errorf("%s: set %s: %w", op, u.Alias, err)
+		}
+
+		warmed++
+	}
+
+	log.Info("cache warmed",
+		slog.Int("aliases", warmed),
+		slog.Duration("duration", time.Since(start)),
+	)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package warmer_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/cache/memory"
+	"url-shortener/internal/cache/warmer"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func TestWarm(t *testing.T) {
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	clicks := map[string]int{"a": 3, "b": 5, "c": 1, "d": 0, "once": 10}
+	for alias, n := range clicks {
+		var opts []storage.SaveOption
+		if alias == "once" {
+			opts = append(opts, storage.WithOneTime())
+		}
+
+		_, err := s.SaveURL("https://"+alias+".com", alias, opts...)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, s.RecordClick(alias))
+		}
+	}
+
+	c := memory.New(time.Hour)
+
+	require.NoError(t, warmer.Warm(context.Background(), slogdiscard.NewDiscardLogger(), c, s, 2))
+
+	for alias, want := range map[string]string{"b": "https://b.com", "a": "https://a.com"} {
+		got, err := c.Get(alias)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	for _, alias := range []string{"c", "d", "once"} {
+		_, err := c.Get(alias)
+		assert.ErrorIs(t, err, cache.ErrMiss, alias)
+	}
+}
@@ -1,25 +1,531 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+
+	"url-shortener/internal/lib/random"
 )
 
 type Config struct {
 	Env         string `yaml:"env" env-default:"local"`
 	StoragePath string `yaml:"storage_path" env-required:"true"`
-	HTTPServer  `yaml:"http_server"`
+	// DB configures the connection pool of StoragePath.
+	DB DB `yaml:"db"`
+	// Fallback is queried for aliases missing from StoragePath.
+	Fallback Fallback `yaml:"fallback"`
+	// CreateDirs creates the missing parent directory of StoragePath.
+	CreateDirs bool `yaml:"create_dirs" env-default:"false"`
+	HTTPServer `yaml:"http_server"`
+	// Users are additional API credentials besides the HTTPServer one.
+	Users []User `yaml:"users"`
+	// AliasQuota is the number of links each of Users may own.
+	// Zero means unlimited. The HTTPServer user is never limited.
+	AliasQuota int64 `yaml:"alias_quota" env-default:"0"`
+	// ReservationTTL is how long POST /url/{alias}/reserve holds an alias.
+	ReservationTTL time.Duration `yaml:"reservation_ttl" env-default:"10m"`
+	// MaxTags is the number of tags a url may have.
+	MaxTags int `yaml:"max_tags" env-default:"10"`
+	// Health configures GET /readyz.
+	Health Health `yaml:"health"`
+	// AuditMaxLimit caps the page size of GET /audit.
+	AuditMaxLimit int `yaml:"audit_max_limit" env-default:"500"`
+	// NotFoundRedirect is the URL users are sent to when an alias is not found.
+	// Empty value keeps the "not found" response.
+	NotFoundRedirect string `yaml:"not_found_redirect"`
+	// NotYetActivePage tells clients that an alias before its active_from
+	// time is not available yet, instead of the "not found" response.
+	NotYetActivePage bool `yaml:"not_yet_active_page" env-default:"false"`
+	// ErrorPagesDir holds custom HTML error page templates named after
+	// the status code, e.g. "404.html". They are re-read on SIGHUP.
+	ErrorPagesDir string `yaml:"error_pages_dir"`
+	// StaticAliases is a YAML file of aliases and their urls served
+	// along with the stored ones, e.g. vanity links. It is re-read on SIGHUP.
+	StaticAliases string `yaml:"static_aliases"`
+	// BaseURL is the public URL short links are served from, e.g. "https://sho.rt".
+	// Empty value means the scheme and host of the request.
+	BaseURL string `yaml:"base_url"`
+	// StrictHTTPStatus makes the API respond with REST status codes,
+	// e.g. 201 Created with a Location header on save.
+	StrictHTTPStatus bool `yaml:"strict_http_status" env-default:"false"`
+	// IdempotencyTTL is how long responses to POST /url with an
+	// Idempotency-Key header are replayed. Zero disables replays.
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env-default:"24h"`
+	// IdempotencyClaimTimeout is how long a request with an Idempotency-Key
+	// header may stay in progress. After it the key may be claimed again,
+	// so that retries aren't rejected for the whole TTL after a crash.
+	IdempotencyClaimTimeout time.Duration `yaml:"idempotency_claim_timeout" env-default:"1m"`
+	// RedirectAliasHeader sends the alias of redirects in the
+	// X-Shortener-Alias header, e.g. for client-side analytics.
+	RedirectAliasHeader bool `yaml:"redirect_alias_header" env-default:"false"`
+	// RedirectTrailingSlash makes "/abc/" redirect the same as "/abc".
+	RedirectTrailingSlash bool `yaml:"redirect_trailing_slash" env-default:"true"`
+	// TrailingSlash is the policy for a trailing slash on every route,
+	// API ones included: "none" routes paths as they are, "strip" serves
+	// "/url/" as "/url" and "redirect" sends 301 to the path without it.
+	// With "strip" or "redirect" RedirectTrailingSlash has no effect,
+	// since alias routes never see the slash.
+	TrailingSlash string `yaml:"trailing_slash" env-default:"none"`
+	// MaxResolveHops enables GET /url/{alias}/resolve, which follows chained
+	// short links up to this number of redirects. Zero disables it.
+	MaxResolveHops int    `yaml:"max_resolve_hops" env-default:"0"`
+	Cache          Cache  `yaml:"cache"`
+	Alias          Alias  `yaml:"alias"`
+	Verify         Verify `yaml:"verify"`
+	Import         Import `yaml:"import"`
+	// ClickEvents samples redirects to an analytics sink besides
+	// the click counters.
+	ClickEvents ClickEvents `yaml:"click_events"`
+	// Handlers turns off API routes, e.g. for a redirect-only instance
+	// facing the internet while another one serves the changes.
+	Handlers Handlers `yaml:"handlers"`
+	// RedirectRules send some clients of an alias to other targets.
+	RedirectRules RedirectRules `yaml:"redirect_rules"`
+	// RedirectDelay throttles redirects to partners that ask for it.
+	RedirectDelay RedirectDelay `yaml:"redirect_delay"`
+	// AllowedSchemes are the schemes of urls that may be saved.
+	AllowedSchemes []string `yaml:"allowed_schemes" env-default:"http,https"`
+	// AccelRedirect leaves redirects to a fronting nginx.
+	AccelRedirect AccelRedirect `yaml:"accel_redirect"`
+	// JSONNaming is the naming of keys in JSON responses,
+	// "snake_case" or "camelCase".
+	JSONNaming string `yaml:"json_naming" env-default:"snake_case"`
+	// IDsAsStrings makes url IDs serialized as JSON strings in responses.
+	IDsAsStrings bool `yaml:"ids_as_strings" env-default:"false"`
+	// ReadOnly rejects all changes while reads and redirects keep working.
+	// It is re-read on SIGHUP.
+	ReadOnly bool    `yaml:"read_only" env-default:"false"`
+	Tracing  Tracing `yaml:"tracing"`
+	Metrics  Metrics `yaml:"metrics"`
+
+	Maintenance Maintenance `yaml:"maintenance"`
+	AdminUI     AdminUI     `yaml:"admin_ui"`
+	Debug       Debug       `yaml:"debug"`
+	Log         Log         `yaml:"log"`
+	// FailOnStorageCloseError makes the process exit with a non-zero code
+	// if the storage fails to close on shutdown.
+	FailOnStorageCloseError bool `yaml:"fail_on_storage_close_error" env-default:"false"`
+	// FailOnRouteConflict refuses to start if stored aliases are shadowed
+	// by routes, instead of only logging them.
+	FailOnRouteConflict bool `yaml:"fail_on_route_conflict" env-default:"false"`
 }
 
 type HTTPServer struct {
 	Address     string        `yaml:"address" env-default:"localhost:8080"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// ShutdownTimeout bounds draining requests and then background
+	// workers on SIGTERM, each.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	User            string        `yaml:"user" env-required:"true"`
+	Password        string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	// H2C enables HTTP/2 over cleartext connections (without TLS).
+	H2C bool `yaml:"h2c" env-default:"false"`
+	// RequestIDHeader is the header an inbound request ID is taken from
+	// and echoed in.
+	RequestIDHeader string `yaml:"request_id_header" env-default:"X-Request-ID"`
+	// SecurityHeaders are sent with every response.
+	SecurityHeaders SecurityHeaders `yaml:"security_headers"`
+	// ForceHTTPS redirects insecure requests to https,
+	// respecting X-Forwarded-Proto set by one of TrustedProxies.
+	ForceHTTPS bool `yaml:"force_https" env-default:"false"`
+	// MaxHeaderBytes bounds the size of request headers, see http.Server.
+	MaxHeaderBytes int `yaml:"max_header_bytes" env-default:"65536"`
+	// MaxHeaderCount bounds the number of request header values.
+	// Zero disables it.
+	MaxHeaderCount int `yaml:"max_header_count" env-default:"100"`
+	// MaxConnsPerIP limits requests in flight per client IP. Zero disables it.
+	MaxConnsPerIP int `yaml:"max_conns_per_ip" env-default:"0"`
+	// TrustedProxies are CIDRs or IPs of reverse proxies whose
+	// X-Forwarded-For header is used to find the client IP, and whose
+	// X-Forwarded-Proto header is used by ForceHTTPS and for short URLs
+	// when BaseURL is empty.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	TLS            TLS      `yaml:"tls"`
+}
+
+// User is an API credential. Aliases created by a user with a Namespace
+// are prefixed with it, e.g. "acme/summer-sale", so that users can't
+// collide with or modify each other's aliases.
+type User struct {
+	Name      string `yaml:"name" env-required:"true"`
+	Password  string `yaml:"password" env-required:"true"`
+	Namespace string `yaml:"namespace"`
+	// Quota overrides AliasQuota for the user, zero means unlimited.
+	Quota *int64 `yaml:"quota"`
+}
+
+type Health struct {
+	// Timeout bounds the storage ping of GET /readyz.
+	Timeout time.Duration `yaml:"timeout" env-default:"1s"`
+}
+
+// HeaderOff disables a security header.
+const HeaderOff = "off"
+
+// SecurityHeaders are the values of security response headers.
+// A header set to HeaderOff is not sent.
+type SecurityHeaders struct {
+	ContentTypeOptions string `yaml:"content_type_options" env-default:"nosniff"`
+	FrameOptions       string `yaml:"frame_options" env-default:"DENY"`
+	ReferrerPolicy     string `yaml:"referrer_policy" env-default:"strict-origin-when-cross-origin"`
+	// ContentSecurityPolicy is not sent by default, since it depends
+	// on the error pages in use.
+	ContentSecurityPolicy string `yaml:"content_security_policy" env-default:"off"`
+}
+
+// Map returns the enabled headers by name.
+func (h SecurityHeaders) Map() map[string]string {
+	headers := make(map[string]string, 4)
+
+	for name, value := range map[string]string{
+		"X-Content-Type-Options":  h.ContentTypeOptions,
+		"X-Frame-Options":         h.FrameOptions,
+		"Referrer-Policy":         h.ReferrerPolicy,
+		"Content-Security-Policy": h.ContentSecurityPolicy,
+	} {
+		if value != HeaderOff {
+			headers[name] = value
+		}
+	}
+
+	return headers
+}
+
+type Alias struct {
+	// Length is the length of generated aliases.
+	Length int `yaml:"length" env-default:"6"`
+	// Charset is the runes of random aliases, ASCII letters and digits
+	// by default. It may include "-._~"; non-ASCII runes are rejected.
+	Charset string `yaml:"charset"`
+	// MinEntropyBits is the entropy of random aliases below which
+	// a warning is logged on startup, as they may be guessed.
+	MinEntropyBits float64 `yaml:"min_entropy_bits" env-default:"32"`
+	// CustomMin and CustomMax bound the length of aliases provided by users.
+	CustomMin int `yaml:"custom_alias_min" env-default:"3"`
+	CustomMax int `yaml:"custom_alias_max" env-default:"50"`
+	// Blocklist rejects custom aliases containing any of the words.
+	Blocklist []string `yaml:"blocklist"`
+	// Strategy of generated aliases, "random", "wordlist" or "sequential".
+	// Wordlist aliases are like "brave-otter-42" and ignore Length.
+	// Sequential aliases are base62 encoded sequence numbers.
+	Strategy string `yaml:"alias_strategy" env-default:"random"`
+	// StrategyOverride lets clients pick any of the strategies
+	// per request with the "strategy" field.
+	StrategyOverride bool `yaml:"strategy_override" env-default:"false"`
+	// Words is the number of words of wordlist aliases.
+	Words int `yaml:"words" env-default:"2"`
+	// WordlistFile replaces the embedded wordlist, one word per line.
+	WordlistFile string `yaml:"wordlist_file"`
+	// ProfanityMode regenerates generated aliases spelling offensive words,
+	// "substring", "word" (whole words only) or "off".
+	ProfanityMode string `yaml:"profanity_mode" env-default:"substring"`
+	// ProfanityFile replaces the embedded profanity list, one word per line.
+	ProfanityFile string `yaml:"profanity_file"`
+	// ProfanityWords are blocked in addition to the list.
+	ProfanityWords []string `yaml:"profanity_words"`
+	// Checksum appends a check character to new aliases, so that mistyped
+	// ones are rejected without a storage lookup. Aliases saved before it
+	// was enabled no longer resolve. Aliases of PUT /url/{alias} and imports
+	// must have a valid check character.
+	Checksum bool `yaml:"checksum" env-default:"false"`
+	// Autoscale increases Length by one, up to AutoscaleMax, when more than
+	// AutoscaleThreshold of generated aliases collide within AutoscaleWindow.
+	Autoscale          bool          `yaml:"autoscale" env-default:"false"`
+	AutoscaleMax       int           `yaml:"autoscale_max" env-default:"12"`
+	AutoscaleThreshold float64       `yaml:"autoscale_threshold" env-default:"0.01"`
+	AutoscaleWindow    time.Duration `yaml:"autoscale_window" env-default:"1h"`
+}
+
+// Verify configures the reachability check of POST /url?verify=true.
+type Verify struct {
+	Timeout time.Duration `yaml:"timeout" env-default:"3s"`
+	// AllowPrivate lets the check reach loopback and private addresses.
+	// Keep it off in production to prevent SSRF.
+	AllowPrivate bool `yaml:"allow_private" env-default:"false"`
+}
+
+// Import configures POST /urls/import. With "?resolve=true" links which
+// redirect are stored with their final url.
+type Import struct {
+	ResolveTimeout time.Duration `yaml:"resolve_timeout" env-default:"5s"`
+	// MaxRedirects is the number of redirects followed per link.
+	MaxRedirects int `yaml:"max_redirects" env-default:"5"`
+	// SkipUnresolved fails links which can't be resolved
+	// instead of storing them as is.
+	SkipUnresolved bool `yaml:"skip_unresolved" env-default:"false"`
+	// AllowPrivate lets resolving reach loopback and private addresses.
+	AllowPrivate bool `yaml:"allow_private" env-default:"false"`
+	// Duplicates handles links sharing an alias within one request:
+	// "fail" rejects the request, "first" or "last" keeps that link.
+	Duplicates string `yaml:"duplicates" env-default:"first"`
+}
+
+// ClickEvents configures sampled click events with the alias, time,
+// referrer and user agent. Sink is one of "none", "log", "file" or "webhook".
+type ClickEvents struct {
+	Sink string `yaml:"sink" env-default:"none"`
+	// SampleRate is the share of redirects emitted, from 0 to 1.
+	SampleRate float64 `yaml:"sample_rate" env-default:"0.01"`
+	// File is appended with events as JSON lines.
+	File string `yaml:"file"`
+	// WebhookURL receives each event as JSON in a POST request.
+	WebhookURL     string        `yaml:"webhook_url"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"5s"`
+}
+
+// Handlers flags the routes changing urls. Unset flags are on.
+type Handlers struct {
+	// SaveEnabled serves POST /url.
+	SaveEnabled *bool `yaml:"save_enabled"`
+	// UpsertEnabled serves PUT /url/{alias}.
+	UpsertEnabled *bool `yaml:"upsert_enabled"`
+	// DeleteEnabled serves DELETE /url/{alias}.
+	DeleteEnabled *bool `yaml:"delete_enabled"`
+	// TagsEnabled serves PATCH /url/{alias}/tags.
+	TagsEnabled *bool `yaml:"tags_enabled"`
+	// ReserveEnabled serves POST and DELETE /url/{alias}/reserve.
+	ReserveEnabled *bool `yaml:"reserve_enabled"`
+	// ImportEnabled serves POST /urls/import.
+	ImportEnabled *bool `yaml:"import_enabled"`
+	// ClicksSyncEnabled serves POST /urls/clicks/sync.
+	ClicksSyncEnabled *bool `yaml:"clicks_sync_enabled"`
+}
+
+// Enabled reports whether an optional flag is on, unset meaning on.
+func Enabled(flag *bool) bool {
+	return flag == nil || *flag
+}
+
+// RedirectRules let urls redirect by device, language or the country
+// a CDN detected, e.g. phones to an app store. They are off by default.
+type RedirectRules struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// CountryHeader is the header the CDN sets to the country of the client.
+	CountryHeader string `yaml:"country_header" env-default:"CF-IPCountry"`
+	// Max is the number of rules a url may have.
+	Max int `yaml:"max" env-default:"10"`
+}
+
+// AccelRedirect makes redirects respond with the target in Header
+// instead of a 302, for nginx to redirect with an internal location,
+// e.g. "location /internal/ { internal; ... }" with Prefix "/internal/".
+// It is off by default.
+type AccelRedirect struct {
+	Enabled bool   `yaml:"enabled" env-default:"false"`
+	Header  string `yaml:"header" env-default:"X-Accel-Redirect"`
+	Prefix  string `yaml:"prefix"`
+}
+
+// MaxRedirectDelay caps RedirectDelay values so a typo can't hold
+// redirect requests for minutes.
+const MaxRedirectDelay = 10 * time.Second
+
+// RedirectDelay is a pause before redirecting. A delay for the alias
+// wins over one for the target host, which wins over Default.
+type RedirectDelay struct {
+	Default time.Duration `yaml:"default" env-default:"0s"`
+	// Hosts maps lower case target hosts, e.g. "partner.com", to their delays.
+	Hosts map[string]time.Duration `yaml:"hosts"`
+	// Aliases maps aliases to their delays. Zero turns the delay off.
+	Aliases map[string]time.Duration `yaml:"aliases"`
+}
+
+func (d RedirectDelay) validate() error {
+	check := func(name string, v time.Duration) error {
+		if v < 0 || v > MaxRedirectDelay {
+			return fmt.Errorf("redirect delay for %s must be from 0 to %v, got %v", name, MaxRedirectDelay, v)
+		}
+		return nil
+	}
+
+	if err := check("default", d.Default); err != nil {
+		return err
+	}
+	for host, v := range d.Hosts {
+		if err := check("host "+host, v); err != nil {
+			return err
+		}
+	}
+	for alias, v := range d.Aliases {
+		if err := check("alias "+alias, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AdminUI serves a dashboard to create, list and delete links at /admin
+// behind basic auth.
+type AdminUI struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// InsecureCookies lets admin cookies be sent over plain http,
+	// for local development only. They are HttpOnly and SameSite either way.
+	InsecureCookies bool `yaml:"insecure_cookies" env-default:"false"`
+}
+
+// Debug enables optional diagnostic routes.
+type Debug struct {
+	// Pprof mounts the net/http/pprof handlers at /debug behind basic auth.
+	Pprof bool `yaml:"pprof" env-default:"false"`
+	// Runtime serves goroutine, memory and open fd counts
+	// at /debug/runtime for the admin user.
+	Runtime bool `yaml:"runtime" env-default:"false"`
+	// PrettyJSON indents all JSON responses. It is ignored in the prod env;
+	// elsewhere "?pretty=true" indents a single response regardless of it.
+	PrettyJSON bool `yaml:"pretty_json" env-default:"false"`
+}
+
+type Log struct {
+	// Bodies logs request and response bodies of the /url routes.
+	// It is ignored in the prod env.
+	Bodies bool `yaml:"bodies" env-default:"false"`
+	// FieldsFormat is the format of record fields in the local env,
+	// "json" or "text".
+	FieldsFormat string `yaml:"fields_format" env-default:"json"`
+	// Source prints the source of log calls in the local env along with
+	// the request ID and the goroutine ID.
+	Source bool `yaml:"source" env-default:"false"`
+	// SlowlogThreshold makes requests and storage queries taking longer
+	// logged as warnings. Zero disables it.
+	SlowlogThreshold time.Duration `yaml:"slowlog_threshold" env-default:"0"`
+	// UseChiLogger enables chi's middleware.Logger in addition
+	// to the structured request log.
+	UseChiLogger bool `yaml:"use_chi_logger" env-default:"false"`
+}
+
+// Cache configures the cache in front of the storage.
+// Type is one of "none", "memory" or "redis".
+type Cache struct {
+	Type string        `yaml:"type" env-default:"none"`
+	TTL  time.Duration `yaml:"ttl" env-default:"1h"`
+	// LocalTTL limits how long each replica keeps a local copy of redis entries.
+	LocalTTL time.Duration `yaml:"local_ttl" env-default:"10s"`
+	// WarmTopN preloads the most clicked aliases into the cache
+	// in the background on startup. Zero disables it.
+	WarmTopN int   `yaml:"warm_top_n" env-default:"0"`
+	Redis    Redis `yaml:"redis"`
+}
+
+type Redis struct {
+	Address string        `yaml:"address" env-default:"localhost:6379"`
+	Timeout time.Duration `yaml:"timeout" env-default:"500ms"`
+	// Channel is used to publish alias invalidations to all replicas.
+	Channel string `yaml:"channel" env-default:"url-shortener:invalidate"`
+}
+
+// DB recycles database connections, so that ones dropped behind
+// the scenes, e.g. on a network filesystem, are not reused. Zero, the
+// default, keeps connections forever: a local sqlite file gains nothing
+// from reopening it, while every new connection loses the per-connection
+// pragmas and prepared statement cache.
+type DB struct {
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"0"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"0"`
+}
+
+// Maintenance configures periodic maintenance jobs. Replicas sharing
+// the database take turns running each of them. A zero interval
+// disables a job. The storage may also be optimized once with
+// the -optimize flag.
+type Maintenance struct {
+	// OptimizeInterval is the period of optimization runs.
+	OptimizeInterval time.Duration `yaml:"optimize_interval" env-default:"0"`
+	// OptimizeMaxWrites skips a run if the storage handled more writes
+	// than that since the previous one.
+	OptimizeMaxWrites int64 `yaml:"optimize_max_writes" env-default:"100"`
+	// PurgeExpiredInterval is the period of deleting expired urls.
+	PurgeExpiredInterval time.Duration `yaml:"purge_expired_interval" env-default:"0"`
+	// CacheWarmInterval is the period of preloading the cache with
+	// the cache.warm_top_n most clicked aliases.
+	CacheWarmInterval time.Duration `yaml:"cache_warm_interval" env-default:"0"`
+	// AuditRetentionInterval is the period of deleting audit entries
+	// older than AuditRetention.
+	AuditRetentionInterval time.Duration `yaml:"audit_retention_interval" env-default:"0"`
+	AuditRetention         time.Duration `yaml:"audit_retention" env-default:"2160h"`
+}
+
+// Tracing configures export of request traces to an OTLP collector.
+type Tracing struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Endpoint is the OTLP/HTTP endpoint of the collector.
+	Endpoint    string        `yaml:"endpoint" env-default:"http://localhost:4318"`
+	Timeout     time.Duration `yaml:"timeout" env-default:"5s"`
+	SampleRatio float64       `yaml:"sample_ratio" env-default:"1"`
+}
+
+// Fallback configures a read-only secondary storage serving the aliases
+// of an old deployment during a migration.
+type Fallback struct {
+	// StoragePath is the sqlite database of the old deployment.
+	// Empty value disables the fallback.
+	StoragePath string `yaml:"storage_path"`
+	// MigrateOnRead copies aliases served by the fallback into the
+	// primary storage.
+	MigrateOnRead bool `yaml:"migrate_on_read" env-default:"false"`
+}
+
+// Trailing slash policies.
+const (
+	TrailingSlashNone     = "none"
+	TrailingSlashStrip    = "strip"
+	TrailingSlashRedirect = "redirect"
+)
+
+// JSON key namings.
+const (
+	JSONNamingSnake = "snake_case"
+	JSONNamingCamel = "camelCase"
+)
+
+// Metrics backends.
+const (
+	MetricsNone       = "none"
+	MetricsPrometheus = "prometheus"
+	MetricsStatsD     = "statsd"
+)
+
+// Metrics configures export of request counts, durations and alias collisions.
+type Metrics struct {
+	// Backend is "none", "prometheus" (served at GET /metrics) or "statsd".
+	Backend string `yaml:"backend" env-default:"none"`
+	// StatsDAddr is the UDP address of the StatsD agent.
+	StatsDAddr string `yaml:"statsd_addr" env-default:"127.0.0.1:8125"`
+	// Prefix is prepended to every metric name.
+	Prefix string `yaml:"prefix" env-default:"url_shortener"`
+}
+
+// Defaults used instead of the env-default ones when running in a container,
+// so the server is reachable from outside and the storage is kept on a volume.
+const (
+	ContainerAddress     = "0.0.0.0:8080"
+	ContainerStoragePath = "/data/storage.db"
+)
+
+// InContainer reports whether CONTAINER or IN_DOCKER is set to a true value.
+func InContainer() bool {
+	for _, key := range []string{"CONTAINER", "IN_DOCKER"} {
+		if v, err := strconv.ParseBool(os.Getenv(key)); err == nil && v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyContainerDefaults pre-fills cfg before the config is read, so the
+// values from the file and the environment still take precedence.
+func applyContainerDefaults(cfg *Config) {
+	cfg.Address = ContainerAddress
+	cfg.StoragePath = ContainerStoragePath
 }
 
 func MustLoad() *Config {
@@ -28,16 +534,130 @@ func MustLoad() *Config {
 		log.Fatal("CONFIG_PATH is not set")
 	}
 
+	cfg, err := Load(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return cfg
+}
+
+// Load reads the config from configPath. It is used on startup and on reload.
+// A YAML config may set "extends: base.yaml" to inherit values from a base file.
+func Load(configPath string) (*Config, error) {
 	// check if file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file does not exist: %s", configPath)
+		return nil, fmt.Errorf("config file does not exist: %s", configPath)
 	}
 
 	var cfg Config
 
-	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config: %s", err)
+	if InContainer() {
+		applyContainerDefaults(&cfg)
+	}
+
+	switch filepath.Ext(configPath) {
+	case ".yaml", ".yml":
+		data, err := readMergedYAML(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read config: %w", err)
+		}
+
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config: %w", err)
+		}
+
+		// apply defaults and environment overrides
+		if err := cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config: %w", err)
+		}
+	default:
+		if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config: %w", err)
+		}
+	}
+
+	if cfg.Alias.Charset != "" {
+		if err := random.ValidateCharset(cfg.Alias.Charset); err != nil {
+			return nil, fmt.Errorf("invalid alias charset: %w", err)
+		}
+	}
+
+	if _, err := cfg.HTTPServer.TLS.Config(); err != nil {
+		return nil, fmt.Errorf("invalid tls config: %w", err)
+	}
+
+	if cfg.Health.Timeout <= 0 {
+		return nil, fmt.Errorf("health timeout must be positive, got %v", cfg.Health.Timeout)
+	}
+
+	if cfg.MaxTags <= 0 {
+		return nil, fmt.Errorf("max tags must be positive, got %d", cfg.MaxTags)
+	}
+
+	if cfg.AuditMaxLimit <= 0 {
+		return nil, fmt.Errorf("audit max limit must be positive, got %d", cfg.AuditMaxLimit)
+	}
+
+	switch cfg.Import.Duplicates {
+	case "fail", "first", "last":
+	default:
+		return nil, fmt.Errorf("unknown import duplicates mode %q", cfg.Import.Duplicates)
+	}
+
+	switch cfg.Metrics.Backend {
+	case MetricsNone, MetricsPrometheus, MetricsStatsD:
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", cfg.Metrics.Backend)
+	}
+
+	if rate := cfg.ClickEvents.SampleRate; rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("click events sample rate must be from 0 to 1, got %v", rate)
+	}
+
+	if cfg.Maintenance.AuditRetentionInterval > 0 && cfg.Maintenance.AuditRetention <= 0 {
+		return nil, fmt.Errorf("audit retention must be positive, got %v", cfg.Maintenance.AuditRetention)
+	}
+
+	if cfg.Maintenance.CacheWarmInterval > 0 && cfg.Cache.WarmTopN <= 0 {
+		return nil, fmt.Errorf("cache warm interval requires cache.warm_top_n")
+	}
+
+	switch cfg.TrailingSlash {
+	case TrailingSlashNone, TrailingSlashStrip, TrailingSlashRedirect:
+	default:
+		return nil, fmt.Errorf("unknown trailing slash policy %q", cfg.TrailingSlash)
+	}
+
+	switch cfg.JSONNaming {
+	case JSONNamingSnake, JSONNamingCamel:
+	default:
+		return nil, fmt.Errorf("unknown json naming %q", cfg.JSONNaming)
+	}
+
+	if cfg.DB.ConnMaxLifetime < 0 || cfg.DB.ConnMaxIdleTime < 0 {
+		return nil, fmt.Errorf("db connection lifetimes must not be negative")
+	}
+
+	if cfg.RedirectRules.Enabled && cfg.RedirectRules.Max <= 0 {
+		return nil, fmt.Errorf("max redirect rules must be positive, got %d", cfg.RedirectRules.Max)
+	}
+
+	if len(cfg.AllowedSchemes) == 0 {
+		return nil, fmt.Errorf("allowed schemes must not be empty")
+	}
+
+	if cfg.AccelRedirect.Enabled && cfg.AccelRedirect.Header == "" {
+		return nil, fmt.Errorf("accel redirect header must not be empty")
+	}
+
+	if cfg.ReservationTTL <= 0 {
+		return nil, fmt.Errorf("reservation ttl must be positive, got %v", cfg.ReservationTTL)
+	}
+
+	if err := cfg.RedirectDelay.validate(); err != nil {
+		return nil, err
 	}
 
-	return &cfg
+	return &cfg, nil
 }
@@ -0,0 +1,332 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad_Extends(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, dir, "base.yaml", `
+env: "prod"
+storage_path: "./storage.db"
+http_server:
+  address: "0.0.0.0:8082"
+  timeout: 4s
+  user: "admin"
+  password: "secret"
+`)
+	path := writeFile(t, dir, "dev.yaml", `
+extends: base.yaml
+env: "dev"
+http_server:
+  timeout: 10s
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "dev", cfg.Env)
+	assert.Equal(t, "./storage.db", cfg.StoragePath)
+	assert.Equal(t, "0.0.0.0:8082", cfg.Address)
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, "admin", cfg.User)
+	// defaults still apply to values set nowhere
+	assert.Equal(t, 60*time.Second, cfg.IdleTimeout)
+}
+
+func TestLoad_ExtendsMissingBase(t *testing.T) {
+	dir := t.TempDir()
+
+	path := writeFile(t, dir, "dev.yaml", `
+extends: missing.yaml
+env: "dev"
+`)
+
+	_, err := config.Load(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "base config file does not exist")
+	assert.Contains(t, err.Error(), "missing.yaml")
+}
+
+func TestLoad_ExtendsCircular(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, dir, "a.yaml", "extends: b.yaml\n")
+	path := writeFile(t, dir, "b.yaml", "extends: a.yaml\n")
+
+	_, err := config.Load(path)
+	assert.ErrorIs(t, err, config.ErrCircularExtends)
+}
+
+func TestLoad_ContainerDefaults(t *testing.T) {
+	const minimal = `
+http_server:
+  user: "admin"
+  password: "secret"
+`
+
+	cases := []struct {
+		name            string
+		env             map[string]string
+		config          string
+		wantAddress     string
+		wantStoragePath string
+	}{
+		{
+			name:            "Not in container",
+			config:          minimal + `storage_path: "./storage.db"`,
+			wantAddress:     "localhost:8080",
+			wantStoragePath: "./storage.db",
+		},
+		{
+			name:            "CONTAINER is set",
+			env:             map[string]string{"CONTAINER": "1"},
+			config:          minimal,
+			wantAddress:     config.ContainerAddress,
+			wantStoragePath: config.ContainerStoragePath,
+		},
+		{
+			name:            "IN_DOCKER is set",
+			env:             map[string]string{"IN_DOCKER": "true"},
+			config:          minimal,
+			wantAddress:     config.ContainerAddress,
+			wantStoragePath: config.ContainerStoragePath,
+		},
+		{
+			name:            "CONTAINER is false",
+			env:             map[string]string{"CONTAINER": "0"},
+			config:          minimal + `storage_path: "./storage.db"`,
+			wantAddress:     "localhost:8080",
+			wantStoragePath: "./storage.db",
+		},
+		{
+			name: "Explicit values win in container",
+			env:  map[string]string{"CONTAINER": "1"},
+			config: minimal + `
+  address: "127.0.0.1:9000"
+storage_path: "/var/lib/url-shortener/storage.db"
+`,
+			wantAddress:     "127.0.0.1:9000",
+			wantStoragePath: "/var/lib/url-shortener/storage.db",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("CONTAINER", "")
+			t.Setenv("IN_DOCKER", "")
+
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			path := writeFile(t, t.TempDir(), "config.yaml", tc.config)
+
+			cfg, err := config.Load(path)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.wantAddress, cfg.Address)
+			assert.Equal(t, tc.wantStoragePath, cfg.StoragePath)
+		})
+	}
+}
+
+func TestLoad_AliasCharset(t *testing.T) {
+	dir := t.TempDir()
+
+	path := writeFile(t, dir, "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+alias:
+  charset: "abcdefghijklmnopqrstuvwxyz-_"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdefghijklmnopqrstuvwxyz-_", cfg.Alias.Charset)
+
+	path = writeFile(t, dir, "unsafe.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+alias:
+  charset: "abcdefghijklmnopqrstuvwxyz/?#"
+`)
+
+	_, err = config.Load(path)
+	assert.ErrorContains(t, err, "invalid alias charset")
+}
+
+func TestLoad_ClickEventsSampleRate(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+click_events:
+  sink: "log"
+  sample_rate: 1.5
+`)
+
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, "sample rate")
+}
+
+func TestLoad_ImportDuplicates(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+import:
+  duplicates: "merge"
+`)
+
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, "unknown import duplicates mode")
+}
+
+func TestLoad_MetricsBackend(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+metrics:
+  backend: "graphite"
+`)
+
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, "unknown metrics backend")
+}
+
+func TestLoad_DB(t *testing.T) {
+	dir := t.TempDir()
+
+	path := writeFile(t, dir, "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Zero(t, cfg.DB.ConnMaxLifetime, "connections are kept forever by default")
+	assert.Zero(t, cfg.DB.ConnMaxIdleTime)
+
+	path = writeFile(t, dir, "nfs.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+db:
+  conn_max_lifetime: 30m
+  conn_max_idle_time: 5m
+`)
+
+	cfg, err = config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, cfg.DB.ConnMaxLifetime)
+	assert.Equal(t, 5*time.Minute, cfg.DB.ConnMaxIdleTime)
+}
+
+func TestLoad_Handlers(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+handlers:
+  save_enabled: false
+  delete_enabled: true
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.False(t, config.Enabled(cfg.Handlers.SaveEnabled))
+	assert.True(t, config.Enabled(cfg.Handlers.DeleteEnabled))
+	assert.True(t, config.Enabled(cfg.Handlers.UpsertEnabled))
+}
+
+func TestLoad_AllowedSchemes(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := config.Load(writeFile(t, dir, "default.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http", "https"}, cfg.AllowedSchemes)
+
+	cfg, err = config.Load(writeFile(t, dir, "https.yaml", `
+storage_path: "./storage.db"
+allowed_schemes: ["https"]
+http_server:
+  user: "admin"
+  password: "secret"
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https"}, cfg.AllowedSchemes)
+}
+
+func TestLoad_SecurityHeaders(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+		"Referrer-Policy":        "strict-origin-when-cross-origin",
+	}, cfg.HTTPServer.SecurityHeaders.Map())
+
+	path = writeFile(t, t.TempDir(), "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+  security_headers:
+    frame_options: "off"
+    content_security_policy: "default-src 'none'"
+`)
+
+	cfg, err = config.Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Referrer-Policy":         "strict-origin-when-cross-origin",
+		"Content-Security-Policy": "default-src 'none'",
+	}, cfg.HTTPServer.SecurityHeaders.Map())
+}
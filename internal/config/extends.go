@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extendsKey references a base config file which the current file is merged onto.
+// The path is relative to the file which references it.
+const extendsKey = "extends"
+
+var ErrCircularExtends = errors.New("circular extends")
+
+// readMergedYAML reads the YAML config at path, resolving the extends chain.
+// Maps are merged recursively with the child overriding the parent;
+// any other value (including lists) is replaced as a whole.
+func readMergedYAML(path string) ([]byte, error) {
+	merged, err := loadYAML(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(merged)
+}
+
+func loadYAML(path string, chain []string) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range chain {
+		if p == abs {
+			return nil, fmt.Errorf("%w: %s", ErrCircularExtends, abs)
+		}
+	}
+
+	chain = append(chain, abs)
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		if os.IsNotExist(err) && len(chain) > 1 {
+			return nil, fmt.Errorf("base config file does not exist: %s (extended by %s)", abs, chain[len(chain)-2])
+		}
+
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", abs, err)
+	}
+
+	if doc == nil {
+		doc = make(map[string]interface{})
+	}
+
+	base, ok := doc[extendsKey]
+	if !ok {
+		return doc, nil
+	}
+
+	delete(doc, extendsKey)
+
+	basePath, ok := base.(string)
+	if !ok || basePath == "" {
+		return nil, fmt.Errorf("%s: %q must be a file path", abs, extendsKey)
+	}
+
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(filepath.Dir(abs), basePath)
+	}
+
+	parent, err := loadYAML(basePath, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeMaps(parent, doc), nil
+}
+
+func mergeMaps(parent map[string]interface{}, child map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(parent)+len(child))
+
+	for k, v := range parent {
+		res[k] = v
+	}
+
+	for k, v := range child {
+		childMap, childIsMap := v.(map[string]interface{})
+		parentMap, parentIsMap := res[k].(map[string]interface{})
+
+		if childIsMap && parentIsMap {
+			res[k] = mergeMaps(parentMap, childMap)
+
+			continue
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
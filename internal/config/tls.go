@@ -0,0 +1,107 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+// TLS makes the server serve HTTPS with the certificate and key files.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// MinVersion is the minimum TLS version, "1.2" or "1.3".
+	MinVersion string `yaml:"min_version" env-default:"1.2"`
+	// CipherSuites restricts the TLS 1.2 cipher suites by their names,
+	// e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Empty means the Go
+	// defaults. TLS 1.3 suites are not configurable.
+	CipherSuites []string `yaml:"cipher_suites"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Enabled reports whether the server serves HTTPS.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Config returns the tls.Config of the policy. It fails on unknown
+// and insecure versions and cipher suites.
+func (t TLS) Config() (*tls.Config, error) {
+	if t.Enabled() && (t.CertFile == "" || t.KeyFile == "") {
+		return nil, errors.New("both cert_file and key_file are required")
+	}
+
+	minVersion, ok := tlsVersions[t.MinVersion]
+	if !ok {
+		switch t.MinVersion {
+		case "1.0", "1.1":
+			return nil, fmt.Errorf("tls version %s is insecure, use 1.2 or 1.3", t.MinVersion)
+		default:
+			return nil, fmt.Errorf("unknown tls version %q, use 1.2 or 1.3", t.MinVersion)
+		}
+	}
+
+	cfg := &tls.Config{MinVersion: minVersion}
+
+	if len(t.CipherSuites) == 0 {
+		return cfg, nil
+	}
+
+	if minVersion == tls.VersionTLS13 {
+		return nil, errors.New("cipher suites have no effect with tls 1.3 only")
+	}
+
+	http2Suite := false
+
+	for _, name := range t.CipherSuites {
+		id, err := cipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+
+		// HTTP/2 requires one of them, see RFC 7540 section 9.2.2
+		if id == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || id == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+			http2Suite = true
+		}
+
+		cfg.CipherSuites = append(cfg.CipherSuites, id)
+	}
+
+	if !http2Suite {
+		return nil, errors.New(
+			"cipher suites must include TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 " +
+				"or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 for HTTP/2",
+		)
+	}
+
+	return cfg, nil
+}
+
+// cipherSuite returns the ID of a secure TLS 1.2 cipher suite by name.
+func cipherSuite(name string) (uint16, error) {
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.Name == name {
+			return 0, fmt.Errorf("cipher suite %s is insecure", name)
+		}
+	}
+
+	for _, s := range tls.CipherSuites() {
+		if s.Name != name {
+			continue
+		}
+
+		for _, v := range s.SupportedVersions {
+			if v == tls.VersionTLS12 {
+				return s.ID, nil
+			}
+		}
+
+		return 0, fmt.Errorf("cipher suite %s is not configurable", name)
+	}
+
+	return 0, fmt.Errorf("unknown cipher suite %q", name)
+}
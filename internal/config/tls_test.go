@@ -0,0 +1,124 @@
+package config_test
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/config"
+)
+
+func TestTLS_Config(t *testing.T) {
+	cases := []struct {
+		name        string
+		tls         config.TLS
+		wantMin     uint16
+		wantCiphers []uint16
+		wantErr     string
+	}{
+		{
+			name:    "TLS 1.2",
+			tls:     config.TLS{MinVersion: "1.2"},
+			wantMin: tls.VersionTLS12,
+		},
+		{
+			name:    "TLS 1.3",
+			tls:     config.TLS{MinVersion: "1.3"},
+			wantMin: tls.VersionTLS13,
+		},
+		{
+			name: "Cipher suites",
+			tls: config.TLS{
+				MinVersion: "1.2",
+				CipherSuites: []string{
+					"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+					"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+				},
+			},
+			wantMin: tls.VersionTLS12,
+			wantCiphers: []uint16{
+				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+			},
+		},
+		{
+			name:    "Insecure version",
+			tls:     config.TLS{MinVersion: "1.0"},
+			wantErr: "tls version 1.0 is insecure, use 1.2 or 1.3",
+		},
+		{
+			name:    "Unknown version",
+			tls:     config.TLS{MinVersion: "tls12"},
+			wantErr: `unknown tls version "tls12", use 1.2 or 1.3`,
+		},
+		{
+			name:    "Insecure cipher suite",
+			tls:     config.TLS{MinVersion: "1.2", CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: "cipher suite TLS_RSA_WITH_RC4_128_SHA is insecure",
+		},
+		{
+			name:    "TLS 1.3 cipher suite",
+			tls:     config.TLS{MinVersion: "1.2", CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}},
+			wantErr: "cipher suite TLS_AES_128_GCM_SHA256 is not configurable",
+		},
+		{
+			name:    "Cipher suites with TLS 1.3",
+			tls:     config.TLS{MinVersion: "1.3", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}},
+			wantErr: "cipher suites have no effect with tls 1.3 only",
+		},
+		{
+			name:    "No HTTP/2 cipher suite",
+			tls:     config.TLS{MinVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}},
+			wantErr: "for HTTP/2",
+		},
+		{
+			name:    "Missing key",
+			tls:     config.TLS{CertFile: "cert.pem", MinVersion: "1.2"},
+			wantErr: "both cert_file and key_file are required",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := tc.tls.Config()
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantMin, cfg.MinVersion)
+			assert.Equal(t, tc.wantCiphers, cfg.CipherSuites)
+		})
+	}
+}
+
+func TestLoad_TLS(t *testing.T) {
+	dir := t.TempDir()
+
+	path := writeFile(t, dir, "config.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+`)
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", cfg.HTTPServer.TLS.MinVersion)
+
+	path = writeFile(t, dir, "insecure.yaml", `
+storage_path: "./storage.db"
+http_server:
+  user: "admin"
+  password: "secret"
+  tls:
+    min_version: "1.0"
+`)
+
+	_, err = config.Load(path)
+	assert.ErrorContains(t, err, "tls version 1.0 is insecure")
+}
@@ -0,0 +1,114 @@
+package errorpage
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+//go:embed templates/*.html
+var defaults embed.FS
+
+// Statuses are the response codes which have an error page.
+var Statuses = []int{http.StatusNotFound, http.StatusGone, http.StatusInternalServerError}
+
+// Data is passed to the error page templates.
+type Data struct {
+	Status    int
+	Alias     string
+	RequestID string
+	// NotYetActive is set on the 404 page of an alias which
+	// exists but is not active yet.
+	NotYetActive bool
+}
+
+// Pages renders HTML error pages. Templates named after the status code,
+// e.g. "404.html", are read from the directory; the embedded defaults
+// are used for the missing ones.
+type Pages struct {
+	dir       string
+	templates atomic.Pointer[map[int]*template.Template]
+}
+
+// New parses the templates from dir. Empty dir means embedded defaults only.
+func New(dir string) (*Pages, error) {
+	const op = "http-server.errorpage.New"
+
+	p := &Pages{dir: dir}
+
+	if err := p.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// Reload re-parses the templates. The previous ones are kept on error.
+func (p *Pages) Reload() error {
+	const op = "http-server.errorpage.Reload"
+
+	templates := make(map[int]*template.Template, len(Statuses))
+
+	for _, status := range Statuses {
+		t, err := p.parse(status)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		templates[status] = t
+	}
+
+	p.templates.Store(&templates)
+
+	return nil
+}
+
+func (p *Pages) parse(status int) (*template.Template, error) {
+	name := fmt.Sprintf("%d.html", status)
+
+	if p.dir != "" {
+		t, err := template.ParseFiles(filepath.Join(p.dir, name))
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return template.ParseFS(defaults, "templates/"+name)
+}
+
+// Render writes the error page for status. It returns false if there is
+// no page for status, so the caller should respond in another way.
+func (p *Pages) Render(w http.ResponseWriter, status int, data Data) bool {
+	t, ok := (*p.templates.Load())[status]
+	if !ok {
+		return false
+	}
+
+	data.Status = status
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+
+	return true
+}
+
+// AcceptsHTML reports whether the client prefers an HTML response, e.g. a browser.
+func AcceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
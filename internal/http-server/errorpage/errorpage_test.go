@@ -0,0 +1,71 @@
+package errorpage_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/errorpage"
+)
+
+func render(t *testing.T, pages *errorpage.Pages, status int) string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	require.True(t, pages.Render(rr, status, errorpage.Data{Alias: "missing"}))
+
+	assert.Equal(t, status, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	return rr.Body.String()
+}
+
+func TestPages_Defaults(t *testing.T) {
+	pages, err := errorpage.New("")
+	require.NoError(t, err)
+
+	for _, status := range errorpage.Statuses {
+		assert.Contains(t, render(t, pages, status), http.StatusText(status))
+	}
+
+	assert.Contains(t, render(t, pages, http.StatusNotFound), "<b>missing</b>")
+
+	assert.False(t, pages.Render(httptest.NewRecorder(), http.StatusTeapot, errorpage.Data{}))
+}
+
+func TestPages_CustomDir(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "404.html")
+
+	require.NoError(t, os.WriteFile(path, []byte(`custom {{.Status}} for {{.Alias}}`), 0o600))
+
+	pages, err := errorpage.New(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "custom 404 for missing", render(t, pages, http.StatusNotFound))
+	// missing templates fall back to the embedded ones
+	assert.Contains(t, render(t, pages, http.StatusInternalServerError), "Internal Server Error")
+
+	require.NoError(t, os.WriteFile(path, []byte(`reloaded {{.Alias}}`), 0o600))
+	require.NoError(t, pages.Reload())
+
+	assert.Equal(t, "reloaded missing", render(t, pages, http.StatusNotFound))
+
+	// a broken template doesn't replace the working one
+	require.NoError(t, os.WriteFile(path, []byte(`{{.Alias`), 0o600))
+	require.Error(t, pages.Reload())
+
+	assert.Equal(t, "reloaded missing", render(t, pages, http.StatusNotFound))
+}
+
+func TestPages_MissingDir(t *testing.T) {
+	pages, err := errorpage.New(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+
+	assert.Contains(t, render(t, pages, http.StatusNotFound), "Not Found")
+}
@@ -0,0 +1,39 @@
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Prefix is the path the dashboard is served from.
+const Prefix = "/admin"
+
+//go:embed static
+var static embed.FS
+
+// New serves the embedded admin dashboard, which uses the JSON API
+// to create, list and delete links. It must be mounted at Prefix.
+func New() http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		// the directory is embedded, so it always exists
+		panic(err)
+	}
+
+	files := http.StripPrefix(Prefix, http.FileServer(http.FS(root)))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// relative asset paths need the trailing slash
+		if r.URL.Path == Prefix {
+			http.Redirect(w, r, Prefix+"/", http.StatusMovedPermanently)
+
+			return
+		}
+
+		w.Header().Set("Content-Security-Policy", "default-src 'self'")
+		w.Header().Set("X-Frame-Options", "DENY")
+
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,111 @@
+// The dashboard uses the JSON API of the service with the credentials
+// the browser was authorized with for /admin.
+"use strict";
+
+const pageSize = 50;
+
+const links = document.getElementById("links");
+const more = document.getElementById("more");
+const message = document.getElementById("message");
+
+let offset = 0;
+
+function show(text, isError) {
+  message.textContent = text;
+  message.className = isError ? "error" : "";
+}
+
+async function api(method, path, body) {
+  const res = await fetch(path, {
+    method: method,
+    headers: body ? { "Content-Type": "application/json" } : {},
+    body: body ? JSON.stringify(body) : undefined,
+  });
+
+  const data = await res.json();
+  if (data.status !== "OK") {
+    throw new Error(data.error || res.statusText);
+  }
+
+  return data;
+}
+
+function row(link) {
+  const tr = document.createElement("tr");
+
+  const alias = document.createElement("td");
+  const a = document.createElement("a");
+  a.href = "/" + link.alias;
+  a.textContent = link.alias;
+  alias.appendChild(a);
+
+  const url = document.createElement("td");
+  url.textContent = link.url;
+
+  const created = document.createElement("td");
+  // the API may be configured with camelCase keys
+  const createdAt = link.created_at || link.createdAt;
+  created.textContent = createdAt ? new Date(createdAt).toLocaleString() : "";
+
+  const actions = document.createElement("td");
+  const del = document.createElement("button");
+  del.type = "button";
+  del.textContent = "Delete";
+  del.addEventListener("click", async () => {
+    if (!confirm("Delete " + link.alias + "?")) {
+      return;
+    }
+
+    try {
+      await api("DELETE", "/url/" + encodeURIComponent(link.alias));
+      tr.remove();
+      show("Deleted " + link.alias, false);
+    } catch (err) {
+      show(err.message, true);
+    }
+  });
+  actions.appendChild(del);
+
+  tr.append(alias, url, created, actions);
+
+  return tr;
+}
+
+async function load() {
+  try {
+    const data = await api("GET", "/urls?limit=" + pageSize + "&offset=" + offset);
+    const urls = data.urls || [];
+
+    urls.forEach((link) => links.appendChild(row(link)));
+    offset += urls.length;
+    more.hidden = urls.length < pageSize;
+  } catch (err) {
+    show(err.message, true);
+  }
+}
+
+document.getElementById("create").addEventListener("submit", async (event) => {
+  event.preventDefault();
+
+  const body = { url: document.getElementById("url").value };
+  const alias = document.getElementById("alias").value.trim();
+  if (alias) {
+    body.alias = alias;
+  }
+
+  try {
+    const data = await api("POST", "/url", body);
+    show("Created " + (data.short_url || data.shortUrl || data.alias), false);
+    event.target.reset();
+
+    links.replaceChildren();
+    offset = 0;
+    await load();
+  } catch (err) {
+    show(err.message, true);
+  }
+});
+
+more.addEventListener("click", load);
+
+load();
@@ -0,0 +1,34 @@
+package allow
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// New responds 204 with the Allow header listing methods along with
+// HEAD and OPTIONS, which it answers itself. It is meant for HEAD and
+// OPTIONS routes of endpoints which don't serve GET, so API probes and
+// CORS preflights don't get 405.
+func New(methods ...string) http.HandlerFunc {
+	allowed := map[string]struct{}{
+		http.MethodHead:    {},
+		http.MethodOptions: {},
+	}
+	for _, m := range methods {
+		allowed[strings.ToUpper(m)] = struct{}{}
+	}
+
+	list := make([]string, 0, len(allowed))
+	for m := range allowed {
+		list = append(list, m)
+	}
+	sort.Strings(list)
+
+	header := strings.Join(list, ", ")
+
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Allow", header)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
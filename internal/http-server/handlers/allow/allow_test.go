@@ -0,0 +1,24 @@
+package allow_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/handlers/allow"
+)
+
+func TestAllowHandler(t *testing.T) {
+	handler := allow.New(http.MethodPut, "delete")
+
+	for _, method := range []string{http.MethodHead, http.MethodOptions} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/url/abc", nil))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code, method)
+		assert.Equal(t, "DELETE, HEAD, OPTIONS, PUT", rr.Header().Get("Allow"), method)
+		assert.Empty(t, rr.Body.String(), method)
+	}
+}
@@ -0,0 +1,156 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 50
+	// DefaultMaxLimit caps the page size unless WithMaxLimit is given.
+	DefaultMaxLimit = 500
+
+	maxActorLength = 256
+)
+
+// actions are the valid values of the "action" filter.
+var actions = map[string]bool{
+	audit.ActionSave:   true,
+	audit.ActionDelete: true,
+	audit.ActionUpdate: true,
+}
+
+type Response struct {
+	resp.Response
+	Entries []storage.AuditEntry `json:"entries"`
+	// Total is the number of entries matching the filters.
+	Total int `json:"total"`
+}
+
+// AuditReader is an interface for reading the audit trail.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditReader
+type AuditReader interface {
+	QueryAudit(filter storage.AuditFilter, limit int, offset int) ([]storage.AuditEntry, int, error)
+}
+
+type options struct {
+	maxLimit int
+}
+
+// Option configures the audit list handler.
+type Option func(*options)
+
+// WithMaxLimit caps the "limit" query parameter, larger values are lowered to n.
+func WithMaxLimit(n int) Option {
+	return func(o *options) {
+		o.maxLimit = n
+	}
+}
+
+// New lists audit entries, newest first, optionally filtered by the
+// "actor" and "action" query parameters and paginated with "limit"
+// and "offset".
+func New(log *slog.Logger, auditReader AuditReader, opts ...Option) http.HandlerFunc {
+	o := options{
+		maxLimit: DefaultMaxLimit,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.audit.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		query := r.URL.Query()
+
+		limit, ok := parseInt(query.Get("limit"), defaultLimit)
+		if !ok || limit <= 0 {
+			log.Info("invalid limit", slog.String("limit", query.Get("limit")))
+
+			invalid(w, r, "invalid limit")
+
+			return
+		}
+		if limit > o.maxLimit {
+			limit = o.maxLimit
+		}
+
+		offset, ok := parseInt(query.Get("offset"), 0)
+		if !ok || offset < 0 {
+			log.Info("invalid offset", slog.String("offset", query.Get("offset")))
+
+			invalid(w, r, "invalid offset")
+
+			return
+		}
+
+		filter := storage.AuditFilter{
+			Actor:  query.Get("actor"),
+			Action: query.Get("action"),
+		}
+
+		if len(filter.Actor) > maxActorLength {
+			log.Info("actor is too long", slog.Int("length", len(filter.Actor)))
+
+			invalid(w, r, "invalid actor")
+
+			return
+		}
+
+		if filter.Action != "" && !actions[filter.Action] {
+			log.Info("invalid action", slog.String("action", filter.Action))
+
+			invalid(w, r, "invalid action")
+
+			return
+		}
+
+		entries, total, err := auditReader.QueryAudit(filter, limit, offset)
+		if err != nil {
+			log.Error("failed to get audit entries", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Entries:  entries,
+			Total:    total,
+		})
+	}
+}
+
+func invalid(w http.ResponseWriter, r *http.Request, msg string) {
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, msg))
+}
+
+func parseInt(v string, def int) (int, bool) {
+	if v == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(v)
+
+	return n, err == nil
+}
@@ -0,0 +1,118 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/audit/list"
+	"url-shortener/internal/http-server/handlers/audit/list/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	entries := []storage.AuditEntry{{ID: 1, Actor: "alice", Action: "save", Alias: "a", Result: "success"}}
+
+	cases := []struct {
+		name       string
+		query      string
+		wantFilter storage.AuditFilter
+		wantLimit  int
+		wantOffset int
+		mockError  error
+		wantCode   int
+		respError  string
+	}{
+		{
+			name:      "Defaults",
+			wantLimit: 50,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:       "Filters and page",
+			query:      "?actor=alice&action=save&limit=10&offset=20",
+			wantFilter: storage.AuditFilter{Actor: "alice", Action: "save"},
+			wantLimit:  10,
+			wantOffset: 20,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name:      "Limit capped",
+			query:     "?limit=1000",
+			wantLimit: 100,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Invalid action",
+			query:     "?action=drop",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid action",
+		},
+		{
+			name:      "Actor too long",
+			query:     "?actor=" + strings.Repeat("a", 257),
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid actor",
+		},
+		{
+			name:      "Zero limit",
+			query:     "?limit=0",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid limit",
+		},
+		{
+			name:      "Negative offset",
+			query:     "?offset=-1",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid offset",
+		},
+		{
+			name:      "Storage error",
+			wantLimit: 50,
+			mockError: errors.New("unexpected error"),
+			wantCode:  http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			auditReaderMock := mocks.NewAuditReader(t)
+			if tc.wantLimit != 0 {
+				auditReaderMock.On("QueryAudit", tc.wantFilter, tc.wantLimit, tc.wantOffset).
+					Return(entries, 7, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), auditReaderMock, list.WithMaxLimit(100))
+
+			req, err := http.NewRequest(http.MethodGet, "/audit"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.wantCode == http.StatusOK {
+				require.Equal(t, entries, resp.Entries)
+				require.Equal(t, 7, resp.Total)
+			}
+		})
+	}
+}
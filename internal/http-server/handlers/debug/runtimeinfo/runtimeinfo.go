@@ -0,0 +1,86 @@
+package runtimeinfo
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/recoverer"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// fdDir lists the open file descriptors of the process on Linux.
+const fdDir = "/proc/self/fd"
+
+type Response struct {
+	resp.Response
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	GCPauseTotalNs uint64 `json:"gc_pause_total_ns"`
+	LastGCPauseNs  uint64 `json:"last_gc_pause_ns"`
+	// OpenFDs is absent where /proc/self/fd is not available.
+	OpenFDs *int `json:"open_fds,omitempty"`
+	// Panics is the number of panics recovered in handlers.
+	Panics int64 `json:"panics"`
+}
+
+// New reports goroutines, memory and open file descriptors of the process
+// to diagnose leaks without pprof.
+func New(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.debug.runtimeinfo.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		res := Response{
+			Response:       resp.OK(),
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: m.HeapAlloc,
+			HeapObjects:    m.HeapObjects,
+			SysBytes:       m.Sys,
+			NumGC:          m.NumGC,
+			GCPauseTotalNs: m.PauseTotalNs,
+			Panics:         recoverer.Panics(),
+		}
+		if m.NumGC > 0 {
+			res.LastGCPauseNs = m.PauseNs[(m.NumGC+255)%256]
+		}
+
+		if runtime.GOOS == "linux" {
+			n, err := openFDs()
+			if err != nil {
+				log.Warn("failed to count open fds", sl.Err(err))
+			} else {
+				res.OpenFDs = &n
+			}
+		}
+
+		render.JSON(w, r, res)
+	}
+}
+
+func openFDs() (int, error) {
+	entries, err := os.ReadDir(fdDir)
+	if err != nil {
+		return 0, err
+	}
+
+	// the listing includes the descriptor ReadDir opened for it
+	return len(entries) - 1, nil
+}
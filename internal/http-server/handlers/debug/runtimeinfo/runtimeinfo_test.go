@@ -0,0 +1,48 @@
+package runtimeinfo_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/debug/runtimeinfo"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestRuntimeInfoHandler(t *testing.T) {
+	runtime.GC()
+
+	handler := runtimeinfo.New(slogdiscard.NewDiscardLogger())
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/runtime", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &fields))
+	for _, f := range []string{"goroutines", "heap_alloc_bytes", "heap_objects", "sys_bytes", "num_gc", "gc_pause_total_ns"} {
+		assert.Contains(t, fields, f)
+	}
+
+	var res runtimeinfo.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+
+	assert.Equal(t, resp.StatusOK, res.Status)
+	assert.Positive(t, res.Goroutines)
+	assert.Positive(t, res.HeapAllocBytes)
+	assert.Positive(t, res.SysBytes)
+	assert.Positive(t, res.NumGC)
+
+	if runtime.GOOS == "linux" {
+		require.NotNil(t, res.OpenFDs)
+		assert.Positive(t, *res.OpenFDs)
+	}
+}
@@ -0,0 +1,77 @@
+package ready
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// Pinger is an interface for checking that the storage is reachable.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Pinger
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// New reports whether the service is ready to serve requests, i.e. the
+// storage responds to a ping within timeout. Otherwise it responds 503
+// with the "timeout" or "storage unavailable" reason.
+func New(log *slog.Logger, pinger Pinger, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.ready.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		// the ping runs aside, so that a driver ignoring ctx can't hang the check
+		done := make(chan error, 1)
+		go func() {
+			done <- pinger.Ping(ctx)
+		}()
+
+		var err error
+
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Warn("storage ping timed out", slog.Duration("timeout", timeout))
+
+			unavailable(w, r, "timeout")
+
+			return
+		}
+		if err != nil {
+			log.Error("storage ping failed", sl.Err(err))
+
+			unavailable(w, r, "storage unavailable")
+
+			return
+		}
+
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+func unavailable(w http.ResponseWriter, r *http.Request, reason string) {
+	render.Status(r, http.StatusServiceUnavailable)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeUnavailable, reason))
+}
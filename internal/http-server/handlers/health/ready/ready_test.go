@@ -0,0 +1,91 @@
+package ready_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/health/ready"
+	"url-shortener/internal/http-server/handlers/health/ready/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestReadyHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		pingError  error
+		wantCode   int
+		wantReason string
+	}{
+		{
+			name:     "Ready",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			pingError:  errors.New("disk I/O error"),
+			wantCode:   http.StatusServiceUnavailable,
+			wantReason: "storage unavailable",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pingerMock := mocks.NewPinger(t)
+			pingerMock.On("Ping", mock.Anything).Return(tc.pingError).Once()
+
+			handler := ready.New(slogdiscard.NewDiscardLogger(), pingerMock, time.Second)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var res resp.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			require.Equal(t, tc.wantReason, res.Error)
+		})
+	}
+}
+
+func TestReadyHandler_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	// the ping ignores its context, like a hung driver
+	pingerMock := mocks.NewPinger(t)
+	pingerMock.On("Ping", mock.Anything).
+		Run(func(mock.Arguments) { <-release }).
+		Return(nil).
+		Once()
+
+	const timeout = 50 * time.Millisecond
+
+	handler := ready.New(slogdiscard.NewDiscardLogger(), pingerMock, timeout)
+
+	start := time.Now()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	require.Less(t, time.Since(start), 10*timeout)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	var res resp.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.Equal(t, "timeout", res.Error)
+	require.Equal(t, resp.CodeUnavailable, res.Code)
+}
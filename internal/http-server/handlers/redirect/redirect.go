@@ -2,15 +2,26 @@ package redirect
 
 import (
 	"errors"
+	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/exp/slog"
 
+	"url-shortener/internal/http-server/errorpage"
+	"url-shortener/internal/http-server/middleware/namespace"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/clock"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/redirectrule"
 	"url-shortener/internal/storage"
 )
 
@@ -21,7 +32,227 @@ type URLGetter interface {
 	GetURL(alias string) (string, error)
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// PasswordChecker is an interface for password protected aliases.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=PasswordChecker
+type PasswordChecker interface {
+	GetPasswordHash(alias string) (string, error)
+	RecordFailedAttempt(alias string) (int64, error)
+}
+
+// OneTimeConsumer is an interface for one-time aliases.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=OneTimeConsumer
+type OneTimeConsumer interface {
+	ConsumeOneTime(alias string) (url string, oneTime bool, err error)
+}
+
+// ClickRecorder is an interface for counting redirects of aliases.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickRecorder
+type ClickRecorder interface {
+	RecordClick(alias string) error
+}
+
+// CacheTTLGetter is an interface for the redirect cache lifetime of aliases.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CacheTTLGetter
+type CacheTTLGetter interface {
+	GetCacheTTL(alias string) (time.Duration, error)
+}
+
+// RedirectRulesGetter is an interface for getting the redirect rules
+// of an alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=RedirectRulesGetter
+type RedirectRulesGetter interface {
+	GetRedirectRules(alias string) ([]storage.RedirectRule, error)
+}
+
+// ClickSampler is an interface for sampled click events.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClickSampler
+type ClickSampler interface {
+	Sample(r *http.Request, alias string)
+}
+
+// PasswordHeader may carry the password of a protected alias
+// instead of the "pw" query parameter.
+const PasswordHeader = "X-Alias-Password"
+
+// AliasHeader carries the alias on redirects, see WithAliasHeader.
+const AliasHeader = "X-Shortener-Alias"
+
+// retryAfter is the Retry-After value in seconds sent while the storage
+// is temporarily unavailable.
+const retryAfter = "1"
+
+type options struct {
+	notFoundRedirect string
+	errorPages       *errorpage.Pages
+	passwords        PasswordChecker
+	oneTime          OneTimeConsumer
+	clicks           ClickRecorder
+	clickEvents      ClickSampler
+	cacheTTLs        CacheTTLGetter
+	checksum         bool
+	notYetActivePage bool
+	delays           Delays
+	sleeper          clock.Sleeper
+	aliasHeader      bool
+	accelHeader      string
+	accelPrefix      string
+	rules            RedirectRulesGetter
+	matcher          redirectrule.Matcher
+}
+
+// Delays are waits before redirecting, e.g. to throttle redirects
+// to partner sites which ask for it.
+type Delays struct {
+	// Default applies to aliases without a more specific delay.
+	Default time.Duration
+	// Hosts are delays by host of the target url.
+	Hosts map[string]time.Duration
+	// Aliases are delays by alias, they take precedence over Hosts.
+	Aliases map[string]time.Duration
+}
+
+// For returns the delay of redirecting alias to target.
+func (d Delays) For(alias string, target string) time.Duration {
+	if delay, ok := d.Aliases[alias]; ok {
+		return delay
+	}
+
+	if len(d.Hosts) > 0 {
+		if u, err := url.Parse(target); err == nil {
+			if delay, ok := d.Hosts[strings.ToLower(u.Hostname())]; ok {
+				return delay
+			}
+		}
+	}
+
+	return d.Default
+}
+
+// Option configures the redirect handler.
+type Option func(*options)
+
+// WithNotFoundRedirect sets the URL users are sent to when an alias is not found.
+// Empty value keeps the default "not found" response.
+func WithNotFoundRedirect(target string) Option {
+	return func(o *options) {
+		o.notFoundRedirect = target
+	}
+}
+
+// WithErrorPages makes the handler respond with HTML error pages
+// to clients which accept HTML.
+func WithErrorPages(pages *errorpage.Pages) Option {
+	return func(o *options) {
+		o.errorPages = pages
+	}
+}
+
+// WithPasswords makes the handler require the password of protected
+// aliases before redirecting.
+func WithPasswords(checker PasswordChecker) Option {
+	return func(o *options) {
+		o.passwords = checker
+	}
+}
+
+// WithOneTime makes the handler expire one-time aliases after
+// the first redirect. Later requests get 410 Gone.
+func WithOneTime(consumer OneTimeConsumer) Option {
+	return func(o *options) {
+		o.oneTime = consumer
+	}
+}
+
+// WithClicks makes the handler count successful redirects.
+func WithClicks(recorder ClickRecorder) Option {
+	return func(o *options) {
+		o.clicks = recorder
+	}
+}
+
+// WithClickEvents makes the handler pass successful redirects to sampler.
+// It must not block.
+func WithClickEvents(sampler ClickSampler) Option {
+	return func(o *options) {
+		o.clickEvents = sampler
+	}
+}
+
+// WithCacheTTL makes the handler emit "Cache-Control: max-age=N" with the
+// cache TTL of the alias, or "no-cache" if it has none.
+func WithCacheTTL(getter CacheTTLGetter) Option {
+	return func(o *options) {
+		o.cacheTTLs = getter
+	}
+}
+
+// WithAliasChecksum makes the handler respond "not found" to aliases
+// without a valid check character, see checksum.Append.
+func WithAliasChecksum(enabled bool) Option {
+	return func(o *options) {
+		o.checksum = enabled
+	}
+}
+
+// WithDelays makes the handler wait before redirecting, using sleeper,
+// e.g. clock.Real. Clients leaving while waiting are not redirected.
+func WithDelays(delays Delays, sleeper clock.Sleeper) Option {
+	return func(o *options) {
+		o.delays = delays
+		o.sleeper = sleeper
+	}
+}
+
+// WithAliasHeader makes the handler send the alias of redirects
+// in AliasHeader, e.g. for client-side analytics.
+func WithAliasHeader(enabled bool) Option {
+	return func(o *options) {
+		o.aliasHeader = enabled
+	}
+}
+
+// WithAccelRedirect makes the handler leave redirects to a fronting
+// proxy: instead of a 302 it responds with the target, prefixed with
+// prefix, in header, e.g. "X-Accel-Redirect" of nginx pointing to an
+// internal location. An empty header keeps standard redirects.
+func WithAccelRedirect(header string, prefix string) Option {
+	return func(o *options) {
+		o.accelHeader = header
+		o.accelPrefix = prefix
+	}
+}
+
+// WithRedirectRules makes the handler redirect clients matching
+// a redirect rule of the alias to its target, e.g. phones to an app
+// store. Other clients are redirected to the url of the alias.
+func WithRedirectRules(getter RedirectRulesGetter, matcher redirectrule.Matcher) Option {
+	return func(o *options) {
+		o.rules = getter
+		o.matcher = matcher
+	}
+}
+
+// WithNotYetActivePage makes the handler tell clients that an alias
+// before its activation time is not available yet. Otherwise such aliases
+// are treated as not found. Both respond 404.
+func WithNotYetActivePage(enabled bool) Option {
+	return func(o *options) {
+		o.notYetActivePage = enabled
+	}
+}
+
+func New(log *slog.Logger, urlGetter URLGetter, opts ...Option) http.HandlerFunc {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -30,34 +261,397 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		urlGetter := storage.WithContext(r.Context(), urlGetter)
+
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
 
-			render.JSON(w, r, resp.Error("invalid request"))
+			resp.NoStore(w)
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid request"))
+
+			return
+		}
+
+		// mistyped aliases are rejected without a storage lookup
+		if o.checksum && !checksum.Valid(alias) {
+			log.Info("invalid alias checksum", slog.String("alias", alias))
+
+			o.notFound(w, r, alias)
 
 			return
 		}
 
+		// aliases of namespaced users are served from /{namespace}/{alias}
+		if ns := chi.URLParam(r, "namespace"); ns != "" {
+			alias = namespace.Join(ns, alias)
+		}
+
+		if o.passwords != nil && !o.checkPassword(w, r, log, alias) {
+			return
+		}
+
+		// the target the delay was waited out for, if any
+		var waitedFor string
+
+		if o.oneTime != nil {
+			// the delay is waited out before a one-time alias is used up,
+			// so that a client leaving meanwhile doesn't lose it
+			if o.sleeper != nil {
+				if target, err := urlGetter.GetURL(alias); err == nil {
+					if !o.wait(r, log, alias, target) {
+						return
+					}
+
+					waitedFor = target
+				}
+			}
+
+			resURL, oneTime, err := o.oneTime.ConsumeOneTime(alias)
+			if errors.Is(err, storage.ErrURLGone) {
+				log.Info("url gone", slog.String("alias", alias))
+
+				resp.NoStore(w)
+
+				if o.renderPage(w, r, http.StatusGone, alias) {
+					return
+				}
+
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeGone, "gone"))
+
+				return
+			}
+			// not found is handled below along with regular aliases
+			if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+				log.Error("failed to consume one-time url", sl.Err(err))
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+				return
+			}
+			if oneTime && err == nil {
+				log.Info("one-time url used", slog.String("alias", alias))
+
+				o.recordClick(r, log, alias)
+
+				resp.NoStore(w)
+				o.setAliasHeader(w, alias)
+				o.redirect(w, r, resURL)
+
+				return
+			}
+		}
+
 		resURL, err := urlGetter.GetURL(alias)
-		if errors.Is(err, storage.ErrURLNotFound) {
+		switch kind := storage.KindOf(err); {
+		case err == nil:
+		case kind == storage.KindNotFound && o.notYetActivePage && errors.Is(err, storage.ErrURLNotActive):
+			log.Info("url not active yet", slog.String("alias", alias))
+
+			o.notYetActive(w, r, alias)
+
+			return
+		case kind == storage.KindNotFound:
 			log.Info("url not found", "alias", alias)
 
-			render.JSON(w, r, resp.Error("not found"))
+			o.notFound(w, r, alias)
 
 			return
-		}
-		if err != nil {
+		case kind == storage.KindTransient:
+			log.Warn("storage is unavailable", sl.Err(err))
+
+			resp.NoStore(w)
+			w.Header().Set("Retry-After", retryAfter)
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeUnavailable, "temporarily unavailable"))
+
+			return
+		default:
 			log.Error("failed to get url", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("internal error"))
+			resp.NoStore(w)
+
+			if o.renderPage(w, r, http.StatusInternalServerError, alias) {
+				return
+			}
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
 
 			return
 		}
 
 		log.Info("got url", slog.String("url", resURL))
 
+		if o.rules != nil {
+			resURL = o.ruleTarget(w, r, log, alias, resURL)
+		}
+
+		if resURL != waitedFor && !o.wait(r, log, alias, resURL) {
+			return
+		}
+
+		o.recordClick(r, log, alias)
+		o.setCacheControl(w, log, alias)
+		o.setAliasHeader(w, alias)
+
 		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+		o.redirect(w, r, resURL)
+	}
+}
+
+// ruleTarget returns the target of the first redirect rule of alias
+// matching r, or fallback. Rules which fail to load are skipped.
+func (o options) ruleTarget(w http.ResponseWriter, r *http.Request, log *slog.Logger, alias string, fallback string) string {
+	rules, err := o.rules.GetRedirectRules(alias)
+	if err != nil {
+		log.Error("failed to get redirect rules", sl.Err(err))
+
+		return fallback
+	}
+	if len(rules) == 0 {
+		return fallback
+	}
+
+	// the target depends on these headers
+	for _, h := range o.matcher.Vary() {
+		w.Header().Add("Vary", h)
+	}
+
+	target, ok := o.matcher.Match(rules, r)
+	if !ok {
+		return fallback
+	}
+
+	log.Info("redirect rule matched", slog.String("url", target))
+
+	return target
+}
+
+// redirect sends the client to target, or hands it to the proxy
+// in accel mode.
+func (o options) redirect(w http.ResponseWriter, r *http.Request, target string) {
+	if o.accelHeader == "" {
+		http.Redirect(w, r, target, http.StatusFound)
+
+		return
+	}
+
+	w.Header().Set(o.accelHeader, o.accelPrefix+target)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (o options) setAliasHeader(w http.ResponseWriter, alias string) {
+	if o.aliasHeader {
+		w.Header().Set(AliasHeader, alias)
+	}
+}
+
+// notFound responds that alias doesn't exist, redirecting to the
+// configured target if any.
+func (o options) notFound(w http.ResponseWriter, r *http.Request, alias string) {
+	resp.NoStore(w)
+
+	if o.notFoundRedirect != "" && !isSelfRedirect(r, alias, o.notFoundRedirect) {
+		http.Redirect(w, r, o.notFoundRedirect, http.StatusFound)
+
+		return
+	}
+
+	if o.renderPage(w, r, http.StatusNotFound, alias) {
+		return
+	}
+
+	render.Status(r, http.StatusNotFound)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotFound, "not found"))
+}
+
+// notYetActive responds that alias exists but is not available yet.
+func (o options) notYetActive(w http.ResponseWriter, r *http.Request, alias string) {
+	resp.NoStore(w)
+
+	if o.errorPages != nil && errorpage.AcceptsHTML(r) && o.errorPages.Render(w, http.StatusNotFound, errorpage.Data{
+		Alias:        alias,
+		RequestID:    middleware.GetReqID(r.Context()),
+		NotYetActive: true,
+	}) {
+		return
+	}
+
+	render.Status(r, http.StatusNotFound)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotYetActive, "not yet available"))
+}
+
+// wait applies the delay of redirecting alias to target. It reports
+// false if the client left meanwhile, so there is no one to respond to.
+func (o options) wait(r *http.Request, log *slog.Logger, alias string, target string) bool {
+	if o.sleeper == nil {
+		return true
+	}
+
+	d := o.delays.For(alias, target)
+	if d <= 0 {
+		return true
+	}
+
+	if err := o.sleeper.Sleep(r.Context(), d); err != nil {
+		log.Info("client left during redirect delay", slog.String("alias", alias), sl.Err(err))
+
+		return false
+	}
+
+	return true
+}
+
+// recordClick counts the redirect and samples its event. A failure is
+// only logged, since it must not break the redirect.
+func (o options) recordClick(r *http.Request, log *slog.Logger, alias string) {
+	if o.clickEvents != nil {
+		o.clickEvents.Sample(r, alias)
 	}
+
+	if o.clicks == nil {
+		return
+	}
+
+	if err := o.clicks.RecordClick(alias); err != nil {
+		log.Error("failed to record click", sl.Err(err))
+	}
+}
+
+// setCacheControl sets Cache-Control from the cache TTL of alias unless
+// the response was already made uncacheable, e.g. for a protected alias.
+func (o options) setCacheControl(w http.ResponseWriter, log *slog.Logger, alias string) {
+	if o.cacheTTLs == nil || w.Header().Get("Cache-Control") != "" {
+		return
+	}
+
+	ttl, err := o.cacheTTLs.GetCacheTTL(alias)
+	if err != nil {
+		log.Error("failed to get cache ttl", sl.Err(err))
+	}
+
+	if ttl <= 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+
+		return
+	}
+
+	w.Header().Set("Cache-Control", "max-age="+strconv.FormatInt(int64(ttl/time.Second), 10))
+}
+
+// checkPassword reports whether the redirect may proceed. Otherwise it
+// has already responded with a password challenge or an error.
+func (o options) checkPassword(w http.ResponseWriter, r *http.Request, log *slog.Logger, alias string) bool {
+	hash, err := o.passwords.GetPasswordHash(alias)
+	if errors.Is(err, storage.ErrURLNotFound) {
+		// the not found response is up to the main flow
+		return true
+	}
+	if err != nil {
+		log.Error("failed to get password hash", sl.Err(err))
+
+		resp.NoStore(w)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+		return false
+	}
+
+	if hash == "" {
+		return true
+	}
+
+	// the redirect of a protected alias must not be cached
+	resp.NoStore(w)
+
+	password := r.URL.Query().Get("pw")
+	if password == "" {
+		password = r.Header.Get(PasswordHeader)
+	}
+
+	if password == "" {
+		log.Info("password required", slog.String("alias", alias))
+
+		challenge(w, r, "password required")
+
+		return false
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		attempts, err := o.passwords.RecordFailedAttempt(alias)
+		if err != nil {
+			log.Error("failed to record failed attempt", sl.Err(err))
+		}
+
+		log.Warn("wrong password", slog.String("alias", alias), slog.Int64("failed_attempts", attempts))
+
+		challenge(w, r, "wrong password")
+
+		return false
+	}
+
+	return true
+}
+
+var passwordForm = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Password required</title>
+</head>
+<body>
+	<form method="get">
+		<p>{{.}}</p>
+		<input type="password" name="pw" autofocus>
+		<button type="submit">Open</button>
+	</form>
+</body>
+</html>
+`))
+
+// challenge asks the client for the password with an HTML form
+// for browsers and a JSON error otherwise.
+func challenge(w http.ResponseWriter, r *http.Request, msg string) {
+	if errorpage.AcceptsHTML(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = passwordForm.Execute(w, msg)
+
+		return
+	}
+
+	render.Status(r, http.StatusUnauthorized)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeUnauthorized, msg))
+}
+
+// renderPage writes the HTML error page if it is configured and the client accepts HTML.
+func (o options) renderPage(w http.ResponseWriter, r *http.Request, status int, alias string) bool {
+	if o.errorPages == nil || !errorpage.AcceptsHTML(r) {
+		return false
+	}
+
+	return o.errorPages.Render(w, status, errorpage.Data{
+		Alias:     alias,
+		RequestID: middleware.GetReqID(r.Context()),
+	})
+}
+
+// isSelfRedirect reports whether target points back to the requested alias
+// on this host, which would make the fallback redirect loop forever.
+func isSelfRedirect(r *http.Request, alias string, target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	if u.Host != "" && !strings.EqualFold(u.Host, r.Host) {
+		return false
+	}
+
+	return strings.Trim(u.Path, "/") == alias
 }
@@ -1,17 +1,33 @@
 package redirect_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 
+	"url-shortener/internal/http-server/errorpage"
 	"url-shortener/internal/http-server/handlers/redirect"
 	"url-shortener/internal/http-server/handlers/redirect/mocks"
 	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/clock"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/redirectrule"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
 )
 
 func TestSaveHandler(t *testing.T) {
@@ -52,3 +68,918 @@ func TestSaveHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_NotFound(t *testing.T) {
+	cases := []struct {
+		name             string
+		alias            string
+		notFoundRedirect string
+		wantLocation     string
+	}{
+		{
+			name:             "Fallback configured",
+			alias:            "missing",
+			notFoundRedirect: "https://example.com/",
+			wantLocation:     "https://example.com/",
+		},
+		{
+			name:  "Fallback not configured",
+			alias: "missing",
+		},
+		{
+			name:             "Fallback points to the same alias",
+			alias:            "home",
+			notFoundRedirect: "/home",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+
+			urlGetterMock.On("GetURL", tc.alias).
+				Return("", storage.ErrURLNotFound).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithNotFoundRedirect(tc.notFoundRedirect),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/"+tc.alias, nil)
+			rr := httptest.NewRecorder()
+
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			if tc.wantLocation != "" {
+				assert.Equal(t, http.StatusFound, rr.Code)
+				assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+
+				return
+			}
+
+			assert.Empty(t, rr.Header().Get("Location"))
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+
+			var resp response.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, "not found", resp.Error)
+			assert.Equal(t, response.CodeNotFound, resp.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_Errors(t *testing.T) {
+	cases := []struct {
+		name       string
+		mockError  error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "Not found",
+			mockError:  storage.ErrURLNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Storage unavailable",
+			mockError:  storage.NewError("op", storage.KindTransient, errors.New("database is locked")),
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   response.CodeUnavailable,
+		},
+		{
+			name:       "Storage failure",
+			mockError:  errors.New("database is locked"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   response.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "test_alias").
+				Return("", tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, response.StatusError, resp.Status)
+			assert.Equal(t, tc.wantCode, resp.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_ActiveFrom(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://www.google.com/", "launch",
+		storage.WithActiveFrom(start.Add(time.Hour)),
+		storage.WithExpiresAt(start.Add(2*time.Hour)),
+	)
+	require.NoError(t, err)
+
+	pages, err := errorpage.New("")
+	require.NoError(t, err)
+
+	newRouter := func(opts ...redirect.Option) *chi.Mux {
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), s, opts...))
+
+		return r
+	}
+
+	code := func(rr *httptest.ResponseRecorder) string {
+		var resp response.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		return resp.Code
+	}
+
+	withPage := newRouter(redirect.WithNotYetActivePage(true), redirect.WithErrorPages(pages))
+	withoutPage := newRouter()
+
+	// before activation
+	rr := httptest.NewRecorder()
+	withPage.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/launch", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, response.CodeNotYetActive, code(rr))
+
+	req := httptest.NewRequest(http.MethodGet, "/launch", nil)
+	req.Header.Set("Accept", "text/html")
+	rr = httptest.NewRecorder()
+	withPage.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "isn't available yet")
+
+	rr = httptest.NewRecorder()
+	withoutPage.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/launch", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, response.CodeNotFound, code(rr))
+
+	// within the window
+	clk.Set(start.Add(time.Hour))
+
+	rr = httptest.NewRecorder()
+	withPage.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/launch", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "https://www.google.com/", rr.Header().Get("Location"))
+
+	// after expiration
+	clk.Set(start.Add(2 * time.Hour))
+
+	rr = httptest.NewRecorder()
+	withPage.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/launch", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, response.CodeNotFound, code(rr))
+}
+
+func TestRedirectHandler_CacheHeaders(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "test_alias").
+		Return("https://www.google.com/", nil).Once()
+	urlGetterMock.On("GetURL", "broken").
+		Return("", errors.New("unexpected error")).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.NotEqual(t, "no-store", rr.Header().Get("Cache-Control"))
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+}
+
+func TestRedirectHandler_ErrorPages(t *testing.T) {
+	pages, err := errorpage.New("")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		accept   string
+		wantHTML bool
+	}{
+		{name: "Browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", wantHTML: true},
+		{name: "API client", accept: "application/json"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+
+			urlGetterMock.On("GetURL", "missing").
+				Return("", storage.ErrURLNotFound).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithErrorPages(pages),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			req.Header.Set("Accept", tc.accept)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			if tc.wantHTML {
+				assert.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+				assert.Contains(t, rr.Body.String(), "<b>missing</b>")
+
+				return
+			}
+
+			var resp response.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, "not found", resp.Error)
+		})
+	}
+}
+
+func TestRedirectHandler_Password(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		hash         string
+		query        string
+		header       string
+		accept       string
+		wantCode     int
+		wantFailed   bool
+		wantRedirect bool
+	}{
+		{
+			name:         "Unprotected",
+			wantCode:     http.StatusFound,
+			wantRedirect: true,
+		},
+		{
+			name:         "Correct password",
+			hash:         string(hash),
+			query:        "?pw=secret",
+			wantCode:     http.StatusFound,
+			wantRedirect: true,
+		},
+		{
+			name:         "Correct password in header",
+			hash:         string(hash),
+			header:       "secret",
+			wantCode:     http.StatusFound,
+			wantRedirect: true,
+		},
+		{
+			name:       "Wrong password",
+			hash:       string(hash),
+			query:      "?pw=wrong",
+			wantCode:   http.StatusUnauthorized,
+			wantFailed: true,
+		},
+		{
+			name:     "No password",
+			hash:     string(hash),
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "No password from browser",
+			hash:     string(hash),
+			accept:   "text/html",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			passwordsMock := mocks.NewPasswordChecker(t)
+
+			passwordsMock.On("GetPasswordHash", "shared").
+				Return(tc.hash, nil).Once()
+
+			if tc.wantRedirect {
+				urlGetterMock.On("GetURL", "shared").
+					Return("https://example.com", nil).Once()
+			}
+			if tc.wantFailed {
+				passwordsMock.On("RecordFailedAttempt", "shared").
+					Return(int64(1), nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithPasswords(passwordsMock),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/shared"+tc.query, nil)
+			if tc.header != "" {
+				req.Header.Set(redirect.PasswordHeader, tc.header)
+			}
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+
+			if tc.wantRedirect {
+				assert.Equal(t, "https://example.com", rr.Header().Get("Location"))
+
+				return
+			}
+
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			assert.Empty(t, rr.Header().Get("Location"))
+
+			if tc.accept != "" {
+				assert.Contains(t, rr.Body.String(), `name="pw"`)
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_OneTime(t *testing.T) {
+	cases := []struct {
+		name         string
+		url          string
+		oneTime      bool
+		err          error
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "First use",
+			url:          "https://example.com",
+			oneTime:      true,
+			wantCode:     http.StatusFound,
+			wantLocation: "https://example.com",
+		},
+		{
+			name:     "Already used",
+			oneTime:  true,
+			err:      storage.ErrURLGone,
+			wantCode: http.StatusGone,
+		},
+		{
+			name:         "Regular alias",
+			wantCode:     http.StatusFound,
+			wantLocation: "https://regular.com",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			consumerMock := mocks.NewOneTimeConsumer(t)
+
+			consumerMock.On("ConsumeOneTime", "once").
+				Return(tc.url, tc.oneTime, tc.err).Once()
+
+			if !tc.oneTime {
+				urlGetterMock.On("GetURL", "once").
+					Return("https://regular.com", nil).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithOneTime(consumerMock),
+			))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/once", nil))
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+
+			if tc.oneTime {
+				assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_OneTimeConcurrent(t *testing.T) {
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db") + "?_busy_timeout=5000")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), s, redirect.WithOneTime(s)))
+
+	const requests = 2
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		codes = make(chan int, requests)
+	)
+
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/once", nil))
+
+			codes <- rr.Code
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+
+	assert.Equal(t, map[int]int{http.StatusFound: 1, http.StatusGone: 1}, counts)
+}
+
+func TestRedirectHandler_Clicks(t *testing.T) {
+	cases := []struct {
+		name      string
+		getErr    error
+		clickErr  error
+		wantCode  int
+		wantClick bool
+	}{
+		{
+			name:      "Counted",
+			wantCode:  http.StatusFound,
+			wantClick: true,
+		},
+		{
+			name:      "Failure doesn't break redirect",
+			clickErr:  errors.New("unexpected error"),
+			wantCode:  http.StatusFound,
+			wantClick: true,
+		},
+		{
+			name:     "Not found isn't counted",
+			getErr:   storage.ErrURLNotFound,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "abc").
+				Return("https://example.com", tc.getErr).Once()
+
+			clicksMock := mocks.NewClickRecorder(t)
+			if tc.wantClick {
+				clicksMock.On("RecordClick", "abc").
+					Return(tc.clickErr).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithClicks(clicksMock),
+			))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_Delays(t *testing.T) {
+	delays := redirect.Delays{
+		Default: time.Second,
+		Hosts:   map[string]time.Duration{"partner.com": 3 * time.Second},
+		Aliases: map[string]time.Duration{"fast": 0, "slow": 5 * time.Second},
+	}
+
+	cases := []struct {
+		name      string
+		alias     string
+		url       string
+		delays    redirect.Delays
+		wantDelay time.Duration
+	}{
+		{name: "No delay by default", alias: "abc", url: "https://example.com"},
+		{name: "Default", alias: "abc", url: "https://example.com", delays: delays, wantDelay: time.Second},
+		{name: "Host", alias: "abc", url: "https://Partner.com/page", delays: delays, wantDelay: 3 * time.Second},
+		{name: "Alias", alias: "slow", url: "https://partner.com", delays: delays, wantDelay: 5 * time.Second},
+		{name: "Alias without delay", alias: "fast", url: "https://partner.com", delays: delays},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+			clk := clock.NewFake(start)
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", tc.alias).Return(tc.url, nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithDelays(tc.delays, clk),
+			))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+tc.alias, nil))
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.url, rr.Header().Get("Location"))
+			assert.Equal(t, tc.wantDelay, clk.Now().Sub(start))
+		})
+	}
+}
+
+func TestRedirectHandler_AliasHeader(t *testing.T) {
+	cases := []struct {
+		name      string
+		enabled   bool
+		path      string
+		alias     string
+		found     bool
+		wantAlias string
+	}{
+		{name: "Enabled", enabled: true, path: "/abc", alias: "abc", found: true, wantAlias: "abc"},
+		{name: "Namespaced", enabled: true, path: "/team/abc", alias: "team/abc", found: true, wantAlias: "team/abc"},
+		{name: "Disabled", path: "/abc", alias: "abc", found: true},
+		{name: "Not found", enabled: true, path: "/abc", alias: "abc"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.found {
+				urlGetterMock.On("GetURL", tc.alias).Return("https://example.com", nil).Once()
+			} else {
+				urlGetterMock.On("GetURL", tc.alias).Return("", storage.ErrURLNotFound).Once()
+			}
+
+			handler := redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithAliasHeader(tc.enabled),
+			)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", handler)
+			r.Get("/{namespace}/{alias}", handler)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, tc.wantAlias, rr.Header().Get(redirect.AliasHeader))
+			if tc.found {
+				assert.Equal(t, http.StatusFound, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_AccelRedirect(t *testing.T) {
+	cases := []struct {
+		name         string
+		header       string
+		prefix       string
+		wantCode     int
+		wantLocation string
+		wantAccel    string
+	}{
+		{name: "Standard", wantCode: http.StatusFound, wantLocation: "https://example.com/page"},
+		{
+			name:      "Accel",
+			header:    "X-Accel-Redirect",
+			prefix:    "/internal/",
+			wantCode:  http.StatusOK,
+			wantAccel: "/internal/https://example.com/page",
+		},
+		{
+			name:      "Custom header",
+			header:    "X-Sendfile-Redirect",
+			wantCode:  http.StatusOK,
+			wantAccel: "https://example.com/page",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "abc").Return("https://example.com/page", nil).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithAccelRedirect(tc.header, tc.prefix),
+			))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+			if tc.header != "" {
+				assert.Equal(t, tc.wantAccel, rr.Header().Get(tc.header))
+			} else {
+				assert.Empty(t, rr.Header().Get("X-Accel-Redirect"))
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_RedirectRules(t *testing.T) {
+	const (
+		iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148"
+		desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36"
+	)
+
+	rules := []storage.RedirectRule{
+		{Device: redirectrule.DeviceMobile, URL: "https://apps.apple.com/app/id1"},
+	}
+
+	cases := []struct {
+		name         string
+		userAgent    string
+		rules        []storage.RedirectRule
+		rulesErr     error
+		wantLocation string
+		wantVary     bool
+	}{
+		{name: "Mobile", userAgent: iPhoneUA, rules: rules, wantLocation: "https://apps.apple.com/app/id1", wantVary: true},
+		{name: "Desktop falls back", userAgent: desktopUA, rules: rules, wantLocation: "https://example.com", wantVary: true},
+		{name: "No rules", userAgent: iPhoneUA, wantLocation: "https://example.com"},
+		{name: "Rules error", userAgent: iPhoneUA, rulesErr: errors.New("unexpected error"), wantLocation: "https://example.com"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "abc").Return("https://example.com", nil).Once()
+
+			rulesMock := mocks.NewRedirectRulesGetter(t)
+			rulesMock.On("GetRedirectRules", "abc").Return(tc.rules, tc.rulesErr).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithRedirectRules(rulesMock, redirectrule.Matcher{}),
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			req.Header.Set("User-Agent", tc.userAgent)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+			assert.Equal(t, tc.wantVary, len(rr.Header().Values("Vary")) > 0)
+		})
+	}
+}
+
+func TestRedirectHandler_DelayClientGone(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "abc").Return("https://example.com", nil).Once()
+
+	clicksMock := mocks.NewClickRecorder(t)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(
+		slogdiscard.NewDiscardLogger(),
+		urlGetterMock,
+		redirect.WithDelays(redirect.Delays{Default: time.Second}, clock.Real{}),
+		redirect.WithClicks(clicksMock),
+	))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil).WithContext(ctx))
+
+	assert.Empty(t, rr.Header().Get("Location"))
+}
+
+func TestRedirectHandler_DelayOneTime(t *testing.T) {
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	newRouter := func(sleeper clock.Sleeper) http.Handler {
+		r := chi.NewRouter()
+		r.Get("/{alias}", redirect.New(
+			slogdiscard.NewDiscardLogger(),
+			s,
+			redirect.WithOneTime(s),
+			redirect.WithDelays(redirect.Delays{Default: time.Second}, sleeper),
+		))
+
+		return r
+	}
+
+	// a client leaving during the delay doesn't use up the alias
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rr := httptest.NewRecorder()
+	newRouter(clock.Real{}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/once", nil).WithContext(ctx))
+	assert.Empty(t, rr.Header().Get("Location"))
+
+	rr = httptest.NewRecorder()
+	newRouter(clk).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/once", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "https://example.com", rr.Header().Get("Location"))
+	assert.Equal(t, time.Second, clk.Now().Sub(start), "waited once")
+
+	rr = httptest.NewRecorder()
+	newRouter(clk).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/once", nil))
+	assert.Equal(t, http.StatusGone, rr.Code)
+}
+
+func TestRedirectHandler_ClickEvents(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "abc").Return("https://example.com", nil).Once()
+	urlGetterMock.On("GetURL", "missing").Return("", storage.ErrURLNotFound).Once()
+
+	samplerMock := mocks.NewClickSampler(t)
+	samplerMock.On("Sample", mock.AnythingOfType("*http.Request"), "abc").Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(
+		slogdiscard.NewDiscardLogger(),
+		urlGetterMock,
+		redirect.WithClickEvents(samplerMock),
+	))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	// not found redirects are not sampled
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestRedirectHandler_AliasChecksum(t *testing.T) {
+	alias := checksum.Append("abc123")
+	typo := "abd123" + alias[len(alias)-1:]
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", alias).Return("https://example.com", nil).Once()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(
+		slogdiscard.NewDiscardLogger(),
+		urlGetterMock,
+		redirect.WithAliasChecksum(true),
+	))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+alias, nil))
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "https://example.com", rr.Header().Get("Location"))
+
+	// the typo is rejected without a storage lookup
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+typo, nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	urlGetterMock.AssertNotCalled(t, "GetURL", typo)
+}
+
+func TestRedirectHandler_CacheTTL(t *testing.T) {
+	cases := []struct {
+		name         string
+		ttl          time.Duration
+		ttlErr       error
+		wantCacheHdr string
+	}{
+		{
+			name:         "Stored TTL",
+			ttl:          time.Hour,
+			wantCacheHdr: "max-age=3600",
+		},
+		{
+			name:         "No TTL",
+			wantCacheHdr: "no-cache",
+		},
+		{
+			name:         "Error",
+			ttlErr:       errors.New("unexpected error"),
+			wantCacheHdr: "no-cache",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", "abc").
+				Return("https://example.com", nil).Once()
+
+			cacheTTLMock := mocks.NewCacheTTLGetter(t)
+			cacheTTLMock.On("GetCacheTTL", "abc").
+				Return(tc.ttl, tc.ttlErr).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(),
+				urlGetterMock,
+				redirect.WithCacheTTL(cacheTTLMock),
+			))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+			assert.Equal(t, tc.wantCacheHdr, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestRedirectHandler_CacheTTLUpdated(t *testing.T) {
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	_, err = s.SaveURL("https://example.com", "abc", storage.WithOwner("alice"), storage.WithCacheTTL(time.Minute))
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), s, redirect.WithCacheTTL(s)))
+
+	cacheControl := func() string {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+		require.Equal(t, http.StatusFound, rr.Code)
+
+		return rr.Header().Get("Cache-Control")
+	}
+
+	assert.Equal(t, "max-age=60", cacheControl())
+
+	_, err = s.UpsertURL("abc", "https://example.com/new", "alice", storage.WithCacheTTL(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "max-age=3600", cacheControl())
+
+	_, err = s.UpsertURL("abc", "https://example.com/new", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "no-cache", cacheControl())
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+type Route struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+}
+
+type Response struct {
+	resp.Response
+	Routes []Route `json:"routes"`
+}
+
+// New lists the methods and patterns registered in router.
+// The router is walked on each request, so routes added later are listed too.
+func New(log *slog.Logger, router chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.routes.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var routes []Route
+
+		err := chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+			routes = append(routes, Route{Method: method, Pattern: route})
+
+			return nil
+		})
+		if err != nil {
+			log.Error("failed to walk routes", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		sort.Slice(routes, func(i, j int) bool {
+			if routes[i].Pattern != routes[j].Pattern {
+				return routes[i].Pattern < routes[j].Pattern
+			}
+
+			return routes[i].Method < routes[j].Method
+		})
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Routes:   routes,
+		})
+	}
+}
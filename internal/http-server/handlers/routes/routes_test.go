@@ -0,0 +1,75 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/routes"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestRoutesHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		pprof     bool
+		wantPprof bool
+	}{
+		{name: "Pprof disabled", pprof: false, wantPprof: false},
+		{name: "Pprof enabled", pprof: true, wantPprof: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			noop := func(http.ResponseWriter, *http.Request) {}
+
+			router := chi.NewRouter()
+			router.Route("/url", func(r chi.Router) {
+				r.Use(middleware.BasicAuth("url-shortener", map[string]string{"user": "secret"}))
+
+				r.Post("/", noop)
+				r.Delete("/{alias}", noop)
+			})
+			router.Get("/{alias}", noop)
+			router.Get("/api/routes", routes.New(slogdiscard.NewDiscardLogger(), router))
+
+			if tc.pprof {
+				router.Mount("/debug", middleware.Profiler())
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/routes", nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp routes.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Contains(t, resp.Routes, routes.Route{Method: http.MethodPost, Pattern: "/url/"})
+			assert.Contains(t, resp.Routes, routes.Route{Method: http.MethodDelete, Pattern: "/url/{alias}"})
+			assert.Contains(t, resp.Routes, routes.Route{Method: http.MethodGet, Pattern: "/{alias}"})
+			assert.Contains(t, resp.Routes, routes.Route{Method: http.MethodGet, Pattern: "/api/routes"})
+
+			hasPprof := false
+			for _, route := range resp.Routes {
+				if route.Pattern == "/debug/pprof/*" {
+					hasPprof = true
+				}
+			}
+
+			assert.Equal(t, tc.wantPprof, hasPprof)
+			assert.NotContains(t, rr.Body.String(), "secret")
+		})
+	}
+}
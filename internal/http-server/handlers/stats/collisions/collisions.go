@@ -0,0 +1,124 @@
+package collisions
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultDays = 30
+	maxDays     = 365
+
+	dayLayout = "2006-01-02"
+)
+
+type Response struct {
+	resp.Response
+	Days []storage.DailyCount `json:"days"`
+}
+
+// CollisionStats is an interface for reading daily collision counters.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollisionStats
+type CollisionStats interface {
+	Collisions(since time.Time) ([]storage.DailyCount, error)
+}
+
+type options struct {
+	clock clock.Clock
+}
+
+// Option configures the collisions handler.
+type Option func(*options)
+
+// WithClock sets the clock which decides what today is.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// New returns the collisions of generated aliases for the last N days
+// including today, one entry per UTC day, oldest first.
+func New(log *slog.Logger, stats CollisionStats, opts ...Option) http.HandlerFunc {
+	o := options{
+		clock: clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.stats.collisions.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		days := defaultDays
+
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				log.Info("invalid days", slog.String("days", v))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid days"))
+
+				return
+			}
+
+			days = n
+			if days > maxDays {
+				days = maxDays
+			}
+		}
+
+		since := o.clock.Now().UTC().AddDate(0, 0, -(days - 1))
+
+		counts, err := stats.Collisions(since)
+		if err != nil {
+			log.Error("failed to get collisions", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Days:     series(since, days, counts),
+		})
+	}
+}
+
+// series returns a counter for each of days starting from since,
+// with zero for days missing in counts.
+func series(since time.Time, days int, counts []storage.DailyCount) []storage.DailyCount {
+	byDay := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		byDay[c.Day] = c.Count
+	}
+
+	out := make([]storage.DailyCount, 0, days)
+	for i := 0; i < days; i++ {
+		day := since.AddDate(0, 0, i).Format(dayLayout)
+		out = append(out, storage.DailyCount{Day: day, Count: byDay[day]})
+	}
+
+	return out
+}
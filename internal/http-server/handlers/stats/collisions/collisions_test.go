@@ -0,0 +1,104 @@
+package collisions_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/stats/collisions"
+	"url-shortener/internal/http-server/handlers/stats/collisions/mocks"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestCollisionsHandler(t *testing.T) {
+	today := time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC)
+	clk := clock.NewFake(today)
+
+	day := func(ago int) string {
+		return today.AddDate(0, 0, -ago).Format("2006-01-02")
+	}
+
+	cases := []struct {
+		name      string
+		query     string
+		counts    []storage.DailyCount
+		mockError error
+		wantCode  int
+		wantDays  []storage.DailyCount
+		respError string
+	}{
+		{
+			name:  "Fills missing days",
+			query: "?days=3",
+			counts: []storage.DailyCount{
+				{Day: day(2), Count: 4},
+				{Day: day(0), Count: 1},
+			},
+			wantCode: http.StatusOK,
+			wantDays: []storage.DailyCount{
+				{Day: day(2), Count: 4},
+				{Day: day(1), Count: 0},
+				{Day: day(0), Count: 1},
+			},
+		},
+		{
+			name:     "No collisions",
+			query:    "?days=1",
+			counts:   []storage.DailyCount{},
+			wantCode: http.StatusOK,
+			wantDays: []storage.DailyCount{{Day: day(0), Count: 0}},
+		},
+		{
+			name:      "Invalid days",
+			query:     "?days=zero",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid days",
+		},
+		{
+			name:      "Storage error",
+			query:     "?days=3",
+			mockError: errors.New("unexpected error"),
+			wantCode:  http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			statsMock := mocks.NewCollisionStats(t)
+			if tc.counts != nil || tc.mockError != nil {
+				statsMock.On("Collisions", mock.AnythingOfType("time.Time")).
+					Return(tc.counts, tc.mockError).
+					Once()
+			}
+
+			handler := collisions.New(slogdiscard.NewDiscardLogger(), statsMock, collisions.WithClock(clk))
+
+			req, err := http.NewRequest(http.MethodGet, "/stats/collisions"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var resp collisions.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.wantDays, resp.Days)
+		})
+	}
+}
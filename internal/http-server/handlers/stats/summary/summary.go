@@ -0,0 +1,81 @@
+package summary
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultTop = 10
+	maxTop     = 100
+)
+
+type Response struct {
+	resp.Response
+	storage.Summary
+}
+
+// Summarizer is an interface for aggregating all saved urls.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Summarizer
+type Summarizer interface {
+	Summary(ctx context.Context, topN int) (storage.Summary, error)
+}
+
+// New returns the totals of saved urls and clicks and the most
+// clicked aliases, "top" of them (10 by default).
+func New(log *slog.Logger, summarizer Summarizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.stats.summary.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		top := defaultTop
+
+		if v := r.URL.Query().Get("top"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				log.Info("invalid top", slog.String("top", v))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid top"))
+
+				return
+			}
+
+			top = n
+			if top > maxTop {
+				top = maxTop
+			}
+		}
+
+		sum, err := summarizer.Summary(r.Context(), top)
+		if err != nil {
+			log.Error("failed to get summary", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Summary:  sum,
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package summary_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/stats/summary"
+	"url-shortener/internal/http-server/handlers/stats/summary/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestSummaryHandler(t *testing.T) {
+	sum := storage.Summary{
+		TotalURLs:      3,
+		TotalClicks:    7,
+		CreatedLastDay: 1,
+		Top:            []storage.AliasClicks{{Alias: "b", Clicks: 5}, {Alias: "a", Clicks: 2}},
+	}
+
+	cases := []struct {
+		name      string
+		query     string
+		wantTop   int
+		mockError error
+		wantCode  int
+		respError string
+	}{
+		{
+			name:     "Default top",
+			wantTop:  10,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Custom top",
+			query:    "?top=2",
+			wantTop:  2,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Top capped",
+			query:    "?top=1000",
+			wantTop:  100,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Invalid top",
+			query:     "?top=-1",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid top",
+		},
+		{
+			name:      "Storage error",
+			wantTop:   10,
+			mockError: errors.New("unexpected error"),
+			wantCode:  http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			summarizerMock := mocks.NewSummarizer(t)
+			if tc.wantTop > 0 {
+				summarizerMock.On("Summary", mock.Anything, tc.wantTop).
+					Return(sum, tc.mockError).
+					Once()
+			}
+
+			handler := summary.New(slogdiscard.NewDiscardLogger(), summarizerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/stats/summary"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var resp summary.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, sum, resp.Summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package clicksync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/api/request"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// MaxAliases is the maximum number of counters synced in one request.
+const MaxAliases = 1000
+
+type Count struct {
+	Alias  string `json:"alias" validate:"required"`
+	Clicks int64  `json:"clicks" validate:"min=0"`
+}
+
+type Request struct {
+	Clicks []Count `json:"clicks" validate:"required,dive"`
+}
+
+type Response struct {
+	resp.Response
+	Updated  int      `json:"updated"`
+	NotFound []string `json:"not_found,omitempty"`
+}
+
+// ClicksSetter is an interface for replacing click counters.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ClicksSetter
+type ClicksSetter interface {
+	SetClicks(ctx context.Context, counts []storage.AliasClicks) ([]string, error)
+}
+
+// New replaces the click counters of the aliases with the given counts,
+// e.g. computed from CDN logs. Unknown aliases are reported in not_found,
+// the others are updated anyway.
+func New(log *slog.Logger, setter ClicksSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.clicksync.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		var req Request
+
+		err := request.DecodeJSON(r, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "empty request"))
+
+			return
+		}
+		if errors.Is(err, request.ErrTooLarge) {
+			log.Error("request body is too large", sl.Err(err))
+
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodePayloadTooLarge, "request body is too large"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Info("invalid request", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if len(req.Clicks) == 0 {
+			log.Info("clicks list is empty")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "clicks list is empty"))
+
+			return
+		}
+		if len(req.Clicks) > MaxAliases {
+			log.Info("too many aliases", slog.Int("count", len(req.Clicks)))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, fmt.Sprintf("too many aliases, max is %d", MaxAliases)))
+
+			return
+		}
+
+		counts := make([]storage.AliasClicks, 0, len(req.Clicks))
+		for _, c := range req.Clicks {
+			counts = append(counts, storage.AliasClicks{Alias: c.Alias, Clicks: c.Clicks})
+		}
+
+		notFound, err := setter.SetClicks(r.Context(), counts)
+		if err != nil {
+			log.Error("failed to set clicks", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		log.Info("clicks synced",
+			slog.Int("updated", len(counts)-len(notFound)),
+			slog.Int("not_found", len(notFound)),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Updated:  len(counts) - len(notFound),
+			NotFound: notFound,
+		})
+	}
+}
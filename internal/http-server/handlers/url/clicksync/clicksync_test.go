@@ -0,0 +1,98 @@
+package clicksync_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/clicksync"
+	"url-shortener/internal/http-server/handlers/url/clicksync/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestClickSyncHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		body         string
+		mockCounts   []storage.AliasClicks
+		mockNotFound []string
+		wantStatus   int
+		wantUpdated  int
+		wantNotFound []string
+		respError    string
+	}{
+		{
+			name:        "Success",
+			body:        `{"clicks":[{"alias":"a","clicks":10},{"alias":"b","clicks":0}]}`,
+			mockCounts:  []storage.AliasClicks{{Alias: "a", Clicks: 10}, {Alias: "b", Clicks: 0}},
+			wantStatus:  http.StatusOK,
+			wantUpdated: 2,
+		},
+		{
+			name:         "Unknown aliases",
+			body:         `{"clicks":[{"alias":"a","clicks":3},{"alias":"missing","clicks":5}]}`,
+			mockCounts:   []storage.AliasClicks{{Alias: "a", Clicks: 3}, {Alias: "missing", Clicks: 5}},
+			mockNotFound: []string{"missing"},
+			wantStatus:   http.StatusOK,
+			wantUpdated:  1,
+			wantNotFound: []string{"missing"},
+		},
+		{
+			name:       "Negative count",
+			body:       `{"clicks":[{"alias":"a","clicks":-1}]}`,
+			wantStatus: http.StatusBadRequest,
+			respError:  "field Clicks is not valid",
+		},
+		{
+			name:       "Empty alias",
+			body:       `{"clicks":[{"alias":"","clicks":1}]}`,
+			wantStatus: http.StatusBadRequest,
+			respError:  "field Alias is a required field",
+		},
+		{
+			name:       "Empty list",
+			body:       `{"clicks":[]}`,
+			wantStatus: http.StatusBadRequest,
+			respError:  "clicks list is empty",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			setterMock := mocks.NewClicksSetter(t)
+			if tc.mockCounts != nil {
+				setterMock.On("SetClicks", mock.Anything, tc.mockCounts).
+					Return(tc.mockNotFound, nil).Once()
+			}
+
+			handler := clicksync.New(slogdiscard.NewDiscardLogger(), setterMock)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/urls/clicks/sync", strings.NewReader(tc.body)))
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp clicksync.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, response.StatusError, resp.Status)
+				assert.Equal(t, tc.respError, resp.Error)
+
+				return
+			}
+
+			assert.Equal(t, response.StatusOK, resp.Status)
+			assert.Equal(t, tc.wantUpdated, resp.Updated)
+			assert.Equal(t, tc.wantNotFound, resp.NotFound)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package delete
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// URLDeleter is an interface for deleting url by alias. Only the owner
+// of the url may delete it.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLDeleter
+type URLDeleter interface {
+	DeleteURL(alias string, owner string) error
+}
+
+// Auditor records mutating operations for the audit trail.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Auditor
+type Auditor interface {
+	Record(entry storage.AuditEntry)
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(storage.AuditEntry) {}
+
+type options struct {
+	auditor Auditor
+}
+
+// Option configures the delete handler.
+type Option func(*options)
+
+// WithAuditor makes the handler record every delete attempt.
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+func New(log *slog.Logger, urlDeleter URLDeleter, opts ...Option) http.HandlerFunc {
+	o := options{auditor: nopAuditor{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		urlDeleter := storage.WithContext(r.Context(), urlDeleter)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+		owner, _, _ := r.BasicAuth()
+
+		entry := storage.AuditEntry{
+			Actor:  audit.Actor(r),
+			Action: audit.ActionDelete,
+			Alias:  alias,
+		}
+
+		err := urlDeleter.DeleteURL(alias, owner)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+
+			entry.Result = "not found"
+			o.auditor.Record(entry)
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotFound, "not found"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNotOwner) {
+			log.Info("alias is owned by another user", slog.String("alias", alias))
+
+			entry.Result = "alias is owned by another user"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeForbidden, "alias is owned by another user"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to delete url", sl.Err(err))
+
+			entry.Result = "failed to delete url"
+			o.auditor.Record(entry)
+
+			render.JSON(w, r, resp.Error("failed to delete url"))
+
+			return
+		}
+
+		log.Info("url deleted", slog.String("alias", alias))
+
+		entry.Result = audit.ResultSuccess
+		o.auditor.Record(entry)
+
+		render.JSON(w, r, resp.OK())
+	}
+}
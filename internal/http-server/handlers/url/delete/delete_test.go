@@ -0,0 +1,107 @@
+package delete_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/delete"
+	"url-shortener/internal/http-server/handlers/url/delete/mocks"
+	"url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		respError  string
+		mockError  error
+		wantCode   int
+		wantResult string
+	}{
+		{
+			name:       "Success",
+			alias:      "test_alias",
+			wantResult: "success",
+		},
+		{
+			name:       "Not found",
+			alias:      "test_alias",
+			respError:  "not found",
+			mockError:  storage.ErrURLNotFound,
+			wantResult: "not found",
+		},
+		{
+			name:       "DeleteURL Error",
+			alias:      "test_alias",
+			respError:  "failed to delete url",
+			mockError:  errors.New("unexpected error"),
+			wantResult: "failed to delete url",
+		},
+		{
+			name:       "Owned by another user",
+			alias:      "test_alias",
+			respError:  "alias is owned by another user",
+			mockError:  storage.ErrNotOwner,
+			wantCode:   http.StatusForbidden,
+			wantResult: "alias is owned by another user",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlDeleterMock := mocks.NewURLDeleter(t)
+			urlDeleterMock.On("DeleteURL", tc.alias, "admin").
+				Return(tc.mockError).
+				Once()
+
+			auditorMock := mocks.NewAuditor(t)
+			auditorMock.On("Record", mock.MatchedBy(func(e storage.AuditEntry) bool {
+				return e.Actor == "admin" &&
+					e.Action == "delete" &&
+					e.Alias == tc.alias &&
+					e.Result == tc.wantResult
+			})).Once()
+
+			r := chi.NewRouter()
+			r.Delete("/url/{alias}", delete.New(
+				slogdiscard.NewDiscardLogger(),
+				urlDeleterMock,
+				delete.WithAuditor(auditorMock),
+			))
+
+			req, err := http.NewRequest(http.MethodDelete, "/url/"+tc.alias, nil)
+			require.NoError(t, err)
+			req.SetBasicAuth("admin", "secret")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			wantCode := tc.wantCode
+			if wantCode == 0 {
+				wantCode = http.StatusOK
+			}
+
+			require.Equal(t, wantCode, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			var resp response.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package follow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/api"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	SourceStorage  = "storage"
+	SourceExternal = "external"
+)
+
+// Hop is a step of the redirect chain. Source tells whether the URL
+// was resolved from our storage or by requesting an external server.
+type Hop struct {
+	URL    string `json:"url"`
+	Source string `json:"source"`
+}
+
+type Response struct {
+	resp.Response
+	Final string `json:"final,omitempty"`
+	Chain []Hop  `json:"chain,omitempty"`
+}
+
+// URLGetter is an interface for getting url by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLGetter
+type URLGetter interface {
+	GetURL(alias string) (string, error)
+}
+
+// ProtectionChecker tells password-protected and one-time aliases,
+// whose targets are only revealed by redirecting.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=ProtectionChecker
+type ProtectionChecker interface {
+	IsProtected(alias string) (bool, error)
+}
+
+// RedirectFunc returns the Location of a redirect response from rawURL.
+// It returns api.ErrInvalidStatusCode if the response is not a redirect.
+type RedirectFunc func(ctx context.Context, rawURL string) (string, error)
+
+type options struct {
+	redirect RedirectFunc
+}
+
+// Option configures the follow handler.
+type Option func(*options)
+
+// WithRedirectFunc replaces the default redirect func for external hops,
+// which refuses to connect to internal addresses.
+func WithRedirectFunc(f RedirectFunc) Option {
+	return func(o *options) {
+		o.redirect = f
+	}
+}
+
+var errTooManyHops = errors.New("too many hops")
+
+// hopTimeout bounds every external hop of the default redirect func.
+const hopTimeout = 5 * time.Second
+
+// New follows the target of the alias until it is not a redirect anymore,
+// at most maxHops times, and returns the final URL along with the chain.
+// Protected aliases are not followed, the chain stops at them.
+func New(
+	log *slog.Logger,
+	urlGetter URLGetter,
+	protection ProtectionChecker,
+	maxHops int,
+	opts ...Option,
+) http.HandlerFunc {
+	o := options{redirect: api.NewResolver(hopTimeout, 0, false).Redirect}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.follow.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		urlGetter := storage.WithContext(r.Context(), urlGetter)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+
+		// aliases missing from the checker, e.g. static ones, aren't protected
+		protected, err := protection.IsProtected(alias)
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			log.Error("failed to check protection", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+		if protected {
+			log.Info("alias is protected", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeForbidden, "alias is protected"))
+
+			return
+		}
+
+		target, err := urlGetter.GetURL(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotFound, "not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", sl.Err(err))
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		f := follower{
+			ctx:        r.Context(),
+			host:       r.Host,
+			urlGetter:  urlGetter,
+			protection: protection,
+			redirect:   o.redirect,
+		}
+
+		chain, err := f.follow(target, maxHops)
+		if errors.Is(err, errTooManyHops) {
+			log.Info("too many hops", slog.String("alias", alias))
+
+			render.JSON(w, r, Response{
+				Response: resp.Error(fmt.Sprintf("too many hops, max is %d", maxHops)),
+				Chain:    chain,
+			})
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to follow url", sl.Err(err))
+
+			render.JSON(w, r, Response{
+				Response: resp.Error("failed to follow url"),
+				Chain:    chain,
+			})
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Final:    chain[len(chain)-1].URL,
+			Chain:    chain,
+		})
+	}
+}
+
+type follower struct {
+	ctx        context.Context
+	host       string
+	urlGetter  URLGetter
+	protection ProtectionChecker
+	redirect   RedirectFunc
+}
+
+// follow returns the chain starting with target, which is taken from storage.
+func (f follower) follow(target string, maxHops int) ([]Hop, error) {
+	chain := []Hop{{URL: target, Source: SourceStorage}}
+
+	for hops := 0; ; hops++ {
+		current := chain[len(chain)-1].URL
+
+		next, source, err := f.next(current)
+		if err != nil {
+			return chain, err
+		}
+		if next == "" {
+			return chain, nil
+		}
+
+		if hops == maxHops {
+			return chain, errTooManyHops
+		}
+
+		chain = append(chain, Hop{URL: next, Source: source})
+	}
+}
+
+// next returns the URL current redirects to or an empty string if it doesn't redirect.
+func (f follower) next(current string) (string, string, error) {
+	u, err := url.Parse(current)
+	if err != nil {
+		return "", "", err
+	}
+
+	// our own aliases are resolved without a round trip
+	if strings.EqualFold(u.Host, f.host) {
+		alias := strings.Trim(u.Path, "/")
+
+		// the chain stops at protected aliases without revealing their target
+		protected, err := f.protection.IsProtected(alias)
+		if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+			return "", "", err
+		}
+		if protected {
+			return "", "", nil
+		}
+
+		next, err := f.urlGetter.GetURL(alias)
+		if errors.Is(err, storage.ErrURLNotFound) {
+			return "", "", nil
+		}
+		if err != nil {
+			return "", "", err
+		}
+
+		return next, SourceStorage, nil
+	}
+
+	location, err := f.redirect(f.ctx, current)
+	if errors.Is(err, api.ErrInvalidStatusCode) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if location == "" {
+		return "", "", nil
+	}
+
+	next, err := u.Parse(location)
+	if err != nil {
+		return "", "", err
+	}
+
+	return next.String(), SourceExternal, nil
+}
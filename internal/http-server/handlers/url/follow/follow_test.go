@@ -0,0 +1,190 @@
+package follow_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/follow"
+	"url-shortener/internal/http-server/handlers/url/follow/mocks"
+	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+// newExternal starts a server which redirects according to redirects,
+// a map of paths to locations, and responds 200 to other paths.
+func newExternal(t *testing.T, redirects map[string]string) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if location, ok := redirects[r.URL.Path]; ok {
+			http.Redirect(w, r, location, http.StatusFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// unprotected reports every alias as unprotected.
+func unprotected(t *testing.T) follow.ProtectionChecker {
+	protection := mocks.NewProtectionChecker(t)
+	protection.On("IsProtected", mock.Anything).Return(false, nil).Maybe()
+
+	return protection
+}
+
+func serve(t *testing.T, urlGetter follow.URLGetter, maxHops int, alias string) follow.Response {
+	t.Helper()
+
+	return serveWith(t, urlGetter, unprotected(t), maxHops, alias,
+		// the test servers listen on loopback
+		follow.WithRedirectFunc(api.NewResolver(time.Second, 0, true).Redirect),
+	)
+}
+
+func serveWith(
+	t *testing.T,
+	urlGetter follow.URLGetter,
+	protection follow.ProtectionChecker,
+	maxHops int,
+	alias string,
+	opts ...follow.Option,
+) follow.Response {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.Get("/url/{alias}/resolve", follow.New(slogdiscard.NewDiscardLogger(), urlGetter, protection, maxHops, opts...))
+
+	req := httptest.NewRequest(http.MethodGet, "/url/"+alias+"/resolve", nil)
+	req.Host = "short.link"
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp follow.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestFollowHandler_SingleHop(t *testing.T) {
+	ext := newExternal(t, map[string]string{"/a": "/final"})
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "start").Return(ext.URL+"/a", nil).Once()
+
+	resp := serve(t, urlGetterMock, 5, "start")
+
+	require.Empty(t, resp.Error)
+	assert.Equal(t, ext.URL+"/final", resp.Final)
+	assert.Equal(t, []follow.Hop{
+		{URL: ext.URL + "/a", Source: follow.SourceStorage},
+		{URL: ext.URL + "/final", Source: follow.SourceExternal},
+	}, resp.Chain)
+}
+
+func TestFollowHandler_MultiHop(t *testing.T) {
+	ext := newExternal(t, map[string]string{
+		"/a": "/b",
+		// back to our own alias, which must be resolved from storage
+		"/b": "http://short.link/second",
+	})
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "start").Return(ext.URL+"/a", nil).Once()
+	urlGetterMock.On("GetURL", "second").Return(ext.URL+"/final", nil).Once()
+
+	resp := serve(t, urlGetterMock, 5, "start")
+
+	require.Empty(t, resp.Error)
+	assert.Equal(t, ext.URL+"/final", resp.Final)
+	assert.Equal(t, []follow.Hop{
+		{URL: ext.URL + "/a", Source: follow.SourceStorage},
+		{URL: ext.URL + "/b", Source: follow.SourceExternal},
+		{URL: "http://short.link/second", Source: follow.SourceExternal},
+		{URL: ext.URL + "/final", Source: follow.SourceStorage},
+	}, resp.Chain)
+}
+
+func TestFollowHandler_TooManyHops(t *testing.T) {
+	ext := newExternal(t, map[string]string{
+		"/a": "/b",
+		"/b": "/c",
+		"/c": "/final",
+	})
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "start").Return(ext.URL+"/a", nil).Once()
+
+	resp := serve(t, urlGetterMock, 2, "start")
+
+	assert.Equal(t, "too many hops, max is 2", resp.Error)
+	assert.Empty(t, resp.Final)
+	assert.Len(t, resp.Chain, 3)
+}
+
+func TestFollowHandler_NotFound(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "missing").Return("", storage.ErrURLNotFound).Once()
+
+	resp := serve(t, urlGetterMock, 5, "missing")
+
+	assert.Equal(t, "not found", resp.Error)
+}
+
+func TestFollowHandler_InternalAddress(t *testing.T) {
+	ext := newExternal(t, map[string]string{"/a": "/final"})
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "start").Return(ext.URL+"/a", nil).Once()
+
+	// the default redirect func refuses loopback addresses
+	resp := serveWith(t, urlGetterMock, unprotected(t), 5, "start")
+
+	assert.Equal(t, "failed to follow url", resp.Error)
+	assert.Equal(t, []follow.Hop{{URL: ext.URL + "/a", Source: follow.SourceStorage}}, resp.Chain)
+}
+
+func TestFollowHandler_Protected(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+
+	protection := mocks.NewProtectionChecker(t)
+	protection.On("IsProtected", "secret").Return(true, nil).Once()
+
+	resp := serveWith(t, urlGetterMock, protection, 5, "secret")
+
+	assert.Equal(t, "alias is protected", resp.Error)
+	assert.Empty(t, resp.Chain)
+}
+
+func TestFollowHandler_ProtectedHop(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "start").Return("http://short.link/secret", nil).Once()
+
+	protection := mocks.NewProtectionChecker(t)
+	protection.On("IsProtected", "start").Return(false, nil).Once()
+	protection.On("IsProtected", "secret").Return(true, nil).Once()
+
+	resp := serveWith(t, urlGetterMock, protection, 5, "start")
+
+	// the chain stops at the protected alias without its target
+	require.Empty(t, resp.Error)
+	assert.Equal(t, "http://short.link/secret", resp.Final)
+	assert.Equal(t, []follow.Hop{{URL: "http://short.link/secret", Source: follow.SourceStorage}}, resp.Chain)
+}
@@ -0,0 +1,403 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api/request"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
+)
+
+// MaxURLs is the maximum number of urls imported in one request.
+const MaxURLs = 100
+
+// Duplicates is how links sharing an alias within one request are handled.
+type Duplicates string
+
+const (
+	// DuplicatesFail rejects the whole request.
+	DuplicatesFail Duplicates = "fail"
+	// DuplicatesKeepFirst imports the first link, the others fail.
+	DuplicatesKeepFirst Duplicates = "first"
+	// DuplicatesKeepLast imports the last link, the others fail.
+	DuplicatesKeepLast Duplicates = "last"
+)
+
+// errDuplicate is the result error of links superseded by another one.
+const errDuplicate = "duplicate alias in request"
+
+type Link struct {
+	URL   string `json:"url" validate:"required,url"`
+	Alias string `json:"alias" validate:"required"`
+}
+
+type Request struct {
+	URLs []Link `json:"urls" validate:"required,dive"`
+}
+
+// Result is the outcome of importing a single link. URL is the stored
+// url, which is the final one if the link was resolved.
+type Result struct {
+	Alias string `json:"alias"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Results []Result `json:"results,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
+type URLSaver interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+}
+
+// Resolver follows redirects of a url to the final one, see api.Resolver.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Resolver
+type Resolver interface {
+	ResolveFinal(ctx context.Context, url string) (string, error)
+}
+
+// Auditor records mutating operations for the audit trail.
+type Auditor interface {
+	Record(entry storage.AuditEntry)
+}
+
+// AliasValidator checks aliases against the deployment policy.
+type AliasValidator interface {
+	Validate(alias string) error
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(storage.AuditEntry) {}
+
+type options struct {
+	auditor        Auditor
+	aliasValidator AliasValidator
+	resolver       Resolver
+	skipUnresolved bool
+	duplicates     Duplicates
+	quota          quota.Limits
+	schemes        []string
+}
+
+// Option configures the import handler.
+type Option func(*options)
+
+// WithAuditor makes the handler record every imported link.
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithAliasValidator replaces the default alias validator, see aliaspolicy.Default.
+func WithAliasValidator(v AliasValidator) Option {
+	return func(o *options) {
+		o.aliasValidator = v
+	}
+}
+
+// WithResolver lets "?resolve=true" store the final url of links which
+// redirect. Links failing to resolve are stored as is, unless skipUnresolved
+// is set.
+func WithResolver(resolver Resolver, skipUnresolved bool) Option {
+	return func(o *options) {
+		o.resolver = resolver
+		o.skipUnresolved = skipUnresolved
+	}
+}
+
+// WithDuplicates sets how links sharing an alias are handled,
+// DuplicatesKeepFirst by default.
+func WithDuplicates(d Duplicates) Option {
+	return func(o *options) {
+		o.duplicates = d
+	}
+}
+
+// WithAllowedSchemes restricts the schemes of imported urls, e.g. to
+// "https". By default any scheme of a valid url is allowed.
+func WithAllowedSchemes(schemes []string) Option {
+	return func(o *options) {
+		o.schemes = schemes
+	}
+}
+
+// WithQuota limits the number of urls each user may own, links beyond
+// it fail.
+func WithQuota(limits quota.Limits) Option {
+	return func(o *options) {
+		o.quota = limits
+	}
+}
+
+// New saves a list of links with their aliases. Each link succeeds or
+// fails on its own, the results are in the order of the request.
+func New(log *slog.Logger, urlSaver URLSaver, opts ...Option) http.HandlerFunc {
+	o := options{
+		auditor:        nopAuditor{},
+		aliasValidator: aliaspolicy.Default(3, 50),
+		duplicates:     DuplicatesKeepFirst,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.importer.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		urlSaver := storage.WithContext(r.Context(), urlSaver)
+
+		resp.NoStore(w)
+
+		var req Request
+
+		err := request.DecodeJSON(r, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if errors.Is(err, request.ErrTooLarge) {
+			log.Error("request body is too large", sl.Err(err))
+
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodePayloadTooLarge, "request body is too large"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		if len(req.URLs) == 0 {
+			log.Info("urls list is empty")
+
+			render.JSON(w, r, resp.Error("urls list is empty"))
+
+			return
+		}
+		if len(req.URLs) > MaxURLs {
+			log.Info("too many urls", slog.Int("count", len(req.URLs)))
+
+			render.JSON(w, r, resp.Error(fmt.Sprintf("too many urls, max is %d", MaxURLs)))
+
+			return
+		}
+
+		superseded, duplicate := duplicates(req.URLs, o.duplicates == DuplicatesKeepLast)
+		if duplicate != "" && o.duplicates == DuplicatesFail {
+			log.Info("duplicate alias in request", slog.String("alias", duplicate))
+
+			render.JSON(w, r, resp.Error(fmt.Sprintf("duplicate alias %q", duplicate)))
+
+			return
+		}
+
+		resolve := o.resolver != nil && resolveRequested(r)
+
+		var saveOpts []storage.SaveOption
+		if user, _, ok := r.BasicAuth(); ok {
+			saveOpts = append(saveOpts, storage.WithOwner(user))
+
+			if n := o.quota.For(user); n > 0 {
+				saveOpts = append(saveOpts, storage.WithQuota(n))
+			}
+		}
+
+		results := make([]Result, 0, len(req.URLs))
+		imported := 0
+
+		for i, link := range req.URLs {
+			if superseded[i] {
+				results = append(results, Result{Alias: link.Alias, Error: errDuplicate})
+
+				continue
+			}
+
+			res := o.importLink(r, log, urlSaver, link, resolve, saveOpts)
+			if res.Error == "" {
+				imported++
+			}
+
+			results = append(results, res)
+		}
+
+		log.Info("urls imported",
+			slog.Int("imported", imported),
+			slog.Int("failed", len(results)-imported),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// importLink saves link and returns its result.
+func (o options) importLink(
+	r *http.Request,
+	log *slog.Logger,
+	urlSaver URLSaver,
+	link Link,
+	resolve bool,
+	saveOpts []storage.SaveOption,
+) Result {
+	res := Result{Alias: link.Alias}
+
+	if !namespace.IsUnqualified(link.Alias) {
+		res.Error = fmt.Sprintf("alias must not contain %q", namespace.Separator)
+
+		return res
+	}
+
+	if err := o.aliasValidator.Validate(link.Alias); err != nil {
+		res.Error = err.Error()
+
+		return res
+	}
+
+	alias := namespace.Qualify(r.Context(), link.Alias)
+	res.Alias = alias
+
+	urlToSave := link.URL
+
+	if resolve {
+		final, err := o.resolver.ResolveFinal(r.Context(), urlToSave)
+		switch {
+		case err == nil:
+			urlToSave = final
+		case o.skipUnresolved:
+			log.Info("failed to resolve url, skipping it", slog.String("url", urlToSave), sl.Err(err))
+
+			res.Error = "url is not resolvable"
+
+			return res
+		default:
+			log.Info("failed to resolve url, storing it as is", slog.String("url", urlToSave), sl.Err(err))
+		}
+	}
+
+	urlToSave, err := urlnorm.Normalize(urlToSave)
+	if err != nil {
+		res.Error = "invalid url"
+
+		return res
+	}
+
+	if err := urlnorm.CheckScheme(urlToSave, o.schemes); err != nil {
+		res.Error = err.Error()
+
+		return res
+	}
+
+	entry := storage.AuditEntry{
+		Actor:  audit.Actor(r),
+		Action: audit.ActionSave,
+		Alias:  alias,
+	}
+
+	_, err = urlSaver.SaveURL(urlToSave, alias, saveOpts...)
+	switch {
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		res.Error = "quota exceeded"
+	case errors.Is(err, storage.ErrAliasReserved):
+		res.Error = "alias is reserved"
+	case errors.Is(err, storage.ErrURLExists):
+		res.Error = "url already exists"
+	case err != nil:
+		log.Error("failed to add url", slog.String("alias", alias), sl.Err(err))
+
+		res.Error = "failed to add url"
+	default:
+		res.URL = urlToSave
+	}
+
+	entry.Result = audit.ResultSuccess
+	if res.Error != "" {
+		entry.Result = res.Error
+	}
+	o.auditor.Record(entry)
+
+	return res
+}
+
+// duplicates returns the indexes of links superseded by another link with
+// the same alias, keeping the first or the last one, and the first alias
+// found twice.
+func duplicates(links []Link, keepLast bool) (map[int]bool, string) {
+	superseded := make(map[int]bool)
+	seen := make(map[string]int, len(links))
+	first := ""
+
+	for i, link := range links {
+		j, ok := seen[link.Alias]
+		if !ok {
+			seen[link.Alias] = i
+
+			continue
+		}
+
+		if first == "" {
+			first = link.Alias
+		}
+
+		if keepLast {
+			superseded[j] = true
+			seen[link.Alias] = i
+		} else {
+			superseded[i] = true
+		}
+	}
+
+	return superseded, first
+}
+
+// resolveRequested reports whether the "resolve" query parameter is true.
+func resolveRequested(r *http.Request) bool {
+	resolve, _ := strconv.ParseBool(r.URL.Query().Get("resolve"))
+
+	return resolve
+}
@@ -0,0 +1,228 @@
+package importer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/importer"
+	"url-shortener/internal/http-server/handlers/url/importer/mocks"
+	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/storage"
+)
+
+func doImport(t *testing.T, handler http.HandlerFunc, target string, links ...importer.Link) importer.Response {
+	t.Helper()
+
+	body, err := json.Marshal(importer.Request{URLs: links})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp importer.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestImportHandler(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://a.com", "first").Return(int64(1), nil).Once()
+	urlSaverMock.On("SaveURL", "https://b.com", "taken").Return(int64(0), storage.ErrURLExists).Once()
+	urlSaverMock.On("SaveURL", "https://d.com", "held").Return(int64(0), storage.ErrAliasReserved).Once()
+
+	handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+	resp := doImport(t, handler, "/import",
+		importer.Link{URL: "https://a.com", Alias: "first"},
+		importer.Link{URL: "https://b.com", Alias: "taken"},
+		importer.Link{URL: "https://c.com", Alias: "a"},
+		importer.Link{URL: "https://d.com", Alias: "held"},
+	)
+
+	require.Len(t, resp.Results, 4)
+	assert.Equal(t, importer.Result{Alias: "first", URL: "https://a.com"}, resp.Results[0])
+	assert.Equal(t, "url already exists", resp.Results[1].Error)
+	assert.NotEmpty(t, resp.Results[2].Error, "too short alias")
+	assert.Equal(t, "alias is reserved", resp.Results[3].Error)
+}
+
+func TestImportHandler_AllowedSchemes(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://a.com", "secure").Return(int64(1), nil).Once()
+
+	handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		importer.WithAllowedSchemes([]string{"https"}))
+
+	resp := doImport(t, handler, "/import",
+		importer.Link{URL: "https://a.com", Alias: "secure"},
+		importer.Link{URL: "http://b.com", Alias: "insecure"},
+	)
+
+	require.Len(t, resp.Results, 2)
+	assert.Empty(t, resp.Results[0].Error)
+	assert.Equal(t, "url scheme must be one of: https", resp.Results[1].Error)
+}
+
+func TestImportHandler_Quota(t *testing.T) {
+	withQuota := mock.MatchedBy(func(opt storage.SaveOption) bool {
+		return storage.NewSaveOptions(opt).Quota == 1
+	})
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://a.com", "first", mock.Anything, withQuota).Return(int64(1), nil).Once()
+	urlSaverMock.On("SaveURL", "https://b.com", "second", mock.Anything, withQuota).
+		Return(int64(0), storage.ErrQuotaExceeded).
+		Once()
+
+	handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		importer.WithQuota(quota.Limits{Default: 1}))
+
+	body, err := json.Marshal(importer.Request{URLs: []importer.Link{
+		{URL: "https://a.com", Alias: "first"},
+		{URL: "https://b.com", Alias: "second"},
+	}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/import", bytes.NewReader(body))
+	req.SetBasicAuth("alice", "secret")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp importer.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	require.Len(t, resp.Results, 2)
+	assert.Empty(t, resp.Results[0].Error)
+	assert.Equal(t, "quota exceeded", resp.Results[1].Error)
+}
+
+func TestImportHandler_Duplicates(t *testing.T) {
+	links := []importer.Link{
+		{URL: "https://a.com", Alias: "dup"},
+		{URL: "https://b.com", Alias: "other"},
+		{URL: "https://c.com", Alias: "dup"},
+	}
+
+	t.Run("Fail", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+			importer.WithDuplicates(importer.DuplicatesFail))
+
+		resp := doImport(t, handler, "/import", links...)
+
+		assert.Equal(t, `duplicate alias "dup"`, resp.Error)
+		assert.Empty(t, resp.Results)
+	})
+
+	t.Run("Keep first", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", "https://a.com", "dup").Return(int64(1), nil).Once()
+		urlSaverMock.On("SaveURL", "https://b.com", "other").Return(int64(2), nil).Once()
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+			importer.WithDuplicates(importer.DuplicatesKeepFirst))
+
+		resp := doImport(t, handler, "/import", links...)
+
+		assert.Equal(t, []importer.Result{
+			{Alias: "dup", URL: "https://a.com"},
+			{Alias: "other", URL: "https://b.com"},
+			{Alias: "dup", Error: "duplicate alias in request"},
+		}, resp.Results)
+	})
+
+	t.Run("Keep last", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", "https://b.com", "other").Return(int64(2), nil).Once()
+		urlSaverMock.On("SaveURL", "https://c.com", "dup").Return(int64(3), nil).Once()
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+			importer.WithDuplicates(importer.DuplicatesKeepLast))
+
+		resp := doImport(t, handler, "/import", links...)
+
+		assert.Equal(t, []importer.Result{
+			{Alias: "dup", Error: "duplicate alias in request"},
+			{Alias: "other", URL: "https://b.com"},
+			{Alias: "dup", URL: "https://c.com"},
+		}, resp.Results)
+	})
+}
+
+func TestImportHandler_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/final":
+			w.WriteHeader(http.StatusOK)
+		case "/once":
+			http.Redirect(w, r, "/final", http.StatusMovedPermanently)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	resolver := api.NewResolver(time.Second, 5, true)
+
+	t.Run("Redirect is resolved", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", srv.URL+"/final", "once").Return(int64(1), nil).Once()
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock, importer.WithResolver(resolver, false))
+
+		resp := doImport(t, handler, "/import?resolve=true", importer.Link{URL: srv.URL + "/once", Alias: "once"})
+
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, importer.Result{Alias: "once", URL: srv.URL + "/final"}, resp.Results[0])
+	})
+
+	t.Run("Unresolved is stored as is", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", srv.URL+"/missing", "missing").Return(int64(1), nil).Once()
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock, importer.WithResolver(resolver, false))
+
+		resp := doImport(t, handler, "/import?resolve=true", importer.Link{URL: srv.URL + "/missing", Alias: "missing"})
+
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, importer.Result{Alias: "missing", URL: srv.URL + "/missing"}, resp.Results[0])
+	})
+
+	t.Run("Unresolved is skipped", func(t *testing.T) {
+		urlSaverMock := mocks.NewURLSaver(t)
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock, importer.WithResolver(resolver, true))
+
+		resp := doImport(t, handler, "/import?resolve=true", importer.Link{URL: srv.URL + "/missing", Alias: "missing"})
+
+		require.Len(t, resp.Results, 1)
+		assert.Equal(t, "url is not resolvable", resp.Results[0].Error)
+	})
+
+	t.Run("Not requested", func(t *testing.T) {
+		resolverMock := mocks.NewResolver(t)
+
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", srv.URL+"/once", "once").Return(int64(1), nil).Once()
+
+		handler := importer.New(slogdiscard.NewDiscardLogger(), urlSaverMock, importer.WithResolver(resolverMock, false))
+
+		doImport(t, handler, "/import", importer.Link{URL: srv.URL + "/once", Alias: "once"})
+
+		resolverMock.AssertNotCalled(t, "ResolveFinal", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,144 @@
+package list
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Response struct {
+	resp.Response
+	URLs []storage.URL `json:"urls"`
+}
+
+// URLsLister is an interface for listing urls by creation time.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLsLister
+type URLsLister interface {
+	URLsCreated(since time.Time, until time.Time, tag string, limit int, offset int) ([]storage.URL, error)
+}
+
+// New lists urls created within the optional RFC3339 "since" and "until"
+// query parameters, optionally having the "tag" one, paginated with
+// "limit" and "offset".
+func New(log *slog.Logger, urlsLister URLsLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		query := r.URL.Query()
+
+		since, ok := parseTime(query.Get("since"))
+		if !ok {
+			log.Info("invalid since", slog.String("since", query.Get("since")))
+
+			render.JSON(w, r, resp.Error("invalid since, RFC3339 expected"))
+
+			return
+		}
+
+		until, ok := parseTime(query.Get("until"))
+		if !ok {
+			log.Info("invalid until", slog.String("until", query.Get("until")))
+
+			render.JSON(w, r, resp.Error("invalid until, RFC3339 expected"))
+
+			return
+		}
+
+		if !since.IsZero() && !until.IsZero() && since.After(until) {
+			log.Info("inverted time range")
+
+			render.JSON(w, r, resp.Error("since must not be after until"))
+
+			return
+		}
+
+		tag := query.Get("tag")
+		if tag != "" {
+			if err := tags.ValidateTag(tag); err != nil {
+				log.Info("invalid tag", slog.String("tag", tag))
+
+				render.JSON(w, r, resp.Error("invalid tag"))
+
+				return
+			}
+		}
+
+		limit, ok := parseInt(query.Get("limit"), defaultLimit)
+		if !ok || limit <= 0 {
+			log.Info("invalid limit", slog.String("limit", query.Get("limit")))
+
+			render.JSON(w, r, resp.Error("invalid limit"))
+
+			return
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+
+		offset, ok := parseInt(query.Get("offset"), 0)
+		if !ok || offset < 0 {
+			log.Info("invalid offset", slog.String("offset", query.Get("offset")))
+
+			render.JSON(w, r, resp.Error("invalid offset"))
+
+			return
+		}
+
+		urls, err := urlsLister.URLsCreated(since, until, tag, limit, offset)
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+		})
+	}
+}
+
+// parseTime returns zero time for an empty value.
+func parseTime(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, true
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+
+	return t, err == nil
+}
+
+func parseInt(v string, def int) (int, bool) {
+	if v == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(v)
+
+	return n, err == nil
+}
@@ -0,0 +1,124 @@
+package list_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/list"
+	"url-shortener/internal/http-server/handlers/url/list/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestListHandler(t *testing.T) {
+	since := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2023, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	found := []storage.URL{{ID: 1, Alias: "may", URL: "https://may.com", CreatedAt: &created}}
+
+	cases := []struct {
+		name       string
+		query      string
+		mockSince  time.Time
+		mockUntil  time.Time
+		mockTag    string
+		mockLimit  int
+		mockOffset int
+		mockURLs   []storage.URL
+		respError  string
+	}{
+		{
+			name:      "Bounded range",
+			query:     "?since=2023-05-01T00:00:00Z&until=2023-06-01T00:00:00Z",
+			mockSince: since,
+			mockUntil: until,
+			mockLimit: 100,
+			mockURLs:  found,
+		},
+		{
+			name:      "Missing bounds",
+			query:     "",
+			mockLimit: 100,
+			mockURLs:  found,
+		},
+		{
+			name:       "Pagination",
+			query:      "?since=2023-05-01T00:00:00Z&limit=5000&offset=10",
+			mockSince:  since,
+			mockLimit:  1000,
+			mockOffset: 10,
+			mockURLs:   []storage.URL{},
+		},
+		{
+			name:      "Tag",
+			query:     "?tag=summer-sale",
+			mockTag:   "summer-sale",
+			mockLimit: 100,
+			mockURLs:  found,
+		},
+		{
+			name:      "Invalid tag",
+			query:     "?tag=Summer%20Sale",
+			respError: "invalid tag",
+		},
+		{
+			name:      "Inverted range",
+			query:     "?since=2023-06-01T00:00:00Z&until=2023-05-01T00:00:00Z",
+			respError: "since must not be after until",
+		},
+		{
+			name:      "Invalid since",
+			query:     "?since=2023-05-01",
+			respError: "invalid since, RFC3339 expected",
+		},
+		{
+			name:      "Invalid until",
+			query:     "?until=yesterday",
+			respError: "invalid until, RFC3339 expected",
+		},
+		{
+			name:      "Invalid limit",
+			query:     "?limit=-1",
+			respError: "invalid limit",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlsListerMock := mocks.NewURLsLister(t)
+
+			if tc.respError == "" {
+				urlsListerMock.On("URLsCreated", tc.mockSince, tc.mockUntil, tc.mockTag, tc.mockLimit, tc.mockOffset).
+					Return(tc.mockURLs, nil).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), urlsListerMock)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/urls"+tc.query, nil))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp list.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				assert.Equal(t, len(tc.mockURLs), len(resp.URLs))
+			}
+		})
+	}
+}
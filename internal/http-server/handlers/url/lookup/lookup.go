@@ -0,0 +1,83 @@
+package lookup
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/urlnorm"
+)
+
+type Response struct {
+	resp.Response
+	Aliases []string `json:"aliases"`
+}
+
+// AliasesGetter is an interface for finding aliases by the target url.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasesGetter
+type AliasesGetter interface {
+	GetAliasesByURL(url string) ([]string, error)
+}
+
+// New returns the aliases pointing to the url from the "url" query
+// parameter. Only aliases owned by the namespace of the request are listed.
+func New(log *slog.Logger, aliasesGetter AliasesGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.lookup.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		rawURL := r.URL.Query().Get("url")
+		if rawURL == "" {
+			log.Info("url is empty")
+
+			render.JSON(w, r, resp.Error("url is required"))
+
+			return
+		}
+
+		url, err := urlnorm.Normalize(rawURL)
+		if err != nil {
+			log.Info("invalid url", slog.String("url", rawURL), sl.Err(err))
+
+			render.JSON(w, r, resp.Error("invalid url"))
+
+			return
+		}
+
+		aliases, err := aliasesGetter.GetAliasesByURL(url)
+		if err != nil {
+			log.Error("failed to get aliases", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		owned := make([]string, 0, len(aliases))
+
+		for _, alias := range aliases {
+			if namespace.Owns(r.Context(), alias) {
+				owned = append(owned, alias)
+			}
+		}
+
+		log.Info("aliases found", slog.Int("count", len(owned)))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Aliases:  owned,
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package lookup_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/lookup"
+	"url-shortener/internal/http-server/handlers/url/lookup/mocks"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestLookupHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		user        string
+		url         string
+		mockURL     string
+		mockAliases []string
+		mockError   error
+		wantAliases []string
+		respError   string
+	}{
+		{
+			name:        "No aliases",
+			user:        "admin",
+			url:         "https://google.com",
+			mockURL:     "https://google.com",
+			wantAliases: []string{},
+		},
+		{
+			name:        "One alias",
+			user:        "admin",
+			url:         "https://google.com",
+			mockURL:     "https://google.com",
+			mockAliases: []string{"one"},
+			wantAliases: []string{"one"},
+		},
+		{
+			name:        "Multiple aliases",
+			user:        "admin",
+			url:         "https://google.com",
+			mockURL:     "https://google.com",
+			mockAliases: []string{"first", "second"},
+			wantAliases: []string{"first", "second"},
+		},
+		{
+			name:        "Normalized url",
+			user:        "admin",
+			url:         " HTTPS://Google.com:443/path ",
+			mockURL:     "https://google.com/path",
+			mockAliases: []string{"one"},
+			wantAliases: []string{"one"},
+		},
+		{
+			name:        "Other namespaces are hidden",
+			user:        "acme",
+			url:         "https://google.com",
+			mockURL:     "https://google.com",
+			mockAliases: []string{"global", "acme/mine", "globex/theirs"},
+			wantAliases: []string{"acme/mine"},
+		},
+		{
+			name:        "Namespaced aliases are hidden from global users",
+			user:        "admin",
+			url:         "https://google.com",
+			mockURL:     "https://google.com",
+			mockAliases: []string{"global", "acme/mine"},
+			wantAliases: []string{"global"},
+		},
+		{
+			name:      "Empty url",
+			user:      "admin",
+			respError: "url is required",
+		},
+		{
+			name:      "Storage error",
+			user:      "admin",
+			url:       "https://google.com",
+			mockURL:   "https://google.com",
+			mockError: errors.New("unexpected error"),
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			aliasesGetterMock := mocks.NewAliasesGetter(t)
+
+			if tc.mockURL != "" {
+				aliasesGetterMock.On("GetAliasesByURL", tc.mockURL).
+					Return(tc.mockAliases, tc.mockError).
+					Once()
+			}
+
+			handler := namespace.New(map[string]string{"acme": "acme"})(
+				lookup.New(slogdiscard.NewDiscardLogger(), aliasesGetterMock),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/urls/by-target?url="+url.QueryEscape(tc.url), nil)
+			req.SetBasicAuth(tc.user, "secret")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp lookup.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			if tc.respError == "" {
+				assert.Equal(t, tc.wantAliases, resp.Aliases)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package reserve
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/aliaspolicy"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// DefaultTTL is how long an alias is held by default.
+const DefaultTTL = 10 * time.Minute
+
+type Response struct {
+	resp.Response
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AliasReserver is an interface for holding aliases before they are saved.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasReserver
+type AliasReserver interface {
+	ReserveAlias(alias string, owner string, ttl time.Duration) (time.Time, error)
+	ReleaseAlias(alias string, owner string) error
+}
+
+// AliasValidator checks aliases against the deployment policy.
+type AliasValidator interface {
+	Validate(alias string) error
+}
+
+type options struct {
+	ttl            time.Duration
+	aliasValidator AliasValidator
+}
+
+// Option configures the reserve handler.
+type Option func(*options)
+
+// WithTTL sets how long an alias is held, DefaultTTL by default.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithAliasValidator replaces the default alias validator, see aliaspolicy.Default.
+func WithAliasValidator(v AliasValidator) Option {
+	return func(o *options) {
+		o.aliasValidator = v
+	}
+}
+
+// New holds the alias for the user, so that nobody else can save it
+// while they fill out a form. Holding it again extends the reservation.
+func New(log *slog.Logger, reserver AliasReserver, opts ...Option) http.HandlerFunc {
+	o := options{
+		ttl:            DefaultTTL,
+		aliasValidator: aliaspolicy.Default(3, 50),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.reserve.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		reserver := storage.WithContext(r.Context(), reserver)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if err := o.aliasValidator.Validate(alias); err != nil {
+			log.Info("invalid alias", slog.String("alias", alias), sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+		user, _, _ := r.BasicAuth()
+
+		expiresAt, err := reserver.ReserveAlias(alias, user, o.ttl)
+		switch {
+		case errors.Is(err, storage.ErrAliasReserved):
+			log.Info("alias is reserved by another user", slog.String("alias", alias))
+
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeAliasExists, "alias is reserved"))
+
+			return
+		case errors.Is(err, storage.ErrURLExists):
+			log.Info("url already exists", slog.String("alias", alias))
+
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeAliasExists, "url already exists"))
+
+			return
+		case err != nil:
+			log.Error("failed to reserve alias", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "failed to reserve alias"))
+
+			return
+		}
+
+		log.Info("alias reserved", slog.String("alias", alias), slog.Time("expires_at", expiresAt))
+
+		expiresAt = expiresAt.UTC()
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Alias:     alias,
+			ExpiresAt: &expiresAt,
+		})
+	}
+}
+
+// NewRelease drops the reservation of the alias by the user
+// before it expires.
+func NewRelease(log *slog.Logger, reserver AliasReserver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.reserve.NewRelease"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		reserver := storage.WithContext(r.Context(), reserver)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid request"))
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+		user, _, _ := r.BasicAuth()
+
+		err := reserver.ReleaseAlias(alias, user)
+		if errors.Is(err, storage.ErrReservationNotFound) {
+			log.Info("reservation not found", slog.String("alias", alias))
+
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotFound, "not found"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to release alias", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "failed to release alias"))
+
+			return
+		}
+
+		log.Info("alias released", slog.String("alias", alias))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+		})
+	}
+}
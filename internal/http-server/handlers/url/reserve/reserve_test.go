@@ -0,0 +1,154 @@
+package reserve_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/reserve"
+	"url-shortener/internal/http-server/handlers/url/reserve/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestReserveHandler(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 1, 12, 5, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		alias     string
+		mockError error
+		status    int
+		respCode  string
+	}{
+		{
+			name:   "Success",
+			alias:  "promo",
+			status: http.StatusOK,
+		},
+		{
+			name:      "Reserved by another user",
+			alias:     "promo",
+			mockError: storage.NewError("op", storage.KindExists, storage.ErrAliasReserved),
+			status:    http.StatusConflict,
+			respCode:  resp.CodeAliasExists,
+		},
+		{
+			name:      "URL exists",
+			alias:     "promo",
+			mockError: storage.ErrURLExists,
+			status:    http.StatusConflict,
+			respCode:  resp.CodeAliasExists,
+		},
+		{
+			name:     "Invalid alias",
+			alias:    "a",
+			status:   http.StatusBadRequest,
+			respCode: resp.CodeInvalidRequest,
+		},
+		{
+			name:      "Storage error",
+			alias:     "promo",
+			mockError: errors.New("unexpected error"),
+			status:    http.StatusInternalServerError,
+			respCode:  resp.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			reserverMock := mocks.NewAliasReserver(t)
+			if tc.status != http.StatusBadRequest {
+				reserverMock.On("ReserveAlias", tc.alias, "alice", 5*time.Minute).
+					Return(expiresAt, tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Post("/url/{alias}/reserve", reserve.New(
+				slogdiscard.NewDiscardLogger(),
+				reserverMock,
+				reserve.WithTTL(5*time.Minute),
+			))
+
+			req := httptest.NewRequest(http.MethodPost, "/url/"+tc.alias+"/reserve", nil)
+			req.SetBasicAuth("alice", "secret")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var body reserve.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			assert.Equal(t, tc.respCode, body.Code)
+			if tc.status == http.StatusOK {
+				assert.Equal(t, tc.alias, body.Alias)
+				require.NotNil(t, body.ExpiresAt)
+				assert.True(t, expiresAt.Equal(*body.ExpiresAt))
+			}
+		})
+	}
+}
+
+func TestReleaseHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		mockError error
+		status    int
+		respCode  string
+	}{
+		{
+			name:   "Success",
+			status: http.StatusOK,
+		},
+		{
+			name:      "Not reserved",
+			mockError: storage.ErrReservationNotFound,
+			status:    http.StatusNotFound,
+			respCode:  resp.CodeNotFound,
+		},
+		{
+			name:      "Storage error",
+			mockError: errors.New("unexpected error"),
+			status:    http.StatusInternalServerError,
+			respCode:  resp.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			reserverMock := mocks.NewAliasReserver(t)
+			reserverMock.On("ReleaseAlias", "promo", "alice").Return(tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Delete("/url/{alias}/reserve", reserve.NewRelease(slogdiscard.NewDiscardLogger(), reserverMock))
+
+			req := httptest.NewRequest(http.MethodDelete, "/url/promo/reserve", nil)
+			req.SetBasicAuth("alice", "secret")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var body reserve.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			assert.Equal(t, tc.respCode, body.Code)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// MaxAliases is the maximum number of aliases resolved in one request.
+const MaxAliases = 100
+
+type Request struct {
+	Aliases []string `json:"aliases" validate:"required,dive,required"`
+}
+
+type Response struct {
+	resp.Response
+	URLs     map[string]string `json:"urls,omitempty"`
+	NotFound []string          `json:"not_found,omitempty"`
+}
+
+// URLsGetter is an interface for getting urls by several aliases at once.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLsGetter
+type URLsGetter interface {
+	GetURLs(aliases []string) (map[string]string, error)
+}
+
+// New resolves aliases of the namespace of the user, see namespace.New.
+// Aliases of other namespaces are reported as not found.
+func New(log *slog.Logger, urlsGetter URLsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.resolve.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			render.JSON(w, r, resp.Error("empty request"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		aliases := unique(req.Aliases)
+
+		if len(aliases) == 0 {
+			log.Info("aliases list is empty")
+
+			render.JSON(w, r, resp.Error("aliases list is empty"))
+
+			return
+		}
+		if len(aliases) > MaxAliases {
+			log.Info("too many aliases", slog.Int("count", len(aliases)))
+
+			render.JSON(w, r, resp.Error(fmt.Sprintf("too many aliases, max is %d", MaxAliases)))
+
+			return
+		}
+
+		// aliases are looked up in the namespace of the user only
+		qualified := make([]string, 0, len(aliases))
+		for _, alias := range aliases {
+			if namespace.IsUnqualified(alias) {
+				qualified = append(qualified, namespace.Qualify(r.Context(), alias))
+			}
+		}
+
+		found, err := urlsGetter.GetURLs(qualified)
+		if err != nil {
+			log.Error("failed to get urls", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		urls := make(map[string]string, len(found))
+
+		var notFound []string
+
+		for _, alias := range aliases {
+			url, ok := "", false
+			if namespace.IsUnqualified(alias) {
+				url, ok = found[namespace.Qualify(r.Context(), alias)]
+			}
+			if !ok {
+				notFound = append(notFound, alias)
+
+				continue
+			}
+
+			urls[alias] = url
+		}
+
+		log.Info("aliases resolved",
+			slog.Int("found", len(urls)),
+			slog.Int("not_found", len(notFound)),
+		)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+			NotFound: notFound,
+		})
+	}
+}
+
+// unique returns aliases without duplicates, keeping the original order.
+func unique(aliases []string) []string {
+	seen := make(map[string]struct{}, len(aliases))
+	res := make([]string, 0, len(aliases))
+
+	for _, alias := range aliases {
+		if _, ok := seen[alias]; ok {
+			continue
+		}
+
+		seen[alias] = struct{}{}
+		res = append(res, alias)
+	}
+
+	return res
+}
@@ -0,0 +1,178 @@
+package resolve_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/resolve"
+	"url-shortener/internal/http-server/handlers/url/resolve/mocks"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestResolveHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		aliases      []string
+		mockAliases  []string
+		mockURLs     map[string]string
+		wantURLs     map[string]string
+		wantNotFound []string
+		respError    string
+	}{
+		{
+			name:        "All found",
+			aliases:     []string{"a", "b"},
+			mockAliases: []string{"a", "b"},
+			mockURLs:    map[string]string{"a": "https://a.com", "b": "https://b.com"},
+			wantURLs:    map[string]string{"a": "https://a.com", "b": "https://b.com"},
+		},
+		{
+			name:         "Partial",
+			aliases:      []string{"a", "missing"},
+			mockAliases:  []string{"a", "missing"},
+			mockURLs:     map[string]string{"a": "https://a.com"},
+			wantURLs:     map[string]string{"a": "https://a.com"},
+			wantNotFound: []string{"missing"},
+		},
+		{
+			name:        "Duplicate aliases",
+			aliases:     []string{"a", "a", "b", "a"},
+			mockAliases: []string{"a", "b"},
+			mockURLs:    map[string]string{"a": "https://a.com", "b": "https://b.com"},
+			wantURLs:    map[string]string{"a": "https://a.com", "b": "https://b.com"},
+		},
+		{
+			name:      "Empty list",
+			aliases:   []string{},
+			respError: "aliases list is empty",
+		},
+		{
+			name:      "Too many aliases",
+			aliases:   manyAliases(resolve.MaxAliases + 1),
+			respError: fmt.Sprintf("too many aliases, max is %d", resolve.MaxAliases),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlsGetterMock := mocks.NewURLsGetter(t)
+
+			if tc.mockAliases != nil {
+				urlsGetterMock.On("GetURLs", tc.mockAliases).
+					Return(tc.mockURLs, nil).
+					Once()
+			}
+
+			handler := resolve.New(slogdiscard.NewDiscardLogger(), urlsGetterMock)
+
+			input, err := json.Marshal(resolve.Request{Aliases: tc.aliases})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/urls/resolve", bytes.NewReader(input))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp resolve.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.wantURLs, resp.URLs)
+			assert.Equal(t, tc.wantNotFound, resp.NotFound)
+		})
+	}
+}
+
+func TestResolveHandler_Namespace(t *testing.T) {
+	urlsGetterMock := mocks.NewURLsGetter(t)
+	urlsGetterMock.On("GetURLs", []string{"acme/a", "acme/b"}).
+		Return(map[string]string{"acme/a": "https://a.com"}, nil).
+		Once()
+
+	handler := namespace.New(map[string]string{"acme": "acme"})(
+		resolve.New(slogdiscard.NewDiscardLogger(), urlsGetterMock),
+	)
+
+	input, err := json.Marshal(resolve.Request{Aliases: []string{"a", "b", "other/c"}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/urls/resolve", bytes.NewReader(input))
+	req.SetBasicAuth("acme", "secret")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp resolve.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	assert.Equal(t, map[string]string{"a": "https://a.com"}, resp.URLs)
+	assert.Equal(t, []string{"b", "other/c"}, resp.NotFound)
+}
+
+func manyAliases(n int) []string {
+	aliases := make([]string, n)
+	for i := range aliases {
+		aliases[i] = "alias" + strings.Repeat("x", i)
+	}
+
+	return aliases
+}
+
+func FuzzResolve(f *testing.F) {
+	seeds := []string{
+		`{"aliases": ["a", "b"]}`,
+		`{"aliases": ["a", "a", "missing"]}`,
+		`{"aliases": []}`,
+		`{"aliases": [""]}`,
+		`{"aliases": ["\xff"]}`,
+		`{"aliases": "a"}`,
+		`{"aliases": null}`,
+		`[`,
+		``,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		urlsGetterMock := mocks.NewURLsGetter(t)
+		urlsGetterMock.On("GetURLs", mock.Anything).
+			Return(map[string]string{}, nil).
+			Maybe()
+
+		handler := resolve.New(slogdiscard.NewDiscardLogger(), urlsGetterMock)
+
+		req, err := http.NewRequest(http.MethodPost, "/urls/resolve", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp resolve.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		if resp.Error == "" {
+			require.LessOrEqual(t, len(resp.NotFound), resolve.MaxAliases)
+		}
+	})
+}
@@ -1,40 +1,452 @@
 package save
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/exp/slog"
 
+	"url-shortener/internal/http-server/middleware/forcehttps"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api/request"
 	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/base62"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/clock"
 	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/quota"
 	"url-shortener/internal/lib/random"
+	"url-shortener/internal/lib/redirectrule"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/lib/urlnorm"
 	"url-shortener/internal/storage"
 )
 
 type Request struct {
 	URL   string `json:"url" validate:"required,url"`
 	Alias string `json:"alias,omitempty"`
+	// Password, if set, is required to follow the alias.
+	Password string `json:"password,omitempty"`
+	// OneTime makes the alias expire after the first redirect.
+	OneTime bool `json:"one_time,omitempty"`
+	// ExpiresAt is the time the alias stops working.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTL is an alternative to ExpiresAt relative to now, e.g. "168h".
+	TTL string `json:"ttl,omitempty"`
+	// Strategy selects the alias strategy of this request by name,
+	// see WithStrategies. Empty value means the default one.
+	Strategy string `json:"strategy,omitempty"`
+	// ActiveFrom is the time the alias starts working, e.g. for
+	// embargoed links. It must be before the expiration time.
+	ActiveFrom *time.Time `json:"active_from,omitempty"`
+	// CacheTTL is how long clients may cache the redirect, in seconds.
+	CacheTTL *int `json:"cache_ttl,omitempty" validate:"omitempty,min=0,max=31536000"`
+	// Tags group urls, e.g. by campaign, see tags.ValidateTag.
+	Tags []string `json:"tags,omitempty"`
+	// RedirectRules send some clients elsewhere, e.g. phones to an app
+	// store, see WithRedirectRules.
+	RedirectRules []storage.RedirectRule `json:"redirect_rules,omitempty"`
+}
+
+// LogValue hides the password from logs.
+func (r Request) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("url", r.URL),
+		slog.String("alias", r.Alias),
+		slog.Bool("protected", r.Password != ""),
+		slog.Bool("one_time", r.OneTime),
+		slog.Any("expires_at", r.ExpiresAt),
+		slog.String("ttl", r.TTL),
+		slog.Any("active_from", r.ActiveFrom),
+		slog.String("strategy", r.Strategy),
+		slog.Any("tags", r.Tags),
+		slog.Int("redirect_rules", len(r.RedirectRules)),
+	)
 }
 
 type Response struct {
 	resp.Response
-	Alias string `json:"alias,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	ID       int64  `json:"id,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+
+	// idAsString makes the ID serialized as a JSON string,
+	// since JavaScript clients lose precision on large int64 values.
+	idAsString bool
+}
+
+func (r Response) MarshalJSON() ([]byte, error) {
+	type plain Response
+
+	if !r.idAsString || r.ID == 0 {
+		return json.Marshal(plain(r))
+	}
+
+	return json.Marshal(struct {
+		plain
+		ID string `json:"id"`
+	}{
+		plain: plain(r),
+		ID:    strconv.FormatInt(r.ID, 10),
+	})
 }
 
-// TODO: move to config if needed
-const aliasLength = 6
+// UnmarshalJSON accepts the ID both as a JSON number and as a string.
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+
+	aux := struct {
+		*plain
+		ID json.Number `json:"id,omitempty"`
+	}{
+		plain: (*plain)(r),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.ID == "" {
+		r.ID = 0
+
+		return nil
+	}
+
+	id, err := aux.ID.Int64()
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+
+	r.ID = id
+
+	return nil
+}
+
+// Defaults used if the corresponding options are not set.
+const (
+	defaultAliasLength    = 6
+	defaultCustomAliasMin = 3
+	defaultCustomAliasMax = 50
+)
+
+// maxGenerateAttempts is the number of generated aliases tried
+// before reporting that the alias exists.
+const maxGenerateAttempts = 3
+
+// maxFilterAttempts is the number of generated aliases tried
+// before giving up if all of them are blocked by the alias filter.
+const maxFilterAttempts = 10
+
+var errAliasBlocked = errors.New("all generated aliases were blocked")
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) (int64, error)
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+}
+
+// Auditor records mutating operations for the audit trail.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Auditor
+type Auditor interface {
+	Record(entry storage.AuditEntry)
+}
+
+// AliasValidator checks custom aliases against the deployment policy.
+// The error message is returned to the client.
+type AliasValidator interface {
+	Validate(alias string) error
+}
+
+// CollisionRecorder counts generated aliases which were already taken.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=CollisionRecorder
+type CollisionRecorder interface {
+	RecordCollision(at time.Time) error
+}
+
+// AliasGenerator generates aliases, e.g. wordlist.Generator.
+type AliasGenerator interface {
+	Generate() string
+}
+
+// AliasAutoscaler provides the length of generated aliases
+// and adjusts it based on collisions, see aliaslen.Autoscaler.
+type AliasAutoscaler interface {
+	Length() int
+	Observe(collision bool)
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+// AliasFilter blocks generated aliases, e.g. profanity.Filter.
+type AliasFilter interface {
+	Blocked(alias string) bool
+}
+
+// SequentialSaver saves urls under aliases encoded from a sequence,
+// see sqlite.Storage.SaveSequentialURL.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SequentialSaver
+type SequentialSaver interface {
+	SaveSequentialURL(
+		urlToSave string,
+		encode func(n int64) (alias string, ok bool),
+		opts ...storage.SaveOption,
+	) (alias string, id int64, err error)
+}
+
+// ReachabilityChecker checks that a url responds, see api.Checker.
+type ReachabilityChecker interface {
+	CheckReachable(ctx context.Context, url string) error
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(storage.AuditEntry) {}
+
+type options struct {
+	auditor        Auditor
+	idAsString     bool
+	aliasLength    int
+	aliasCharset   string
+	customAliasMin int
+	customAliasMax int
+	aliasValidator AliasValidator
+	collisions     CollisionRecorder
+	autoscaler     AliasAutoscaler
+	generator      AliasGenerator
+	strictStatus   bool
+	baseURL        string
+	trustedProxy   func(r *http.Request) bool
+	reachability   ReachabilityChecker
+	filter         AliasFilter
+	checksum       bool
+	quota          quota.Limits
+	clock          clock.Clock
+	sequential     SequentialSaver
+	strategies     map[string]Strategy
+	maxTags        int
+	maxRules       int
+	schemes        []string
+}
+
+// Option configures the save handler.
+type Option func(*options)
+
+// WithAuditor makes the handler record every save attempt.
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithIDAsString makes the handler serialize the url ID as a JSON string.
+func WithIDAsString(enabled bool) Option {
+	return func(o *options) {
+		o.idAsString = enabled
+	}
+}
+
+// WithAliasLength sets the length of generated aliases.
+func WithAliasLength(length int) Option {
+	return func(o *options) {
+		o.aliasLength = length
+	}
+}
+
+// WithAliasCharset sets the runes of random aliases, see random.ValidateCharset.
+// Empty charset keeps random.DefaultCharset.
+func WithAliasCharset(charset string) Option {
+	return func(o *options) {
+		if charset != "" {
+			o.aliasCharset = charset
+		}
+	}
+}
+
+// WithCustomAliasLength sets the allowed length range of aliases
+// provided by the user for the default alias validator.
+// It doesn't affect generated aliases.
+func WithCustomAliasLength(min int, max int) Option {
+	return func(o *options) {
+		o.customAliasMin = min
+		o.customAliasMax = max
+	}
+}
+
+// WithAliasValidator replaces the default validator of custom aliases,
+// see aliaspolicy.Default.
+func WithAliasValidator(v AliasValidator) Option {
+	return func(o *options) {
+		o.aliasValidator = v
+	}
+}
+
+// WithCollisionRecorder makes the handler count collisions of generated aliases.
+func WithCollisionRecorder(r CollisionRecorder) Option {
+	return func(o *options) {
+		o.collisions = r
+	}
+}
+
+// WithAliasAutoscaler makes the length of generated aliases managed
+// by a, overriding WithAliasLength.
+func WithAliasAutoscaler(a AliasAutoscaler) Option {
+	return func(o *options) {
+		o.autoscaler = a
+	}
+}
+
+// WithAliasGenerator replaces random aliases of WithAliasLength
+// or WithAliasAutoscaler with the ones of g.
+func WithAliasGenerator(g AliasGenerator) Option {
+	return func(o *options) {
+		o.generator = g
+	}
+}
+
+// Strategy generates aliases of a request which picked it by name.
+// A zero Strategy generates random aliases.
+type Strategy struct {
+	Generator  AliasGenerator
+	Sequential SequentialSaver
+}
+
+// WithStrategies lets clients pick one of strategies by name with
+// Request.Strategy. Without it requests with a strategy are rejected.
+func WithStrategies(strategies map[string]Strategy) Option {
+	return func(o *options) {
+		o.strategies = strategies
+	}
+}
+
+// WithAliasFilter makes the handler regenerate aliases blocked by f.
+// Custom aliases are up to WithAliasValidator.
+func WithAliasFilter(f AliasFilter) Option {
+	return func(o *options) {
+		o.filter = f
+	}
+}
+
+// WithSequentialAliases generates aliases from the sequence of saver,
+// encoded in base62, instead of random ones. The alias filter and
+// the checksum still apply.
+func WithSequentialAliases(saver SequentialSaver) Option {
+	return func(o *options) {
+		o.sequential = saver
+	}
+}
+
+// WithAliasChecksum appends a check character to new aliases, both
+// generated and custom, see checksum.Append.
+func WithAliasChecksum(enabled bool) Option {
+	return func(o *options) {
+		o.checksum = enabled
+	}
+}
+
+// WithClock sets the clock expiration and collision times are taken from.
+func WithClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithQuota limits the number of urls each authenticated user may own.
+// Deleted urls free up the quota.
+func WithQuota(limits quota.Limits) Option {
+	return func(o *options) {
+		o.quota = limits
+	}
+}
+
+// WithReachabilityChecker lets clients pass "?verify=true" to have
+// the url checked with c before it is saved. Without it the parameter
+// is ignored.
+func WithReachabilityChecker(c ReachabilityChecker) Option {
+	return func(o *options) {
+		o.reachability = c
+	}
+}
+
+// WithMaxTags caps the number of tags of a url, tags.DefaultMax by default.
+func WithMaxTags(n int) Option {
+	return func(o *options) {
+		o.maxTags = n
+	}
+}
+
+// WithRedirectRules lets urls have up to max redirect rules. By default
+// requests with redirect rules are rejected.
+func WithRedirectRules(max int) Option {
+	return func(o *options) {
+		o.maxRules = max
+	}
+}
+
+// WithAllowedSchemes restricts the schemes of saved urls, e.g. to
+// "https". By default any scheme of a valid url is allowed.
+func WithAllowedSchemes(schemes []string) Option {
+	return func(o *options) {
+		o.schemes = schemes
+	}
+}
+
+// WithStrictStatus makes the handler respond to a successful save with
+// 201 Created and the short URL in the Location header instead of 200.
+func WithStrictStatus(enabled bool) Option {
+	return func(o *options) {
+		o.strictStatus = enabled
+	}
+}
+
+// WithBaseURL sets the public URL short links are served from,
+// e.g. "https://sho.rt". By default it is taken from the request.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTrustedProxy makes short URLs taken from the request use the https
+// scheme if X-Forwarded-Proto is "https" and trustedProxy, e.g.
+// realip.Resolver.FromTrustedProxy, reports the request came from a
+// trusted proxy. By default only requests over TLS get https.
+func WithTrustedProxy(trustedProxy func(r *http.Request) bool) Option {
+	return func(o *options) {
+		o.trustedProxy = trustedProxy
+	}
+}
+
+func New(log *slog.Logger, urlSaver URLSaver, opts ...Option) http.HandlerFunc {
+	o := options{
+		auditor:        nopAuditor{},
+		aliasLength:    defaultAliasLength,
+		aliasCharset:   random.DefaultCharset,
+		customAliasMin: defaultCustomAliasMin,
+		customAliasMax: defaultCustomAliasMax,
+		clock:          clock.Real{},
+		maxTags:        tags.DefaultMax,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.aliasValidator == nil {
+		o.aliasValidator = aliaspolicy.Default(o.customAliasMin, o.customAliasMax)
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
@@ -43,28 +455,49 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		urlSaver := storage.WithContext(r.Context(), urlSaver)
+
+		resp.NoStore(w)
+
 		var req Request
 
-		err := render.DecodeJSON(r.Body, &req)
+		err := request.DecodeJSON(r, &req)
 		if errors.Is(err, io.EOF) {
 			// Такую ошибку встретим, если получили запрос с пустым телом.
 			// Обработаем её отдельно
 			log.Error("request body is empty")
 
-			render.JSON(w, r, resp.Error("empty request"))
+			render.JSON(w, r, resp.Error(request.ErrorMessage(err)))
+
+			return
+		}
+		if errors.Is(err, request.ErrTooLarge) {
+			log.Error("request body is too large", sl.Err(err))
+
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodePayloadTooLarge, "request body is too large"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to decode request body", sl.Err(err))
 
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			render.JSON(w, r, resp.Error(request.ErrorMessage(err)))
 
 			return
 		}
 
 		log.Info("request body decoded", slog.Any("request", req))
 
+		req.URL = strings.TrimSpace(req.URL)
+		if req.URL == "" {
+			log.Info("url is empty")
+
+			render.JSON(w, r, resp.Error("url is required"))
+
+			return
+		}
+
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 
@@ -75,22 +508,262 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			return
 		}
 
+		if !namespace.IsUnqualified(req.Alias) {
+			log.Info("alias contains namespace separator", slog.String("alias", req.Alias))
+
+			render.JSON(w, r, resp.Error(fmt.Sprintf("alias must not contain %q", namespace.Separator)))
+
+			return
+		}
+
+		if req.Alias != "" {
+			if err := o.aliasValidator.Validate(req.Alias); err != nil {
+				log.Info("invalid alias", slog.String("alias", req.Alias), sl.Err(err))
+
+				render.JSON(w, r, resp.Error(err.Error()))
+
+				return
+			}
+		}
+
+		if err := tags.Validate(req.Tags, o.maxTags); err != nil {
+			log.Info("invalid tags", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		if len(req.RedirectRules) > 0 && o.maxRules == 0 {
+			log.Info("redirect rules are disabled")
+
+			render.JSON(w, r, resp.Error("redirect rules are disabled"))
+
+			return
+		}
+		if err := redirectrule.Validate(req.RedirectRules, o.maxRules); err != nil {
+			log.Info("invalid redirect rules", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		o := o
+
+		if req.Strategy != "" {
+			strategy, ok := o.strategies[req.Strategy]
+			if !ok {
+				log.Info("strategy is not allowed", slog.String("strategy", req.Strategy))
+
+				render.JSON(w, r, resp.Error(fmt.Sprintf("strategy %q is not allowed", req.Strategy)))
+
+				return
+			}
+
+			o.generator = strategy.Generator
+			o.sequential = strategy.Sequential
+		}
+
+		generated := req.Alias == ""
+
 		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		if generated && o.sequential == nil {
+			alias, err = o.generateAlias()
+			if err != nil {
+				log.Error("failed to generate alias", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("failed to generate alias"))
+
+				return
+			}
+		} else {
+			alias = o.withChecksum(alias)
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+
+		urlToSave, err := urlnorm.Normalize(req.URL)
+		if err != nil {
+			log.Info("failed to normalize url", sl.Err(err))
+
+			render.JSON(w, r, resp.Error("invalid url"))
+
+			return
+		}
+
+		if err := urlnorm.CheckScheme(urlToSave, o.schemes); err != nil {
+			log.Info("url scheme is not allowed", slog.String("url", urlToSave))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		for i, rule := range req.RedirectRules {
+			if err := urlnorm.CheckScheme(rule.URL, o.schemes); err != nil {
+				log.Info("redirect rule url scheme is not allowed", slog.String("url", rule.URL))
+
+				render.JSON(w, r, resp.Error(fmt.Sprintf("redirect rule %d: %s", i, err)))
+
+				return
+			}
+		}
+
+		if o.reachability != nil && verifyRequested(r) {
+			if err := o.reachability.CheckReachable(r.Context(), urlToSave); err != nil {
+				log.Info("url is not reachable", slog.String("url", urlToSave), sl.Err(err))
+
+				render.JSON(w, r, resp.Error("url is not reachable"))
+
+				return
+			}
+		}
+
+		entry := storage.AuditEntry{
+			Actor:  audit.Actor(r),
+			Action: audit.ActionSave,
+			Alias:  alias,
+		}
+
+		var saveOpts []storage.SaveOption
+
+		if user, _, ok := r.BasicAuth(); ok {
+			saveOpts = append(saveOpts, storage.WithOwner(user))
+
+			if n := o.quota.For(user); n > 0 {
+				saveOpts = append(saveOpts, storage.WithQuota(n))
+			}
+		}
+
+		if req.Password != "" {
+			hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				log.Error("failed to hash password", sl.Err(err))
+
+				render.JSON(w, r, resp.Error("invalid password"))
+
+				return
+			}
+
+			saveOpts = append(saveOpts, storage.WithPasswordHash(string(hash)))
+		}
+
+		if req.CacheTTL != nil {
+			saveOpts = append(saveOpts, storage.WithCacheTTL(time.Duration(*req.CacheTTL)*time.Second))
+		}
+
+		if req.OneTime {
+			saveOpts = append(saveOpts, storage.WithOneTime())
+		}
+
+		expiresAt, err := expiration(req, o.clock.Now())
+		if err != nil {
+			log.Info("invalid expiration", sl.Err(err))
+
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+		if expiresAt != nil {
+			saveOpts = append(saveOpts, storage.WithExpiresAt(*expiresAt))
+		}
+
+		if req.ActiveFrom != nil {
+			if expiresAt != nil && !req.ActiveFrom.Before(*expiresAt) {
+				log.Info("active_from is not before expiration")
+
+				render.JSON(w, r, resp.Error("active_from must be before the expiration time"))
+
+				return
+			}
+
+			saveOpts = append(saveOpts, storage.WithActiveFrom(*req.ActiveFrom))
+		}
+
+		if len(req.Tags) > 0 {
+			saveOpts = append(saveOpts, storage.WithTags(req.Tags...))
+		}
+
+		if len(req.RedirectRules) > 0 {
+			saveOpts = append(saveOpts, storage.WithRedirectRules(req.RedirectRules...))
+		}
+
+		var id int64
+
+		switch {
+		case generated && o.sequential != nil:
+			alias, id, err = o.sequential.SaveSequentialURL(urlToSave, o.sequentialAlias(r.Context()), saveOpts...)
+			entry.Alias = alias
+		case generated:
+			id, err = urlSaver.SaveURL(urlToSave, alias, saveOpts...)
+
+			// a taken generated alias is retried with a new one
+			for attempt := 1; ; attempt++ {
+				o.observeGenerated(log, err)
+
+				if !errors.Is(err, storage.ErrURLExists) || attempt == maxGenerateAttempts {
+					break
+				}
+
+				next, genErr := o.generateAlias()
+				if genErr != nil {
+					err = genErr
+
+					break
+				}
+
+				alias = namespace.Qualify(r.Context(), next)
+				id, err = urlSaver.SaveURL(urlToSave, alias, saveOpts...)
+			}
+
+			entry.Alias = alias
+		default:
+			id, err = urlSaver.SaveURL(urlToSave, alias, saveOpts...)
 		}
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			user, _, _ := r.BasicAuth()
+			limit := o.quota.For(user)
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
+			log.Info("quota exceeded", slog.String("user", user), slog.Int64("quota", limit))
+
+			entry.Result = "quota exceeded"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(
+				resp.CodeQuotaExceeded,
+				fmt.Sprintf("quota of %d links exceeded, delete some to create new ones", limit),
+			))
+
+			return
+		}
+		if errors.Is(err, storage.ErrAliasReserved) {
+			log.Info("alias is reserved by another user", slog.String("alias", alias))
+
+			entry.Result = "alias is reserved"
+			o.auditor.Record(entry)
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeAliasExists, "alias is reserved"))
+
+			return
+		}
 		if errors.Is(err, storage.ErrURLExists) {
 			log.Info("url already exists", slog.String("url", req.URL))
 
-			render.JSON(w, r, resp.Error("url already exists"))
+			entry.Result = "url already exists"
+			o.auditor.Record(entry)
+
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeAliasExists, "url already exists"))
 
 			return
 		}
 		if err != nil {
 			log.Error("failed to add url", sl.Err(err))
 
+			entry.Result = "failed to add url"
+			o.auditor.Record(entry)
+
 			render.JSON(w, r, resp.Error("failed to add url"))
 
 			return
@@ -98,13 +771,146 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 
 		log.Info("url added", slog.Int64("id", id))
 
-		responseOK(w, r, alias)
+		entry.Result = audit.ResultSuccess
+		o.auditor.Record(entry)
+
+		shortURL := o.shortURL(r, alias)
+
+		if o.strictStatus {
+			w.Header().Set("Location", shortURL)
+			render.Status(r, http.StatusCreated)
+		}
+
+		render.JSON(w, r, NewResponse(alias, id, shortURL, o.idAsString))
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
-	render.JSON(w, r, Response{
-		Response: resp.OK(),
-		Alias:    alias,
-	})
+// verifyRequested reports whether the "verify" query parameter is true.
+func verifyRequested(r *http.Request) bool {
+	verify, _ := strconv.ParseBool(r.URL.Query().Get("verify"))
+
+	return verify
+}
+
+// generateAlias returns a new alias of the configured strategy
+// which isn't blocked by the alias filter.
+func (o options) generateAlias() (string, error) {
+	for attempt := 0; attempt < maxFilterAttempts; attempt++ {
+		alias := o.newAlias()
+		if o.filter == nil || !o.filter.Blocked(alias) {
+			return o.withChecksum(alias), nil
+		}
+	}
+
+	return "", errAliasBlocked
+}
+
+// sequentialAlias returns the encoder of sequential aliases, which skips
+// the values the alias filter blocks.
+func (o options) sequentialAlias(ctx context.Context) func(n int64) (string, bool) {
+	return func(n int64) (string, bool) {
+		alias := base62.Encode(n)
+		if o.filter != nil && o.filter.Blocked(alias) {
+			return "", false
+		}
+
+		return namespace.Qualify(ctx, o.withChecksum(alias)), true
+	}
+}
+
+// withChecksum appends the check character to alias if it is enabled.
+func (o options) withChecksum(alias string) string {
+	if !o.checksum {
+		return alias
+	}
+
+	return checksum.Append(alias)
+}
+
+// newAlias returns a new alias of the configured strategy.
+func (o options) newAlias() string {
+	if o.generator != nil {
+		return o.generator.Generate()
+	}
+
+	length := o.aliasLength
+	if o.autoscaler != nil {
+		length = o.autoscaler.Length()
+	}
+
+	return random.NewRandomStringFromCharset(length, o.aliasCharset)
+}
+
+// observeGenerated records whether saving a generated alias collided.
+func (o options) observeGenerated(log *slog.Logger, err error) {
+	collision := errors.Is(err, storage.ErrURLExists)
+	if err != nil && !collision {
+		return
+	}
+
+	if o.autoscaler != nil {
+		o.autoscaler.Observe(collision)
+	}
+
+	if collision && o.collisions != nil {
+		if err := o.collisions.RecordCollision(o.clock.Now()); err != nil {
+			log.Error("failed to record collision", sl.Err(err))
+		}
+	}
+}
+
+// shortURL returns the URL alias redirects from.
+func (o options) shortURL(r *http.Request, alias string) string {
+	baseURL := o.baseURL
+	if baseURL == "" {
+		scheme := "http"
+		if forcehttps.IsSecure(r, o.trustedProxy) {
+			scheme = "https"
+		}
+
+		baseURL = scheme + "://" + r.Host
+	}
+
+	return baseURL + "/" + alias
+}
+
+// expiration returns the absolute expiration time of the request
+// or nil if the alias doesn't expire.
+func expiration(req Request, now time.Time) (*time.Time, error) {
+	if req.TTL != "" && req.ExpiresAt != nil {
+		return nil, errors.New("only one of ttl and expires_at may be set")
+	}
+
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil {
+			return nil, errors.New("invalid ttl, duration like \"72h\" expected")
+		}
+		if ttl <= 0 {
+			return nil, errors.New("ttl must be positive")
+		}
+
+		expiresAt := now.Add(ttl)
+
+		return &expiresAt, nil
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	return req.ExpiresAt, nil
+}
+
+// NewResponse returns the response to a successful save. It depends on
+// nothing but its arguments, so the response is the same whatever the
+// handler logs.
+func NewResponse(alias string, id int64, shortURL string, idAsString bool) Response {
+	return Response{
+		Response:   resp.OK(),
+		Alias:      alias,
+		ID:         id,
+		ShortURL:   shortURL,
+		idAsString: idAsString,
+	}
 }
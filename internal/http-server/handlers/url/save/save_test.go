@@ -2,19 +2,35 @@ package save_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/exp/slog"
 
 	"url-shortener/internal/http-server/handlers/url/save"
 	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/api"
+	"url-shortener/internal/lib/api/request"
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/clock"
 	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/profanity"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/storage"
 )
 
 func TestSaveHandler(t *testing.T) {
@@ -39,7 +55,7 @@ func TestSaveHandler(t *testing.T) {
 			name:      "Empty URL",
 			url:       "",
 			alias:     "some_alias",
-			respError: "field URL is a required field",
+			respError: "url is required",
 		},
 		{
 			name:      "Invalid URL",
@@ -54,6 +70,13 @@ func TestSaveHandler(t *testing.T) {
 			respError: "failed to add url",
 			mockError: errors.New("unexpected error"),
 		},
+		{
+			name:      "Alias reserved",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			respError: "alias is reserved",
+			mockError: storage.NewError("op", storage.KindExists, storage.ErrAliasReserved),
+		},
 	}
 
 	for _, tc := range cases {
@@ -81,6 +104,7 @@ func TestSaveHandler(t *testing.T) {
 			handler.ServeHTTP(rr, req)
 
 			require.Equal(t, rr.Code, http.StatusOK)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
 
 			body := rr.Body.String()
 
@@ -94,3 +118,1517 @@ func TestSaveHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveHandler_Audit(t *testing.T) {
+	cases := []struct {
+		name       string
+		mockError  error
+		wantResult string
+	}{
+		{
+			name:       "Success",
+			wantResult: "success",
+		},
+		{
+			name:       "URL exists",
+			mockError:  storage.ErrURLExists,
+			wantResult: "url already exists",
+		},
+		{
+			name:       "SaveURL Error",
+			mockError:  errors.New("unexpected error"),
+			wantResult: "failed to add url",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			ownedByAdmin := mock.MatchedBy(func(opt storage.SaveOption) bool {
+				return storage.NewSaveOptions(opt).Owner == "admin"
+			})
+			urlSaverMock.On("SaveURL", "https://google.com", "test_alias", ownedByAdmin).
+				Return(int64(1), tc.mockError).
+				Once()
+
+			auditorMock := mocks.NewAuditor(t)
+			auditorMock.On("Record", mock.MatchedBy(func(e storage.AuditEntry) bool {
+				return e.Actor == "admin" &&
+					e.Action == "save" &&
+					e.Alias == "test_alias" &&
+					e.Result == tc.wantResult
+			})).Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithAuditor(auditorMock))
+
+			input := `{"url": "https://google.com", "alias": "test_alias"}`
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req.SetBasicAuth("admin", "secret")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+		})
+	}
+}
+
+func TestSaveHandler_IDSerialization(t *testing.T) {
+	const id = int64(9007199254740993) // 2^53 + 1, not representable as float64
+
+	cases := []struct {
+		name       string
+		idAsString bool
+		wantRawID  string
+	}{
+		{
+			name:      "Number",
+			wantRawID: "9007199254740993",
+		},
+		{
+			name:       "String",
+			idAsString: true,
+			wantRawID:  `"9007199254740993"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", "https://google.com", "test_alias").
+				Return(id, nil).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithIDAsString(tc.idAsString))
+
+			input := `{"url": "https://google.com", "alias": "test_alias"}`
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var raw map[string]json.RawMessage
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+			require.Equal(t, tc.wantRawID, string(raw["id"]))
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, id, resp.ID)
+			require.Equal(t, "test_alias", resp.Alias)
+		})
+	}
+}
+
+func TestSaveHandler_CustomAliasLength(t *testing.T) {
+	const (
+		minLength = 3
+		maxLength = 10
+	)
+
+	respError := fmt.Sprintf("alias length must be between %d and %d characters", minLength, maxLength)
+
+	cases := []struct {
+		name      string
+		alias     string
+		respError string
+	}{
+		{name: "Below min", alias: "ab", respError: respError},
+		{name: "At min", alias: "abc"},
+		{name: "At max", alias: "abcdefghij"},
+		{name: "Above max", alias: "abcdefghijk", respError: respError},
+		{name: "Multi-byte runes at max", alias: "ёёёёёёёёёё"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", "https://google.com", tc.alias).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				// generated aliases are not bound by the custom range
+				save.WithAliasLength(20),
+				save.WithCustomAliasLength(minLength, maxLength),
+			)
+
+			input := fmt.Sprintf(`{"url": "https://google.com", "alias": "%s"}`, tc.alias)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_GeneratedAliasLength(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", mock.MatchedBy(func(alias string) bool {
+		return len(alias) == 20
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasLength(20),
+		save.WithCustomAliasLength(3, 10),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+	require.Len(t, resp.Alias, 20)
+}
+
+func TestSaveHandler_AliasCharset(t *testing.T) {
+	const charset = "-._~xz"
+
+	inCharset := func(alias string) bool {
+		if utf8.RuneCountInString(alias) != 8 {
+			return false
+		}
+		for _, c := range alias {
+			if !strings.ContainsRune(charset, c) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", mock.MatchedBy(inCharset)).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasLength(8),
+		save.WithAliasCharset(charset),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+	require.True(t, inCharset(resp.Alias))
+}
+
+func TestSaveHandler_InvalidAliasCharacters(t *testing.T) {
+	cases := []struct {
+		name  string
+		alias string
+	}{
+		{name: "Space", alias: "my alias"},
+		{name: "Control character", alias: "ali\tas"},
+		{name: "Replacement character", alias: "ali�as"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t))
+
+			body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: tc.alias})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "alias contains invalid characters", resp.Error)
+		})
+	}
+}
+
+func FuzzSaveHandler(f *testing.F) {
+	seeds := []string{
+		`{"url": "https://google.com", "alias": "test_alias"}`,
+		`{"url": "https://google.com"}`,
+		`{"url": "invalid url", "alias": "some_alias"}`,
+		`{"url": "https://google.com", "alias": "team/alias"}`,
+		`{"url": "https://google.com", "alias": "\xff\xfe"}`,
+		`{"url": "", "alias": ""}`,
+		`{"url": 1}`,
+		`{`,
+		``,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Return(int64(1), nil).
+			Maybe()
+
+		handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		if resp.Error == "" {
+			require.NotEmpty(t, resp.Alias)
+			require.True(t, utf8.ValidString(resp.Alias))
+		}
+	})
+}
+
+func TestSaveHandler_URLWhitespace(t *testing.T) {
+	cases := []struct {
+		name      string
+		url       string
+		wantURL   string
+		respError string
+	}{
+		{name: "Empty", url: "", respError: "url is required"},
+		{name: "Whitespace only", url: " \t\n ", respError: "url is required"},
+		{name: "Surrounding spaces", url: "  https://google.com\n", wantURL: "https://google.com"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			if tc.wantURL != "" {
+				urlSaverMock.On("SaveURL", tc.wantURL, "some_alias").
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+			body, err := json.Marshal(save.Request{URL: tc.url, Alias: "some_alias"})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_AliasValidator(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "ok").
+		Return(int64(1), nil).
+		Once()
+
+	// a custom validator replaces the default one, so "ok" is accepted
+	// despite being shorter than the default minimum
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		save.WithAliasValidator(aliaspolicy.Blocklist("darn")),
+	)
+
+	for alias, wantErr := range map[string]string{
+		"ok":      "",
+		"darn-it": "alias contains a blocked word",
+	} {
+		body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: alias})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, wantErr, resp.Error)
+	}
+}
+
+func TestSaveHandler_Quota(t *testing.T) {
+	withQuota := func(n int64) interface{} {
+		return mock.MatchedBy(func(opt storage.SaveOption) bool {
+			return storage.NewSaveOptions(opt).Quota == n
+		})
+	}
+
+	// alice is at her quota of 2, then deletes a link
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "blocked", mock.Anything, withQuota(2)).
+		Return(int64(0), storage.ErrQuotaExceeded).
+		Once()
+	urlSaverMock.On("SaveURL", "https://google.com", "again", mock.Anything, withQuota(2)).
+		Return(int64(3), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		save.WithQuota(quota.Limits{Default: 5, Users: map[string]int64{"alice": 2, "admin": 0}}),
+	)
+
+	send := func(user, alias string) (int, save.Response) {
+		body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: alias})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, "secret")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		return rr.Code, resp
+	}
+
+	code, resp := send("alice", "blocked")
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, "QUOTA_EXCEEDED", resp.Code)
+	require.Equal(t, "quota of 2 links exceeded, delete some to create new ones", resp.Error)
+
+	code, resp = send("alice", "again")
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Error)
+}
+
+func TestSaveHandler_QuotaUnlimited(t *testing.T) {
+	// a zero quota isn't passed to the storage
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "unlimited", mock.Anything).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		save.WithQuota(quota.Limits{Default: 5, Users: map[string]int64{"admin": 0}}),
+	)
+
+	body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: "unlimited"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "secret")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSaveHandler_Gzip(t *testing.T) {
+	gzipped := func(data string) *bytes.Buffer {
+		var buf bytes.Buffer
+
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+
+		return &buf
+	}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "zipped").
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+	cases := []struct {
+		name     string
+		body     *bytes.Buffer
+		wantCode int
+	}{
+		{
+			name:     "Decoded",
+			body:     gzipped(`{"url": "https://google.com", "alias": "zipped"}`),
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Bomb",
+			body:     gzipped(`{"url": "https://google.com", "alias": "` + strings.Repeat("a", request.MaxDecompressedSize) + `"}`),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest(http.MethodPost, "/save", tc.body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Encoding", "gzip")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, tc.wantCode, rr.Code, tc.name)
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	r := save.NewResponse("abc", 42, "https://sho.rt/abc", false)
+
+	require.Equal(t, "OK", r.Status)
+	require.Empty(t, r.Error)
+	require.Equal(t, "abc", r.Alias)
+	require.Equal(t, int64(42), r.ID)
+	require.Equal(t, "https://sho.rt/abc", r.ShortURL)
+
+	b, err := json.Marshal(r)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":"OK","alias":"abc","id":42,"short_url":"https://sho.rt/abc"}`, string(b))
+
+	b, err = json.Marshal(save.NewResponse("abc", 42, "https://sho.rt/abc", true))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status":"OK","alias":"abc","id":"42","short_url":"https://sho.rt/abc"}`, string(b))
+}
+
+func TestSaveHandler_ResponseIndependentOfLogger(t *testing.T) {
+	loggers := map[string]*slog.Logger{
+		"discard": slogdiscard.NewDiscardLogger(),
+		"json":    slog.New(slog.NewJSONHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	want, err := json.Marshal(save.NewResponse("abc", 1, "https://sho.rt/abc", false))
+	require.NoError(t, err)
+
+	for name, log := range loggers {
+		urlSaverMock := mocks.NewURLSaver(t)
+		urlSaverMock.On("SaveURL", "https://google.com", "abc").Return(int64(1), nil).Once()
+
+		handler := save.New(log, urlSaverMock, save.WithBaseURL("https://sho.rt"))
+
+		req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(`{"url":"https://google.com","alias":"abc"}`))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.JSONEq(t, string(want), rr.Body.String(), name)
+	}
+}
+
+func TestSaveHandler_DecodeErrors(t *testing.T) {
+	handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t))
+
+	cases := []struct {
+		name      string
+		body      string
+		wantError string
+	}{
+		{
+			name:      "Empty body",
+			body:      "",
+			wantError: "request body is empty",
+		},
+		{
+			name:      "Truncated",
+			body:      `{"url": "https://google.com"`,
+			wantError: "malformed JSON: unexpected end of input",
+		},
+		{
+			name:      "Syntax",
+			body:      `{"url" "https://google.com"}`,
+			wantError: "malformed JSON at offset 8",
+		},
+		{
+			name:      "Type mismatch",
+			body:      `{"url": 42}`,
+			wantError: `field "url" must be string, got number`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/save", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.wantError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_SequentialAliases(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+
+	var encode func(n int64) (string, bool)
+
+	sequentialSaverMock := mocks.NewSequentialSaver(t)
+	sequentialSaverMock.On("SaveSequentialURL", "https://google.com", mock.Anything).
+		Run(func(args mock.Arguments) {
+			encode = args.Get(1).(func(int64) (string, bool))
+		}).
+		Return("11", int64(63), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		save.WithSequentialAliases(sequentialSaverMock),
+		save.WithAliasFilter(filterFunc(func(alias string) bool { return alias == "z" })),
+	)
+
+	body, err := json.Marshal(save.Request{URL: "https://google.com"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+	require.Equal(t, "11", resp.Alias)
+
+	alias, ok := encode(63)
+	require.True(t, ok)
+	require.Equal(t, "11", alias)
+
+	// 61 is "z", which the filter blocks
+	_, ok = encode(61)
+	require.False(t, ok)
+}
+
+func TestSaveHandler_Password(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "shared", mock.MatchedBy(func(opt storage.SaveOption) bool {
+		hash := storage.NewSaveOptions(opt).PasswordHash
+
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")) == nil
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+	body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: "shared", Password: "secret"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+}
+
+func TestSaveHandler_OneTime(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "once", mock.MatchedBy(func(opt storage.SaveOption) bool {
+		return storage.NewSaveOptions(opt).OneTime
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+	body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: "once", OneTime: true})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+}
+
+func TestSaveHandler_Collisions(t *testing.T) {
+	cases := []struct {
+		name           string
+		alias          string
+		wantAttempts   int
+		wantCollisions int
+	}{
+		{
+			name:           "Generated alias retried",
+			wantAttempts:   3,
+			wantCollisions: 3,
+		},
+		{
+			name:         "Custom alias",
+			alias:        "taken",
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", "https://google.com", mock.AnythingOfType("string")).
+				Return(int64(0), storage.ErrURLExists).
+				Times(tc.wantAttempts)
+
+			collisionsMock := mocks.NewCollisionRecorder(t)
+			if tc.wantCollisions > 0 {
+				collisionsMock.On("RecordCollision", mock.AnythingOfType("time.Time")).
+					Return(nil).
+					Times(tc.wantCollisions)
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				save.WithCollisionRecorder(collisionsMock),
+			)
+
+			body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: tc.alias})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, "url already exists", resp.Error)
+		})
+	}
+}
+
+type fakeAutoscaler struct {
+	length     int
+	collisions []bool
+}
+
+func (a *fakeAutoscaler) Length() int { return a.length }
+
+func (a *fakeAutoscaler) Observe(collision bool) { a.collisions = append(a.collisions, collision) }
+
+func TestSaveHandler_AliasAutoscaler(t *testing.T) {
+	autoscaler := &fakeAutoscaler{length: 9}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", mock.MatchedBy(func(alias string) bool {
+		return len(alias) == 9
+	})).
+		Return(int64(0), storage.ErrURLExists).
+		Times(3)
+	urlSaverMock.On("SaveURL", "https://google.com", "custom").
+		Return(int64(0), storage.ErrURLExists).
+		Once()
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasLength(6),
+		save.WithAliasAutoscaler(autoscaler),
+	)
+
+	for _, alias := range []string{"", "custom"} {
+		body, err := json.Marshal(save.Request{URL: "https://google.com", Alias: alias})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// custom aliases don't affect the collision rate
+	require.Equal(t, []bool{true, true, true}, autoscaler.collisions)
+}
+
+func TestSaveHandler_Expiration(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(now)
+
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	cases := []struct {
+		name      string
+		ttl       string
+		expiresAt *time.Time
+		wantExp   func(exp *time.Time) bool
+		respError string
+	}{
+		{
+			name: "Valid ttl",
+			ttl:  "168h",
+			wantExp: func(exp *time.Time) bool {
+				return exp != nil && exp.Equal(now.Add(168*time.Hour))
+			},
+		},
+		{
+			name:      "Valid expires_at",
+			expiresAt: &future,
+			wantExp: func(exp *time.Time) bool {
+				return exp != nil && exp.Equal(future)
+			},
+		},
+		{
+			name:      "Both fields",
+			ttl:       "1h",
+			expiresAt: &future,
+			respError: "only one of ttl and expires_at may be set",
+		},
+		{
+			name:      "Negative ttl",
+			ttl:       "-1h",
+			respError: "ttl must be positive",
+		},
+		{
+			name:      "Zero ttl",
+			ttl:       "0s",
+			respError: "ttl must be positive",
+		},
+		{
+			name:      "Invalid ttl",
+			ttl:       "a week",
+			respError: `invalid ttl, duration like "72h" expected`,
+		},
+		{
+			name:      "Past expires_at",
+			expiresAt: &past,
+			respError: "expires_at must be in the future",
+		},
+		{
+			name:      "Now expires_at",
+			expiresAt: &now,
+			respError: "expires_at must be in the future",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", "https://google.com", "expiring", mock.MatchedBy(func(opt storage.SaveOption) bool {
+					return tc.wantExp(storage.NewSaveOptions(opt).ExpiresAt)
+				})).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithClock(clk))
+
+			body, err := json.Marshal(save.Request{
+				URL:       "https://google.com",
+				Alias:     "expiring",
+				TTL:       tc.ttl,
+				ExpiresAt: tc.expiresAt,
+			})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+func TestSaveHandler_ActiveFrom(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	activeFrom := now.Add(time.Hour)
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "embargoed", mock.Anything, mock.MatchedBy(func(opt storage.SaveOption) bool {
+		o := storage.NewSaveOptions(opt)
+
+		return o.ActiveFrom != nil && o.ActiveFrom.Equal(activeFrom)
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithClock(clock.NewFake(now)))
+
+	for ttl, wantErr := range map[string]string{
+		"2h": "",
+		"1h": "active_from must be before the expiration time",
+	} {
+		body, err := json.Marshal(save.Request{
+			URL:        "https://google.com",
+			Alias:      "embargoed",
+			TTL:        ttl,
+			ActiveFrom: &activeFrom,
+		})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, wantErr, resp.Error, ttl)
+	}
+}
+
+func TestSaveHandler_Tags(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "tagged", mock.MatchedBy(func(opt storage.SaveOption) bool {
+		return reflect.DeepEqual([]string{"summer-sale", "newsletter"}, storage.NewSaveOptions(opt).Tags)
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithMaxTags(2))
+
+	for tags, wantErr := range map[string]string{
+		`["summer-sale","newsletter"]`:       "",
+		`["Summer Sale"]`:                    `invalid tag "Summer Sale"`,
+		`["summer-sale","newsletter","vip"]`: "too many tags, max is 2",
+	} {
+		body := fmt.Sprintf(`{"url":"https://google.com","alias":"tagged","tags":%s}`, tags)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, wantErr, resp.Error, tags)
+	}
+}
+
+func TestSaveHandler_AllowedSchemes(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "secure", mock.Anything).
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithAllowedSchemes([]string{"https"}))
+
+	for url, wantErr := range map[string]string{
+		"HTTPS://google.com": "",
+		"http://google.com":  "url scheme must be one of: https",
+		"google.com":         "field URL is not a valid URL",
+	} {
+		alias := "secure"
+		if wantErr != "" {
+			alias = "rejected"
+		}
+
+		body := fmt.Sprintf(`{"url":%q,"alias":%q}`, url, alias)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, wantErr, resp.Error, url)
+	}
+}
+
+func TestSaveHandler_AllowedSchemesRedirectRules(t *testing.T) {
+	handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t),
+		save.WithAllowedSchemes([]string{"https"}),
+		save.WithRedirectRules(1),
+	)
+
+	body := `{"url":"https://google.com","alias":"app","redirect_rules":[{"device":"mobile","url":"http://apps.apple.com"}]}`
+
+	req, err := http.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "redirect rule 0: url scheme must be one of: https", resp.Error)
+}
+
+func TestSaveHandler_RedirectRules(t *testing.T) {
+	rule := storage.RedirectRule{Device: "mobile", URL: "https://apps.apple.com/app/id1"}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "app", mock.MatchedBy(func(opt storage.SaveOption) bool {
+		return reflect.DeepEqual([]storage.RedirectRule{rule}, storage.NewSaveOptions(opt).RedirectRules)
+	})).
+		Return(int64(1), nil).
+		Once()
+
+	enabled := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.WithRedirectRules(1))
+	disabled := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+
+	cases := []struct {
+		name    string
+		handler http.Handler
+		rules   string
+		wantErr string
+	}{
+		{
+			name:    "Saved",
+			handler: enabled,
+			rules:   `[{"device":"mobile","url":"https://apps.apple.com/app/id1"}]`,
+		},
+		{
+			name:    "Invalid",
+			handler: enabled,
+			rules:   `[{"device":"tv","url":"https://apps.apple.com/app/id1"}]`,
+			wantErr: `invalid redirect rule 0: unknown device "tv", expected one of mobile, desktop, ios, android`,
+		},
+		{
+			name:    "Disabled",
+			handler: disabled,
+			rules:   `[{"device":"mobile","url":"https://apps.apple.com/app/id1"}]`,
+			wantErr: "redirect rules are disabled",
+		},
+	}
+
+	for _, tc := range cases {
+		body := fmt.Sprintf(`{"url":"https://google.com","alias":"app","redirect_rules":%s}`, tc.rules)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", strings.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		tc.handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, tc.wantErr, resp.Error, tc.name)
+	}
+}
+
+func TestSaveHandler_StrictStatus(t *testing.T) {
+	cases := []struct {
+		name         string
+		opts         []save.Option
+		tls          bool
+		proto        string
+		wantCode     int
+		wantLocation string
+		wantShortURL string
+	}{
+		{
+			name:         "Default",
+			wantCode:     http.StatusOK,
+			wantShortURL: "http://sho.rt/test_alias",
+		},
+		{
+			name:         "Strict",
+			opts:         []save.Option{save.WithStrictStatus(true)},
+			wantCode:     http.StatusCreated,
+			wantLocation: "http://sho.rt/test_alias",
+			wantShortURL: "http://sho.rt/test_alias",
+		},
+		{
+			name:         "Strict over TLS",
+			opts:         []save.Option{save.WithStrictStatus(true)},
+			tls:          true,
+			wantCode:     http.StatusCreated,
+			wantLocation: "https://sho.rt/test_alias",
+			wantShortURL: "https://sho.rt/test_alias",
+		},
+		{
+			name:         "Forwarded proto from untrusted client",
+			proto:        "https",
+			wantCode:     http.StatusOK,
+			wantShortURL: "http://sho.rt/test_alias",
+		},
+		{
+			name: "Forwarded proto from trusted proxy",
+			opts: []save.Option{save.WithTrustedProxy(func(*http.Request) bool {
+				return true
+			})},
+			proto:        "https",
+			wantCode:     http.StatusOK,
+			wantShortURL: "https://sho.rt/test_alias",
+		},
+		{
+			name:         "Strict with base URL",
+			opts:         []save.Option{save.WithStrictStatus(true), save.WithBaseURL("https://public.io/")},
+			wantCode:     http.StatusCreated,
+			wantLocation: "https://public.io/test_alias",
+			wantShortURL: "https://public.io/test_alias",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", "https://google.com", "test_alias").
+				Return(int64(1), nil).
+				Once()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, tc.opts...)
+
+			input := `{"url": "https://google.com", "alias": "test_alias"}`
+
+			req := httptest.NewRequest(http.MethodPost, "http://sho.rt/url", bytes.NewReader([]byte(input)))
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			require.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Empty(t, resp.Error)
+			require.Equal(t, "test_alias", resp.Alias)
+			require.Equal(t, tc.wantShortURL, resp.ShortURL)
+		})
+	}
+}
+
+type sequenceGenerator struct {
+	aliases []string
+	next    int
+}
+
+func (g *sequenceGenerator) Generate() string {
+	alias := g.aliases[g.next]
+	g.next++
+
+	return alias
+}
+
+func TestSaveHandler_Strategies(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	// the default strategy
+	urlSaverMock.On("SaveURL", "https://google.com", "default-alias").
+		Return(int64(1), nil).
+		Once()
+	urlSaverMock.On("SaveURL", "https://google.com", "brave-otter-42").
+		Return(int64(2), nil).
+		Once()
+	urlSaverMock.On("SaveURL", "https://google.com", mock.MatchedBy(func(alias string) bool {
+		return utf8.RuneCountInString(alias) == 6
+	})).
+		Return(int64(3), nil).
+		Once()
+
+	sequentialSaverMock := mocks.NewSequentialSaver(t)
+	sequentialSaverMock.On("SaveSequentialURL", "https://google.com", mock.Anything).
+		Return("1", int64(4), nil).
+		Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock,
+		save.WithAliasGenerator(&sequenceGenerator{aliases: []string{"default-alias"}}),
+		save.WithStrategies(map[string]save.Strategy{
+			"random":     {},
+			"wordlist":   {Generator: &sequenceGenerator{aliases: []string{"brave-otter-42"}}},
+			"sequential": {Sequential: sequentialSaverMock},
+		}),
+	)
+
+	cases := []struct {
+		strategy  string
+		wantAlias string
+		respError string
+	}{
+		{strategy: "", wantAlias: "default-alias"},
+		{strategy: "wordlist", wantAlias: "brave-otter-42"},
+		{strategy: "sequential", wantAlias: "1"},
+		{strategy: "random"},
+		{strategy: "uuid", respError: `strategy "uuid" is not allowed`},
+	}
+
+	for _, tc := range cases {
+		body, err := json.Marshal(save.Request{URL: "https://google.com", Strategy: tc.strategy})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, tc.respError, resp.Error, tc.strategy)
+
+		if tc.wantAlias != "" {
+			require.Equal(t, tc.wantAlias, resp.Alias, tc.strategy)
+		}
+	}
+}
+
+func TestSaveHandler_StrategiesDisabled(t *testing.T) {
+	handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t))
+
+	body, err := json.Marshal(save.Request{URL: "https://google.com", Strategy: "random"})
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, `strategy "random" is not allowed`, resp.Error)
+}
+
+func TestSaveHandler_AliasGenerator(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "brave-otter-42").
+		Return(int64(0), storage.ErrURLExists).
+		Once()
+	urlSaverMock.On("SaveURL", "https://google.com", "calm-heron-7").
+		Return(int64(2), nil).
+		Once()
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasGenerator(&sequenceGenerator{aliases: []string{"brave-otter-42", "calm-heron-7"}}),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+	require.Equal(t, "calm-heron-7", resp.Alias)
+}
+
+func TestSaveHandler_AliasChecksum(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		wantAlias string
+	}{
+		{
+			name:      "Generated",
+			body:      `{"url": "https://google.com"}`,
+			wantAlias: checksum.Append("brave-otter-42"),
+		},
+		{
+			name:      "Custom",
+			body:      `{"url": "https://google.com", "alias": "summer"}`,
+			wantAlias: checksum.Append("summer"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlSaverMock := mocks.NewURLSaver(t)
+			urlSaverMock.On("SaveURL", "https://google.com", tc.wantAlias).
+				Return(int64(1), nil).
+				Once()
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				save.WithAliasGenerator(&sequenceGenerator{aliases: []string{"brave-otter-42"}}),
+				save.WithAliasChecksum(true),
+			)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Empty(t, resp.Error)
+			require.Equal(t, tc.wantAlias, resp.Alias)
+			require.True(t, checksum.Valid(resp.Alias))
+		})
+	}
+}
+
+func TestSaveHandler_Verify(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	cases := []struct {
+		name      string
+		path      string
+		query     string
+		respError string
+	}{
+		{
+			name:  "Reachable",
+			path:  "/ok",
+			query: "?verify=true",
+		},
+		{
+			name:      "Not found",
+			path:      "/missing",
+			query:     "?verify=true",
+			respError: "url is not reachable",
+		},
+		{
+			name:      "Timeout",
+			path:      "/slow",
+			query:     "?verify=true",
+			respError: "url is not reachable",
+		},
+		{
+			name:  "Verify disabled",
+			path:  "/missing",
+			query: "?verify=false",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlSaverMock := mocks.NewURLSaver(t)
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", target.URL+tc.path, "abc").
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(
+				slogdiscard.NewDiscardLogger(),
+				urlSaverMock,
+				save.WithReachabilityChecker(api.NewChecker(200*time.Millisecond, true)),
+			)
+
+			body := fmt.Sprintf(`{"url": %q, "alias": "abc"}`, target.URL+tc.path)
+
+			req, err := http.NewRequest(http.MethodPost, "/url"+tc.query, bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var resp save.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+		})
+	}
+}
+
+// filterFunc blocks aliases for which it returns true.
+type filterFunc func(alias string) bool
+
+func (f filterFunc) Blocked(alias string) bool {
+	return f(alias)
+}
+
+func TestSaveHandler_AliasFilter(t *testing.T) {
+	filter, err := profanity.New(profanity.ModeSubstring)
+	require.NoError(t, err)
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "calm-heron-7").
+		Return(int64(1), nil).
+		Once()
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasGenerator(&sequenceGenerator{aliases: []string{"shit-otter-42", "brave-sh1t-1", "calm-heron-7"}}),
+		save.WithAliasFilter(filter),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Empty(t, resp.Error)
+	require.Equal(t, "calm-heron-7", resp.Alias)
+}
+
+func TestSaveHandler_AliasFilterRandom(t *testing.T) {
+	const requests = 100
+
+	hasDigit := func(alias string) bool {
+		return strings.ContainsAny(alias, "0123456789")
+	}
+
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", mock.MatchedBy(func(alias string) bool {
+		return !hasDigit(alias)
+	})).
+		Return(int64(1), nil).
+		Times(requests)
+
+	// short aliases make blocked ones frequent
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithAliasLength(2),
+		save.WithAliasFilter(filterFunc(hasDigit)),
+	)
+
+	for i := 0; i < requests; i++ {
+		req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp save.Response
+
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Empty(t, resp.Error)
+		require.False(t, hasDigit(resp.Alias))
+	}
+}
+
+func TestSaveHandler_AliasFilterExhausted(t *testing.T) {
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		mocks.NewURLSaver(t),
+		save.WithAliasFilter(filterFunc(func(string) bool { return true })),
+	)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp save.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "failed to generate alias", resp.Error)
+}
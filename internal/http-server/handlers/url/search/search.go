@@ -0,0 +1,91 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type Response struct {
+	resp.Response
+	URLs []storage.URL `json:"urls"`
+}
+
+// AliasSearcher is an interface for searching urls by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AliasSearcher
+type AliasSearcher interface {
+	SearchAliases(query string, limit int) ([]storage.URL, error)
+}
+
+// New searches urls whose alias contains the "q" query parameter,
+// prefix matches first, up to "limit" of them.
+func New(log *slog.Logger, searcher AliasSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.search.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		resp.NoStore(w)
+
+		q := strings.TrimSpace(r.URL.Query().Get("q"))
+		if q == "" {
+			log.Info("query is empty")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "q is required"))
+
+			return
+		}
+
+		limit := defaultLimit
+
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				log.Info("invalid limit", slog.String("limit", v))
+
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid limit"))
+
+				return
+			}
+
+			limit = n
+			if limit > maxLimit {
+				limit = maxLimit
+			}
+		}
+
+		urls, err := searcher.SearchAliases(q, limit)
+		if err != nil {
+			log.Error("failed to search aliases", sl.Err(err))
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			URLs:     urls,
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package search_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/search"
+	"url-shortener/internal/http-server/handlers/url/search/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestSearchHandler(t *testing.T) {
+	found := []storage.URL{{ID: 1, Alias: "sale", URL: "https://sale.com"}}
+
+	cases := []struct {
+		name      string
+		query     string
+		wantQ     string
+		wantLimit int
+		mockError error
+		wantCode  int
+		respError string
+	}{
+		{
+			name:      "Default limit",
+			query:     "?q=sale",
+			wantQ:     "sale",
+			wantLimit: 20,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Limit capped",
+			query:     "?q=sale&limit=500",
+			wantQ:     "sale",
+			wantLimit: 100,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Wildcards passed as is",
+			query:     "?q=50%25_off",
+			wantQ:     "50%_off",
+			wantLimit: 20,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "Empty query",
+			query:     "?q=+",
+			wantCode:  http.StatusBadRequest,
+			respError: "q is required",
+		},
+		{
+			name:      "Invalid limit",
+			query:     "?q=sale&limit=x",
+			wantCode:  http.StatusBadRequest,
+			respError: "invalid limit",
+		},
+		{
+			name:      "Storage error",
+			query:     "?q=sale",
+			wantQ:     "sale",
+			wantLimit: 20,
+			mockError: errors.New("unexpected error"),
+			wantCode:  http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			searcherMock := mocks.NewAliasSearcher(t)
+			if tc.wantQ != "" {
+				searcherMock.On("SearchAliases", tc.wantQ, tc.wantLimit).
+					Return(found, tc.mockError).
+					Once()
+			}
+
+			handler := search.New(slogdiscard.NewDiscardLogger(), searcherMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/urls/search"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+
+			var resp search.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				require.Equal(t, found, resp.URLs)
+			}
+		})
+	}
+}
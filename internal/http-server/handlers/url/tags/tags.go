@@ -0,0 +1,217 @@
+package tags
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/api/request"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/tags"
+	"url-shortener/internal/storage"
+)
+
+// Request adds and removes tags. A tag both added and removed is removed.
+type Request struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Alias string   `json:"alias"`
+	Tags  []string `json:"tags"`
+}
+
+// TagsUpdater is an interface for changing tags of a url. Only the owner
+// of the url may change them.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TagsUpdater
+type TagsUpdater interface {
+	UpdateTags(alias string, owner string, add []string, remove []string, maxTags int) ([]string, error)
+}
+
+// Auditor records mutating operations for the audit trail.
+type Auditor interface {
+	Record(entry storage.AuditEntry)
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(storage.AuditEntry) {}
+
+type options struct {
+	auditor Auditor
+	maxTags int
+}
+
+// Option configures the tags handler.
+type Option func(*options)
+
+// WithAuditor makes the handler record every tags change.
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithMaxTags caps the number of tags of a url, tags.DefaultMax by default.
+func WithMaxTags(n int) Option {
+	return func(o *options) {
+		o.maxTags = n
+	}
+}
+
+// New adds and removes tags of the alias and responds with its tags.
+func New(log *slog.Logger, tagsUpdater TagsUpdater, opts ...Option) http.HandlerFunc {
+	o := options{
+		auditor: nopAuditor{},
+		maxTags: tags.DefaultMax,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.tags.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tagsUpdater := storage.WithContext(r.Context(), tagsUpdater)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+
+			invalid(w, r, "invalid request")
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+
+		var req Request
+
+		err := request.DecodeJSON(r, &req)
+		if errors.Is(err, io.EOF) {
+			log.Info("request body is empty")
+
+			invalid(w, r, "empty request")
+
+			return
+		}
+		if errors.Is(err, request.ErrTooLarge) {
+			log.Info("request body is too large", sl.Err(err))
+
+			render.Status(r, http.StatusRequestEntityTooLarge)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodePayloadTooLarge, "request body is too large"))
+
+			return
+		}
+		if err != nil {
+			log.Info("failed to decode request body", sl.Err(err))
+
+			invalid(w, r, "failed to decode request")
+
+			return
+		}
+
+		if len(req.Add) == 0 && len(req.Remove) == 0 {
+			log.Info("no tags to change")
+
+			invalid(w, r, "add or remove is required")
+
+			return
+		}
+
+		for _, tag := range append(req.Add, req.Remove...) {
+			if err := tags.ValidateTag(tag); err != nil {
+				log.Info("invalid tag", slog.String("tag", tag))
+
+				invalid(w, r, err.Error())
+
+				return
+			}
+		}
+
+		entry := storage.AuditEntry{
+			Actor:  audit.Actor(r),
+			Action: audit.ActionUpdate,
+			Alias:  alias,
+		}
+
+		owner, _, _ := r.BasicAuth()
+
+		updated, err := tagsUpdater.UpdateTags(alias, owner, req.Add, req.Remove, o.maxTags)
+		switch {
+		case errors.Is(err, storage.ErrURLNotFound):
+			log.Info("url not found", slog.String("alias", alias))
+
+			entry.Result = "not found"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusNotFound)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeNotFound, "not found"))
+
+			return
+		case errors.Is(err, storage.ErrNotOwner):
+			log.Info("alias is owned by another user", slog.String("alias", alias))
+
+			entry.Result = "alias is owned by another user"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeForbidden, "alias is owned by another user"))
+
+			return
+		case errors.Is(err, storage.ErrTooManyTags):
+			log.Info("too many tags", slog.String("alias", alias))
+
+			entry.Result = "too many tags"
+			o.auditor.Record(entry)
+
+			invalid(w, r, "too many tags")
+
+			return
+		case err != nil:
+			log.Error("failed to update tags", sl.Err(err))
+
+			entry.Result = "failed to update tags"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "failed to update tags"))
+
+			return
+		}
+
+		log.Info("tags updated", slog.String("alias", alias), slog.Any("tags", updated))
+
+		entry.Result = audit.ResultSuccess
+		o.auditor.Record(entry)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			Tags:     updated,
+		})
+	}
+}
+
+func invalid(w http.ResponseWriter, r *http.Request, msg string) {
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, msg))
+}
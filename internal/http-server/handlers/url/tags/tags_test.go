@@ -0,0 +1,121 @@
+package tags_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/tags"
+	"url-shortener/internal/http-server/handlers/url/tags/mocks"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+func TestTagsHandler(t *testing.T) {
+	cases := []struct {
+		name      string
+		body      string
+		mockAdd   []string
+		mockDrop  []string
+		mockTags  []string
+		mockError error
+		status    int
+		respCode  string
+		respTags  []string
+	}{
+		{
+			name:     "Success",
+			body:     `{"add":["summer-sale"],"remove":["draft"]}`,
+			mockAdd:  []string{"summer-sale"},
+			mockDrop: []string{"draft"},
+			mockTags: []string{"newsletter", "summer-sale"},
+			status:   http.StatusOK,
+			respTags: []string{"newsletter", "summer-sale"},
+		},
+		{
+			name:     "Nothing to change",
+			body:     `{}`,
+			status:   http.StatusBadRequest,
+			respCode: resp.CodeInvalidRequest,
+		},
+		{
+			name:     "Invalid tag",
+			body:     `{"add":["Summer Sale"]}`,
+			status:   http.StatusBadRequest,
+			respCode: resp.CodeInvalidRequest,
+		},
+		{
+			name:      "Too many tags",
+			body:      `{"add":["summer-sale"]}`,
+			mockAdd:   []string{"summer-sale"},
+			mockError: storage.ErrTooManyTags,
+			status:    http.StatusBadRequest,
+			respCode:  resp.CodeInvalidRequest,
+		},
+		{
+			name:      "Not found",
+			body:      `{"add":["summer-sale"]}`,
+			mockAdd:   []string{"summer-sale"},
+			mockError: storage.ErrURLNotFound,
+			status:    http.StatusNotFound,
+			respCode:  resp.CodeNotFound,
+		},
+		{
+			name:      "Owned by another user",
+			body:      `{"add":["summer-sale"]}`,
+			mockAdd:   []string{"summer-sale"},
+			mockError: storage.ErrNotOwner,
+			status:    http.StatusForbidden,
+			respCode:  resp.CodeForbidden,
+		},
+		{
+			name:      "Storage error",
+			body:      `{"add":["summer-sale"]}`,
+			mockAdd:   []string{"summer-sale"},
+			mockError: errors.New("unexpected error"),
+			status:    http.StatusInternalServerError,
+			respCode:  resp.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tagsUpdaterMock := mocks.NewTagsUpdater(t)
+
+			if tc.mockTags != nil || tc.mockError != nil {
+				tagsUpdaterMock.On("UpdateTags", "promo", "alice", tc.mockAdd, tc.mockDrop, 5).
+					Return(tc.mockTags, tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/url/{alias}/tags", tags.New(slogdiscard.NewDiscardLogger(), tagsUpdaterMock, tags.WithMaxTags(5)))
+
+			req := httptest.NewRequest(http.MethodPatch, "/url/promo/tags", bytes.NewReader([]byte(tc.body)))
+			req.SetBasicAuth("alice", "secret")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.status, rr.Code)
+
+			var body tags.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			assert.Equal(t, tc.respCode, body.Code)
+			assert.Equal(t, tc.respTags, body.Tags)
+		})
+	}
+}
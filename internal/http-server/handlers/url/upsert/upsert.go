@@ -0,0 +1,268 @@
+package upsert
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/aliaspolicy"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
+)
+
+type Request struct {
+	URL string `json:"url" validate:"required,url"`
+	// CacheTTL is how long clients may cache the redirect, in seconds.
+	CacheTTL *int `json:"cache_ttl,omitempty" validate:"omitempty,min=0,max=31536000"`
+}
+
+type Response struct {
+	resp.Response
+	Alias   string `json:"alias,omitempty"`
+	Created bool   `json:"created,omitempty"`
+}
+
+// URLUpserter is an interface for creating or updating url by alias.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=URLUpserter
+type URLUpserter interface {
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+}
+
+// Auditor records mutating operations for the audit trail.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=Auditor
+type Auditor interface {
+	Record(entry storage.AuditEntry)
+}
+
+// AliasValidator checks aliases against the deployment policy.
+type AliasValidator interface {
+	Validate(alias string) error
+}
+
+type nopAuditor struct{}
+
+func (nopAuditor) Record(storage.AuditEntry) {}
+
+type options struct {
+	auditor        Auditor
+	aliasValidator AliasValidator
+	schemes        []string
+	quota          quota.Limits
+}
+
+// Option configures the upsert handler.
+type Option func(*options)
+
+// WithAuditor makes the handler record every upsert attempt.
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+	}
+}
+
+// WithAliasValidator replaces the default alias validator, see aliaspolicy.Default.
+func WithAliasValidator(v AliasValidator) Option {
+	return func(o *options) {
+		o.aliasValidator = v
+	}
+}
+
+// WithAllowedSchemes restricts the schemes of saved urls, e.g. to
+// "https". By default any scheme of a valid url is allowed.
+func WithAllowedSchemes(schemes []string) Option {
+	return func(o *options) {
+		o.schemes = schemes
+	}
+}
+
+// WithQuota limits the number of urls each user may create.
+func WithQuota(limits quota.Limits) Option {
+	return func(o *options) {
+		o.quota = limits
+	}
+}
+
+// New creates the alias from the path or updates its url if the alias
+// was created by the same user. It responds 201 on create and 200 on update.
+func New(log *slog.Logger, urlUpserter URLUpserter, opts ...Option) http.HandlerFunc {
+	o := options{
+		auditor:        nopAuditor{},
+		aliasValidator: aliaspolicy.Default(3, 50),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.upsert.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		urlUpserter := storage.WithContext(r.Context(), urlUpserter)
+
+		resp.NoStore(w)
+
+		alias := chi.URLParam(r, "alias")
+		if err := o.aliasValidator.Validate(alias); err != nil {
+			log.Info("invalid alias", slog.String("alias", alias), sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "empty request"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "failed to decode request"))
+
+			return
+		}
+
+		req.URL = strings.TrimSpace(req.URL)
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		urlToSave, err := urlnorm.Normalize(req.URL)
+		if err != nil {
+			log.Info("failed to normalize url", sl.Err(err))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "invalid url"))
+
+			return
+		}
+
+		if err := urlnorm.CheckScheme(urlToSave, o.schemes); err != nil {
+			log.Info("url scheme is not allowed", slog.String("url", urlToSave))
+
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, err.Error()))
+
+			return
+		}
+
+		alias = namespace.Qualify(r.Context(), alias)
+		owner, _, _ := r.BasicAuth()
+
+		entry := storage.AuditEntry{
+			Actor:  audit.Actor(r),
+			Action: audit.ActionUpdate,
+			Alias:  alias,
+		}
+
+		var upsertOpts []storage.SaveOption
+		if req.CacheTTL != nil {
+			upsertOpts = append(upsertOpts, storage.WithCacheTTL(time.Duration(*req.CacheTTL)*time.Second))
+		}
+		if n := o.quota.For(owner); n > 0 {
+			upsertOpts = append(upsertOpts, storage.WithQuota(n))
+		}
+
+		created, err := urlUpserter.UpsertURL(alias, urlToSave, owner, upsertOpts...)
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			log.Info("quota exceeded", slog.String("user", owner), slog.Int64("quota", o.quota.For(owner)))
+
+			entry.Result = "quota exceeded"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(
+				resp.CodeQuotaExceeded,
+				fmt.Sprintf("quota of %d links exceeded, delete some to create new ones", o.quota.For(owner)),
+			))
+
+			return
+		}
+		if errors.Is(err, storage.ErrAliasReserved) {
+			log.Info("alias is reserved by another user", slog.String("alias", alias))
+
+			entry.Result = "alias is reserved"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusConflict)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeAliasExists, "alias is reserved"))
+
+			return
+		}
+		if errors.Is(err, storage.ErrNotOwner) {
+			log.Info("alias is owned by another user", slog.String("alias", alias))
+
+			entry.Result = "alias is owned by another user"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeForbidden, "alias is owned by another user"))
+
+			return
+		}
+		if err != nil {
+			log.Error("failed to upsert url", sl.Err(err))
+
+			entry.Result = "failed to upsert url"
+			o.auditor.Record(entry)
+
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+			return
+		}
+
+		if created {
+			entry.Action = audit.ActionSave
+			render.Status(r, http.StatusCreated)
+		}
+
+		log.Info("url upserted", slog.String("alias", alias), slog.Bool("created", created))
+
+		entry.Result = audit.ResultSuccess
+		o.auditor.Record(entry)
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Alias:    alias,
+			Created:  created,
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package upsert_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/upsert"
+	"url-shortener/internal/http-server/handlers/url/upsert/mocks"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/quota"
+	"url-shortener/internal/storage"
+)
+
+func TestUpsertHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		body       string
+		created    bool
+		mockError  error
+		respError  string
+		wantCode   int
+		wantAction string
+		wantResult string
+	}{
+		{
+			name:       "Created",
+			alias:      "test_alias",
+			body:       `{"url": "https://google.com"}`,
+			created:    true,
+			wantCode:   http.StatusCreated,
+			wantAction: "save",
+			wantResult: "success",
+		},
+		{
+			name:       "Updated",
+			alias:      "test_alias",
+			body:       `{"url": "https://google.com"}`,
+			wantCode:   http.StatusOK,
+			wantAction: "update",
+			wantResult: "success",
+		},
+		{
+			name:       "Owned by another user",
+			alias:      "test_alias",
+			body:       `{"url": "https://google.com"}`,
+			mockError:  storage.ErrNotOwner,
+			respError:  "alias is owned by another user",
+			wantCode:   http.StatusForbidden,
+			wantAction: "update",
+			wantResult: "alias is owned by another user",
+		},
+		{
+			name:       "UpsertURL Error",
+			alias:      "test_alias",
+			body:       `{"url": "https://google.com"}`,
+			mockError:  errors.New("unexpected error"),
+			respError:  "internal error",
+			wantCode:   http.StatusInternalServerError,
+			wantAction: "update",
+			wantResult: "failed to upsert url",
+		},
+		{
+			name:      "Invalid URL",
+			alias:     "test_alias",
+			body:      `{"url": "some invalid URL"}`,
+			respError: "field URL is not a valid URL",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid alias",
+			alias:     "a",
+			body:      `{"url": "https://google.com"}`,
+			respError: "alias length must be between 3 and 50 characters",
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:      "Disallowed scheme",
+			alias:     "test_alias",
+			body:      `{"url": "http://google.com"}`,
+			respError: "url scheme must be one of: https",
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpserterMock := mocks.NewURLUpserter(t)
+			auditorMock := mocks.NewAuditor(t)
+
+			if tc.wantAction != "" {
+				urlUpserterMock.On("UpsertURL", tc.alias, "https://google.com", "admin").
+					Return(tc.created, tc.mockError).
+					Once()
+
+				auditorMock.On("Record", mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Actor == "admin" &&
+						e.Action == tc.wantAction &&
+						e.Alias == tc.alias &&
+						e.Result == tc.wantResult
+				})).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Put("/url/{alias}", upsert.New(
+				slogdiscard.NewDiscardLogger(),
+				urlUpserterMock,
+				upsert.WithAuditor(auditorMock),
+				upsert.WithAllowedSchemes([]string{"https"}),
+			))
+
+			req, err := http.NewRequest(http.MethodPut, "/url/"+tc.alias, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req.SetBasicAuth("admin", "secret")
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantCode, rr.Code)
+			require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+			var resp upsert.Response
+
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			require.Equal(t, tc.respError, resp.Error)
+			require.Equal(t, tc.created, resp.Created)
+		})
+	}
+}
+
+func TestUpsertHandler_CacheTTL(t *testing.T) {
+	urlUpserterMock := mocks.NewURLUpserter(t)
+	urlUpserterMock.On("UpsertURL", "abc", "https://google.com", "admin",
+		mock.MatchedBy(func(opt storage.SaveOption) bool {
+			return storage.NewSaveOptions(opt).CacheTTL == 5*time.Minute
+		})).
+		Return(false, nil).
+		Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}", upsert.New(slogdiscard.NewDiscardLogger(), urlUpserterMock))
+
+	req, err := http.NewRequest(http.MethodPut, "/url/abc", strings.NewReader(`{"url": "https://google.com", "cache_ttl": 300}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", "secret")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestUpsertHandler_Quota(t *testing.T) {
+	urlUpserterMock := mocks.NewURLUpserter(t)
+	urlUpserterMock.On("UpsertURL", "abc", "https://google.com", "alice",
+		mock.MatchedBy(func(opt storage.SaveOption) bool {
+			return storage.NewSaveOptions(opt).Quota == 2
+		})).
+		Return(false, storage.ErrQuotaExceeded).
+		Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}", upsert.New(slogdiscard.NewDiscardLogger(), urlUpserterMock,
+		upsert.WithQuota(quota.Limits{Default: 2}),
+	))
+
+	req, err := http.NewRequest(http.MethodPut, "/url/abc", strings.NewReader(`{"url": "https://google.com"}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("alice", "secret")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	var resp upsert.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "QUOTA_EXCEEDED", resp.Code)
+}
+
+func TestUpsertHandler_Reserved(t *testing.T) {
+	urlUpserterMock := mocks.NewURLUpserter(t)
+	urlUpserterMock.On("UpsertURL", "held", "https://google.com", "bob").
+		Return(false, storage.ErrAliasReserved).
+		Once()
+
+	r := chi.NewRouter()
+	r.Put("/url/{alias}", upsert.New(slogdiscard.NewDiscardLogger(), urlUpserterMock))
+
+	req, err := http.NewRequest(http.MethodPut, "/url/held", strings.NewReader(`{"url": "https://google.com"}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("bob", "secret")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+
+	var resp upsert.Response
+
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, "ALIAS_EXISTS", resp.Code)
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// New allows only the admin user to pass. It relies on the basic auth
+// middleware to check the password, so it must be used after it.
+func New(log *slog.Logger, admin string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/admin"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, _, ok := r.BasicAuth()
+			if ok && user == admin {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log.Info("request rejected, admin only",
+				slog.String("user", user),
+				slog.String("path", r.URL.Path),
+			)
+
+			resp.NoStore(w)
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.ErrorWithCode(resp.CodeForbidden, "admin only"))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
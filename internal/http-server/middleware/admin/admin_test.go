@@ -0,0 +1,40 @@
+package admin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/admin"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestAdmin(t *testing.T) {
+	handler := admin.New(slogdiscard.NewDiscardLogger(), "root")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	cases := []struct {
+		name       string
+		user       string
+		wantStatus int
+	}{
+		{name: "Admin", user: "root", wantStatus: http.StatusOK},
+		{name: "Other user", user: "alice", wantStatus: http.StatusForbidden},
+		{name: "Anonymous", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/stats/summary", nil)
+		if tc.user != "" {
+			req.SetBasicAuth(tc.user, "secret")
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.wantStatus, rr.Code, tc.name)
+	}
+}
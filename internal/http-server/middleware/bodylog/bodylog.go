@@ -0,0 +1,150 @@
+package bodylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// DefaultMaxBodySize is the number of logged bytes of each body.
+const DefaultMaxBodySize = 4 << 10
+
+const redacted = "REDACTED"
+
+// redactedHeaders carry credentials and are never logged.
+var redactedHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+	"X-Alias-Password":    {},
+}
+
+// New logs request and response bodies, truncated to maxBodySize bytes,
+// along with the request headers. It is meant for debugging only:
+// credential headers and "password" fields of JSON bodies are redacted,
+// but the bodies may still hold sensitive data.
+func New(log *slog.Logger, maxBodySize int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/bodylog"),
+		)
+
+		log.Warn("body logging enabled, don't use it in production")
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			entry := log.With(
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			reqBody, err := io.ReadAll(r.Body)
+			if err != nil {
+				entry.Error("failed to read request body", sl.Err(err))
+			}
+			_ = r.Body.Close()
+
+			// the handler reads the body once again
+			r.Body = io.NopCloser(bytes.NewReader(reqBody))
+
+			respBody := &limitedBuffer{max: maxBodySize}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(respBody)
+
+			defer func() {
+				entry.Debug("request bodies",
+					slog.Any("headers", redactHeaders(r.Header)),
+					slog.String("request_body", truncate(redactBody(reqBody), maxBodySize)),
+					slog.Int("status", ww.Status()),
+					slog.String("response_body", respBody.String()),
+					slog.Bool("response_truncated", respBody.truncated),
+				)
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	res := make(map[string]string, len(h))
+
+	for k, v := range h {
+		if _, ok := redactedHeaders[http.CanonicalHeaderKey(k)]; ok {
+			res[k] = redacted
+
+			continue
+		}
+
+		res[k] = strings.Join(v, ", ")
+	}
+
+	return res
+}
+
+// redactBody hides top level "password" fields of a JSON object.
+func redactBody(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body
+	}
+
+	found := false
+
+	for k := range fields {
+		if strings.EqualFold(k, "password") {
+			fields[k] = json.RawMessage(`"` + redacted + `"`)
+			found = true
+		}
+	}
+
+	if !found {
+		return body
+	}
+
+	res, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+
+	return res
+}
+
+func truncate(b []byte, max int) string {
+	if len(b) > max {
+		return string(b[:max])
+	}
+
+	return string(b)
+}
+
+// limitedBuffer keeps the first max bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if left := b.max - b.Len(); left < len(p) {
+		b.truncated = true
+		if left > 0 {
+			b.Buffer.Write(p[:left])
+		}
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,109 @@
+package bodylog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/bodylog"
+)
+
+type record struct {
+	Msg               string            `json:"msg"`
+	Headers           map[string]string `json:"headers"`
+	RequestBody       string            `json:"request_body"`
+	ResponseBody      string            `json:"response_body"`
+	ResponseTruncated bool              `json:"response_truncated"`
+	Status            int               `json:"status"`
+}
+
+func serve(t *testing.T, maxBodySize int, body string, handler http.HandlerFunc) (record, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var logs bytes.Buffer
+
+	log := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(body))
+	req.SetBasicAuth("admin", "secret")
+	req.Header.Set("X-Alias-Password", "secret")
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	bodylog.New(log, maxBodySize)(handler).ServeHTTP(rr, req)
+
+	var rec record
+
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+
+		if rec.Msg == "request bodies" {
+			return rec, rr
+		}
+	}
+
+	t.Fatal("bodies are not logged")
+
+	return rec, rr
+}
+
+func TestBodyLog(t *testing.T) {
+	const reqBody = `{"url":"https://google.com","alias":"test"}`
+
+	var downstream []byte
+
+	rec, rr := serve(t, bodylog.DefaultMaxBodySize, reqBody, func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		downstream, err = io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"status":"OK"}`))
+	})
+
+	// the handler still gets the whole body and the client the whole response
+	assert.Equal(t, reqBody, string(downstream))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `{"status":"OK"}`, rr.Body.String())
+
+	assert.Equal(t, reqBody, rec.RequestBody)
+	assert.Equal(t, `{"status":"OK"}`, rec.ResponseBody)
+	assert.Equal(t, http.StatusCreated, rec.Status)
+	assert.False(t, rec.ResponseTruncated)
+
+	assert.Equal(t, "REDACTED", rec.Headers["Authorization"])
+	assert.Equal(t, "REDACTED", rec.Headers["X-Alias-Password"])
+	assert.Equal(t, "application/json", rec.Headers["Content-Type"])
+}
+
+func TestBodyLog_RedactsPassword(t *testing.T) {
+	rec, _ := serve(t, bodylog.DefaultMaxBodySize, `{"url":"https://google.com","password":"secret"}`,
+		func(w http.ResponseWriter, r *http.Request) {},
+	)
+
+	assert.NotContains(t, rec.RequestBody, "secret")
+	assert.Contains(t, rec.RequestBody, `"password":"REDACTED"`)
+}
+
+func TestBodyLog_Truncates(t *testing.T) {
+	long := strings.Repeat("x", 100)
+
+	rec, rr := serve(t, 10, long, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(long))
+	})
+
+	assert.Equal(t, long, rr.Body.String())
+
+	assert.Equal(t, long[:10], rec.RequestBody)
+	assert.Equal(t, long[:10], rec.ResponseBody)
+	assert.True(t, rec.ResponseTruncated)
+}
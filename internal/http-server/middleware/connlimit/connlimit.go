@@ -0,0 +1,71 @@
+package connlimit
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// ClientIPFunc returns the client IP of a request, see realip.Resolver.
+type ClientIPFunc func(r *http.Request) string
+
+// New rejects requests with 503 while the client IP already has max
+// requests in flight, so that one client can't exhaust the connections.
+func New(log *slog.Logger, max int, clientIP ClientIPFunc) func(next http.Handler) http.Handler {
+	var (
+		mu     sync.Mutex
+		active = make(map[string]int)
+	)
+
+	acquire := func(ip string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if active[ip] >= max {
+			return false
+		}
+
+		active[ip]++
+
+		return true
+	}
+
+	release := func(ip string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		active[ip]--
+		if active[ip] == 0 {
+			delete(active, ip)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/connlimit"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+
+			if !acquire(ip) {
+				log.Warn("too many connections", slog.String("ip", ip), slog.Int("max", max))
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusServiceUnavailable)
+				render.JSON(w, r, resp.Error("too many connections"))
+
+				return
+			}
+			defer release(ip)
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,79 @@
+package connlimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/connlimit"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/realip"
+)
+
+func TestConnLimit(t *testing.T) {
+	const max = 2
+
+	var (
+		entered = make(chan struct{})
+		unblock = make(chan struct{})
+	)
+
+	handler := connlimit.New(slogdiscard.NewDiscardLogger(), max, (&realip.Resolver{}).ClientIP)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			entered <- struct{}{}
+			<-unblock
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	serve := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		req.RemoteAddr = remoteAddr
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	var (
+		wg    sync.WaitGroup
+		codes = make(chan int, max)
+	)
+
+	// occupy all slots of the first IP
+	for i := 0; i < max; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			codes <- serve("1.1.1.1:1000")
+		}()
+
+		<-entered
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve("1.1.1.1:2000"), "cap enforced")
+
+	otherIP := make(chan int, 1)
+	go func() { otherIP <- serve("2.2.2.2:1000") }()
+
+	// the other IP gets in while the first one is at the cap
+	<-entered
+
+	close(unblock)
+	wg.Wait()
+	close(codes)
+
+	assert.Equal(t, http.StatusOK, <-otherIP, "another IP unaffected")
+	for code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	go func() { <-entered }()
+	assert.Equal(t, http.StatusOK, serve("1.1.1.1:3000"), "slots released")
+}
@@ -0,0 +1,55 @@
+package forcehttps
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ForwardedProtoHeader is set by TLS-terminating proxies to the scheme
+// of the original request.
+const ForwardedProtoHeader = "X-Forwarded-Proto"
+
+// New redirects insecure requests to the https scheme of the same host
+// with 301, or with 308 for methods other than GET and HEAD, so that
+// the method and body are preserved. A request is secure if it came
+// over TLS, or if X-Forwarded-Proto is "https" and trustedProxy, e.g.
+// realip.Resolver.FromTrustedProxy, reports the request came from a
+// trusted proxy. A nil trustedProxy trusts no proxies.
+func New(trustedProxy func(r *http.Request) bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if IsSecure(r, trustedProxy) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			status := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				status = http.StatusPermanentRedirect
+			}
+
+			http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), status)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// IsSecure reports whether r came over TLS, or with X-Forwarded-Proto
+// "https" from a proxy trustedProxy trusts. A nil trustedProxy trusts
+// no proxies.
+func IsSecure(r *http.Request, trustedProxy func(r *http.Request) bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	if trustedProxy == nil || !trustedProxy(r) {
+		return false
+	}
+
+	// the leftmost value is set by the proxy closest to the client
+	proto, _, _ := strings.Cut(r.Header.Get(ForwardedProtoHeader), ",")
+
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
@@ -0,0 +1,118 @@
+package forcehttps_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/forcehttps"
+)
+
+func TestForceHTTPS(t *testing.T) {
+	cases := []struct {
+		name           string
+		method         string
+		target         string
+		tls            bool
+		fromProxy      bool
+		forwardedProto string
+		wantStatus     int
+		wantLocation   string
+	}{
+		{
+			name:         "HTTP request redirected",
+			method:       http.MethodGet,
+			target:       "http://short.io/abc?x=1",
+			wantStatus:   http.StatusMovedPermanently,
+			wantLocation: "https://short.io/abc?x=1",
+		},
+		{
+			name:         "HTTP POST redirected preserving method",
+			method:       http.MethodPost,
+			target:       "http://short.io/url",
+			wantStatus:   http.StatusPermanentRedirect,
+			wantLocation: "https://short.io/url",
+		},
+		{
+			name:       "HTTPS request passed through",
+			method:     http.MethodGet,
+			target:     "https://short.io/abc",
+			tls:        true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:           "Forwarded HTTPS passed through",
+			method:         http.MethodGet,
+			target:         "http://short.io/abc",
+			fromProxy:      true,
+			forwardedProto: "https",
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name:           "Forwarded HTTPS from untrusted peer redirected",
+			method:         http.MethodGet,
+			target:         "http://short.io/abc",
+			forwardedProto: "https",
+			wantStatus:     http.StatusMovedPermanently,
+			wantLocation:   "https://short.io/abc",
+		},
+		{
+			name:           "Forwarded HTTP redirected",
+			method:         http.MethodGet,
+			target:         "http://short.io/abc",
+			fromProxy:      true,
+			forwardedProto: "http",
+			wantStatus:     http.StatusMovedPermanently,
+			wantLocation:   "https://short.io/abc",
+		},
+		{
+			name:           "TLS passed through despite forwarded HTTP",
+			method:         http.MethodGet,
+			target:         "https://short.io/abc",
+			tls:            true,
+			forwardedProto: "http",
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name:           "Leftmost forwarded proto used",
+			method:         http.MethodGet,
+			target:         "http://short.io/abc",
+			fromProxy:      true,
+			forwardedProto: "HTTPS, http",
+			wantStatus:     http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			trustedProxy := func(*http.Request) bool { return tc.fromProxy }
+
+			handler := forcehttps.New(trustedProxy)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.TLS = nil
+			}
+			if tc.forwardedProto != "" {
+				req.Header.Set(forcehttps.ForwardedProtoHeader, tc.forwardedProto)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.wantStatus, rr.Code)
+			assert.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package headerlimit
+
+import (
+	"net/http"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// New rejects requests with more than maxCount header values or more
+// than maxBytes of header names and values with 431. It complements
+// http.Server.MaxHeaderBytes, which only bounds the raw HTTP/1 header
+// block, by an explicit check of the parsed headers. Zero disables a limit.
+func New(log *slog.Logger, maxCount int, maxBytes int) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/headerlimit"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			count, size := measure(r.Header)
+
+			if (maxCount > 0 && count > maxCount) || (maxBytes > 0 && size > maxBytes) {
+				log.Warn("request headers too large",
+					slog.Int("count", count),
+					slog.Int("bytes", size),
+					slog.String("remote_addr", r.RemoteAddr),
+				)
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusRequestHeaderFieldsTooLarge)
+				render.JSON(w, r, resp.Error("request headers too large"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// measure returns the number of header values and the bytes taken by names and values.
+func measure(h http.Header) (count int, size int) {
+	for name, values := range h {
+		for _, v := range values {
+			count++
+			size += len(name) + len(v)
+		}
+	}
+
+	return count, size
+}
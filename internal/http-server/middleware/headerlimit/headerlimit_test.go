@@ -0,0 +1,65 @@
+package headerlimit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/headerlimit"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestHeaderLimit(t *testing.T) {
+	handler := headerlimit.New(slogdiscard.NewDiscardLogger(), 10, 1024)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	cases := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{
+			name:       "Regular headers",
+			headers:    map[string]string{"Accept": "application/json", "User-Agent": "test"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Oversized header",
+			headers:    map[string]string{"X-Big": strings.Repeat("a", 2048)},
+			wantStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:       "Too many headers",
+			headers:    manyHeaders(11),
+			wantStatus: http.StatusRequestHeaderFieldsTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, tc.wantStatus, rr.Code, tc.name)
+	}
+}
+
+func manyHeaders(n int) map[string]string {
+	h := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		h[fmt.Sprintf("X-H%d", i)] = "v"
+	}
+
+	return h
+}
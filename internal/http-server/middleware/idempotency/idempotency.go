@@ -0,0 +1,235 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/api/request"
+	resp "url-shortener/internal/lib/api/response"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Header is the request header carrying the idempotency key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader is set on responses replayed for a known key.
+const ReplayedHeader = "Idempotent-Replayed"
+
+const maxKeyLength = 255
+
+// storedHeaders are the response headers replayed along with the body.
+var storedHeaders = []string{"Content-Type", "Location"}
+
+type ResponseStore interface {
+	ClaimKey(key string, actor string, requestHash string, at time.Time, expiredBefore time.Time, staleBefore time.Time) error
+	ReleaseKey(key string, actor string) error
+	StoredResponse(key string, actor string, since time.Time) (storage.StoredResponse, error)
+	StoreResponse(key string, actor string, res storage.StoredResponse) error
+}
+
+// New makes requests with an Idempotency-Key header safe to retry:
+// the first request with a key claims it per basic auth user, and its
+// response is stored. Until ttl passes, the following requests with the
+// key get it replayed without reaching the handler, or 409 Conflict
+// while the first one is in progress. Reusing a key for a different
+// request is rejected with 422 Unprocessable Entity.
+// Server errors and panics are not stored, so a failed request can be
+// retried. A claim left in progress longer than claimTimeout, e.g. by
+// a crashed process, may be claimed again.
+func New(
+	log *slog.Logger,
+	store ResponseStore,
+	ttl time.Duration,
+	claimTimeout time.Duration,
+	clock clock.Clock,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/idempotency"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if len(key) > maxKeyLength {
+				resp.NoStore(w)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "idempotency key is too long"))
+
+				return
+			}
+
+			reqBody, err := io.ReadAll(io.LimitReader(r.Body, request.MaxDecompressedSize+1))
+			if err != nil {
+				log.Error("failed to read request body", sl.Err(err))
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "failed to read request"))
+
+				return
+			}
+			if len(reqBody) > request.MaxDecompressedSize {
+				resp.NoStore(w)
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodePayloadTooLarge, "request body is too large"))
+
+				return
+			}
+			_ = r.Body.Close()
+
+			// the handler reads the body once again
+			r.Body = io.NopCloser(bytes.NewReader(reqBody))
+
+			actor, _, _ := r.BasicAuth()
+			hash := requestHash(r, reqBody)
+			now := clock.Now()
+
+			err = store.ClaimKey(key, actor, hash, now, now.Add(-ttl), now.Add(-claimTimeout))
+			if errors.Is(err, storage.ErrKeyClaimed) {
+				replayClaimed(w, r, log, store, key, actor, hash, now.Add(-ttl))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to claim idempotency key", sl.Err(err))
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+				return
+			}
+
+			// the claim is released unless the response is stored,
+			// including when the handler panics
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+
+				if err := store.ReleaseKey(key, actor); err != nil {
+					log.Error("failed to release idempotency key", sl.Err(err))
+				}
+			}()
+
+			var body bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+
+			next.ServeHTTP(ww, r)
+
+			if ww.Status() >= http.StatusInternalServerError {
+				return
+			}
+
+			res := storage.StoredResponse{
+				Status: ww.Status(),
+				Header: make(map[string][]string),
+				Body:   body.Bytes(),
+			}
+			for _, h := range storedHeaders {
+				if v := w.Header().Values(h); len(v) > 0 {
+					res.Header[h] = v
+				}
+			}
+
+			if err := store.StoreResponse(key, actor, res); err != nil {
+				log.Error("failed to store response", sl.Err(err))
+
+				return
+			}
+
+			stored = true
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// replayClaimed responds to a request whose key is claimed already.
+func replayClaimed(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	store ResponseStore,
+	key string,
+	actor string,
+	hash string,
+	since time.Time,
+) {
+	stored, err := store.StoredResponse(key, actor, since)
+	if err != nil && !errors.Is(err, storage.ErrKeyNotFound) {
+		log.Error("failed to get stored response", sl.Err(err))
+
+		resp.NoStore(w)
+		render.Status(r, http.StatusInternalServerError)
+		render.JSON(w, r, resp.ErrorWithCode(resp.CodeInternal, "internal error"))
+
+		return
+	}
+
+	switch {
+	case err == nil && stored.RequestHash != hash:
+		log.Info("idempotency key reused for a different request")
+
+		resp.NoStore(w)
+		render.Status(r, http.StatusUnprocessableEntity)
+		render.JSON(w, r, resp.ErrorWithCode(resp.CodeInvalidRequest, "idempotency key was used for a different request"))
+	case err != nil || stored.Status == 0:
+		// a claim released in the meantime is in progress as well
+		log.Info("request with idempotency key is in progress")
+
+		resp.NoStore(w)
+		render.Status(r, http.StatusConflict)
+		render.JSON(w, r, resp.ErrorWithCode(resp.CodeConflict, "request with this idempotency key is in progress"))
+	default:
+		log.Info("replaying stored response", slog.Int("status", stored.Status))
+
+		replay(w, stored)
+	}
+}
+
+// requestHash identifies the request by its method, path, query and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, res storage.StoredResponse) {
+	resp.NoStore(w)
+	for h, values := range res.Header {
+		for _, v := range values {
+			w.Header().Add(h, v)
+		}
+	}
+	w.Header().Set(ReplayedHeader, "true")
+
+	w.WriteHeader(res.Status)
+	_, _ = w.Write(res.Body)
+}
@@ -0,0 +1,270 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/idempotency"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func newHandler(t *testing.T, ttl time.Duration, clock clock.Clock) http.Handler {
+	t.Helper()
+
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	log := slogdiscard.NewDiscardLogger()
+
+	router := chi.NewRouter()
+	router.With(idempotency.New(log, storage, ttl, time.Minute, clock)).Post("/url", save.New(log, storage))
+
+	return router
+}
+
+func post(t *testing.T, handler http.Handler, key string, user string) (*httptest.ResponseRecorder, save.Response) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{"url": "https://example.com"}`)))
+	req.SetBasicAuth(user, "pass")
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp save.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Alias)
+
+	return rr, resp
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	handler := newHandler(t, time.Hour, clock.Real{})
+
+	first, created := post(t, handler, "key-1", "alice")
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	second, replayed := post(t, handler, "key-1", "alice")
+	assert.Equal(t, "true", second.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, created, replayed)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+
+	_, other := post(t, handler, "key-2", "alice")
+	assert.NotEqual(t, created.Alias, other.Alias)
+
+	// keys are scoped by user
+	_, bobs := post(t, handler, "key-1", "bob")
+	assert.NotEqual(t, created.Alias, bobs.Alias)
+
+	// no key, no replay
+	_, a := post(t, handler, "", "alice")
+	_, b := post(t, handler, "", "alice")
+	assert.NotEqual(t, a.Alias, b.Alias)
+}
+
+func TestIdempotency_Expired(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	handler := newHandler(t, time.Hour, clk)
+
+	_, created := post(t, handler, "key-1", "alice")
+
+	clk.Advance(time.Hour + time.Second)
+
+	second, recreated := post(t, handler, "key-1", "alice")
+	assert.Empty(t, second.Header().Get(idempotency.ReplayedHeader))
+	assert.NotEqual(t, created.Alias, recreated.Alias)
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	handler := newHandler(t, time.Hour, clock.Real{})
+
+	req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{"url": "https://example.com"}`)))
+	req.Header.Set(idempotency.Header, string(bytes.Repeat([]byte("k"), 256)))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestIdempotency_DifferentRequest(t *testing.T) {
+	handler := newHandler(t, time.Hour, clock.Real{})
+
+	post(t, handler, "key-1", "alice")
+
+	req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{"url": "https://other.com"}`)))
+	req.SetBasicAuth("alice", "pass")
+	req.Header.Set(idempotency.Header, "key-1")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestIdempotency_DifferentQuery(t *testing.T) {
+	handler := newHandler(t, time.Hour, clock.Real{})
+
+	post(t, handler, "key-1", "alice")
+
+	req := httptest.NewRequest(http.MethodPost, "/url?dry_run=true", bytes.NewReader([]byte(`{"url": "https://example.com"}`)))
+	req.SetBasicAuth("alice", "pass")
+	req.Header.Set(idempotency.Header, "key-1")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestIdempotency_Panic(t *testing.T) {
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	calls := 0
+
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), storage, time.Hour, time.Minute, clock.Real{})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			if calls == 1 {
+				panic("boom")
+			}
+
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(idempotency.Header, "key-1")
+
+		rr := httptest.NewRecorder()
+
+		// like the recoverer middleware
+		func() {
+			defer func() {
+				if recover() != nil {
+					rr.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			handler.ServeHTTP(rr, req)
+		}()
+
+		return rr
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, send().Code)
+	assert.Equal(t, http.StatusCreated, send().Code, "the key is released after a panic")
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_StaleClaim(t *testing.T) {
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	clk := clock.NewFake(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	started := make(chan struct{})
+	hung := make(chan struct{})
+	defer close(hung)
+	calls := 0
+
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), storage, time.Hour, time.Minute, clk)(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			if calls == 1 {
+				// never completes, like a crashed process
+				close(started)
+				<-hung
+
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(idempotency.Header, "key-1")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	go send()
+	<-started
+
+	assert.Equal(t, http.StatusConflict, send().Code)
+
+	clk.Advance(time.Minute + time.Second)
+
+	assert.Equal(t, http.StatusCreated, send().Code)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	storage, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = storage.Close() })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	calls := 0
+
+	handler := idempotency.New(slogdiscard.NewDiscardLogger(), storage, time.Hour, time.Minute, clock.Real{})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls++
+			close(started)
+			<-release
+
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(idempotency.Header, "key-1")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+
+	<-started
+	assert.Equal(t, http.StatusConflict, send().Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+
+	replayed := send()
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, "true", replayed.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, 1, calls)
+}
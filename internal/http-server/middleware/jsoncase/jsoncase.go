@@ -0,0 +1,239 @@
+package jsoncase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Naming is the convention of JSON object keys in responses.
+type Naming string
+
+const (
+	// SnakeCase is the naming handlers use, e.g. "short_url".
+	SnakeCase Naming = "snake_case"
+	// CamelCase renames keys like "short_url" to "shortUrl".
+	CamelCase Naming = "camelCase"
+)
+
+// New renames the keys of JSON response bodies to naming. Handlers keep
+// writing snake_case, so SnakeCase responses pass through untouched.
+// Objects under the opaque keys hold data, e.g. aliases, rather than
+// fields, and their keys are left as is.
+func New(naming Naming, opaque ...string) func(next http.Handler) http.Handler {
+	skip := make(map[string]bool, len(opaque))
+	for _, key := range opaque {
+		skip[key] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if naming != CamelCase {
+			return next
+		}
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			cw := &caseWriter{ResponseWriter: w, status: http.StatusOK, opaque: skip}
+			defer cw.finish()
+
+			next.ServeHTTP(cw, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Camel converts a snake_case key to camelCase, e.g. "created_at" to "createdAt".
+func Camel(key string) string {
+	if !strings.Contains(key, "_") {
+		return key
+	}
+
+	var b strings.Builder
+	b.Grow(len(key))
+
+	upper := false
+	for i, c := range key {
+		if c == '_' && i > 0 {
+			upper = true
+			continue
+		}
+		if upper && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// Rename rewrites the object keys of the JSON document data with Camel,
+// keeping the order of keys and the formatting of numbers.
+func Rename(data []byte, opaque map[string]bool) ([]byte, error) {
+	const op = "jsoncase.Rename"
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	out.Grow(len(data))
+
+	if err := rename(dec, &out, opaque, true); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := dec.Token(); err == nil {
+		return nil, fmt.Errorf("%s: trailing data after JSON value", op)
+	}
+
+	// render.JSON ends documents with a new line
+	if bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+func rename(dec *json.Decoder, out *bytes.Buffer, opaque map[string]bool, renameKeys bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			out.WriteByte('[')
+			for i := 0; dec.More(); i++ {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				if err := rename(dec, out, opaque, renameKeys); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+			out.WriteByte(']')
+
+			return nil
+		}
+
+		out.WriteByte('{')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := keyTok.(string)
+
+			name := key
+			if renameKeys {
+				name = Camel(key)
+			}
+			if err := writeValue(out, name); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+
+			if err := rename(dec, out, opaque, renameKeys && !opaque[key]); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		out.WriteByte('}')
+
+		return nil
+	case json.Number:
+		out.WriteString(t.String())
+
+		return nil
+	default:
+		return writeValue(out, t)
+	}
+}
+
+func writeValue(out *bytes.Buffer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	out.Write(b)
+
+	return nil
+}
+
+// caseWriter buffers a JSON body to rename its keys in finish
+// and writes any other body through.
+type caseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	json        bool
+	buf         bytes.Buffer
+	opaque      map[string]bool
+}
+
+func (w *caseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.json = mediaType == "application/json"
+
+	if !w.json {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *caseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.json {
+		return w.buf.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working, JSON ones are flushed in finish.
+func (w *caseWriter) Flush() {
+	if w.json {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *caseWriter) finish() {
+	if !w.json {
+		return
+	}
+
+	body := w.buf.Bytes()
+	if renamed, err := Rename(body, w.opaque); err == nil {
+		body = renamed
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
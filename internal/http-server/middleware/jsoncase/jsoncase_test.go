@@ -0,0 +1,111 @@
+package jsoncase_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/handlers/url/save/mocks"
+	"url-shortener/internal/http-server/middleware/jsoncase"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestCamel(t *testing.T) {
+	cases := map[string]string{
+		"status":        "status",
+		"short_url":     "shortUrl",
+		"created_at":    "createdAt",
+		"max_tags_used": "maxTagsUsed",
+		"_private":      "_private",
+	}
+
+	for in, want := range cases {
+		assert.Equal(t, want, jsoncase.Camel(in), in)
+	}
+}
+
+func TestRename(t *testing.T) {
+	in := `{"short_url":"x","items":[{"created_at":1.50,"tags":["a_b"]}],"urls":{"my_alias":"u"},"n":null}` + "\n"
+	want := `{"shortUrl":"x","items":[{"createdAt":1.50,"tags":["a_b"]}],"urls":{"my_alias":"u"},"n":null}` + "\n"
+
+	got, err := jsoncase.Rename([]byte(in), map[string]bool{"urls": true})
+	require.NoError(t, err)
+
+	assert.Equal(t, want, string(got))
+}
+
+func TestJSONCase(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", "https://google.com", "abc").Return(int64(1), nil)
+
+	handler := save.New(
+		slogdiscard.NewDiscardLogger(),
+		urlSaverMock,
+		save.WithBaseURL("https://sho.rt"),
+	)
+
+	cases := []struct {
+		name     string
+		naming   jsoncase.Naming
+		body     string
+		wantKeys []string
+	}{
+		{
+			name:     "Snake case save",
+			naming:   jsoncase.SnakeCase,
+			body:     `{"url": "https://google.com", "alias": "abc"}`,
+			wantKeys: []string{"alias", "id", "short_url", "status"},
+		},
+		{
+			name:     "Camel case save",
+			naming:   jsoncase.CamelCase,
+			body:     `{"url": "https://google.com", "alias": "abc"}`,
+			wantKeys: []string{"alias", "id", "shortUrl", "status"},
+		},
+		{
+			name:     "Camel case error",
+			naming:   jsoncase.CamelCase,
+			body:     `{"alias": "abc"}`,
+			wantKeys: []string{"error", "status"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+
+			jsoncase.New(tc.naming)(handler).ServeHTTP(rr, req)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			keys := make([]string, 0, len(body))
+			for k := range body {
+				keys = append(keys, k)
+			}
+
+			assert.ElementsMatch(t, tc.wantKeys, keys)
+		})
+	}
+}
+
+func TestJSONCase_NonJSON(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(`{"short_url":"x"}`))
+	})
+
+	rr := httptest.NewRecorder()
+	jsoncase.New(jsoncase.CamelCase)(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, `{"short_url":"x"}`, rr.Body.String())
+}
@@ -4,11 +4,32 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/exp/slog"
 )
 
-func New(log *slog.Logger) func(next http.Handler) http.Handler {
+type options struct {
+	slowThreshold time.Duration
+}
+
+// Option configures the logger middleware.
+type Option func(*options)
+
+// WithSlowThreshold makes requests taking longer than threshold logged
+// as a "slow request" warning. Zero threshold disables it.
+func WithSlowThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+func New(log *slog.Logger, opts ...Option) func(next http.Handler) http.Handler {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	return func(next http.Handler) http.Handler {
 		log := log.With(
 			slog.String("component", "middleware/logger"),
@@ -28,10 +49,24 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 
 			t1 := time.Now()
 			defer func() {
+				duration := time.Since(t1)
+
+				if o.slowThreshold > 0 && duration > o.slowThreshold {
+					entry.Warn("slow request",
+						slog.String("route", routePattern(r)),
+						slog.Int("status", ww.Status()),
+						slog.Int("bytes", ww.BytesWritten()),
+						slog.String("duration", duration.String()),
+						slog.String("threshold", o.slowThreshold.String()),
+					)
+
+					return
+				}
+
 				entry.Info("request completed",
 					slog.Int("status", ww.Status()),
 					slog.Int("bytes", ww.BytesWritten()),
-					slog.String("duration", time.Since(t1).String()),
+					slog.String("duration", duration.String()),
 				)
 			}()
 
@@ -41,3 +76,12 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 		return http.HandlerFunc(fn)
 	}
 }
+
+// routePattern returns the matched chi route, e.g. "/{alias}".
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+
+	return ""
+}
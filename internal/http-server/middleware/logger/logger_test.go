@@ -0,0 +1,58 @@
+package logger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+
+	mwLogger "url-shortener/internal/http-server/middleware/logger"
+)
+
+func TestLogger_SlowRequest(t *testing.T) {
+	cases := []struct {
+		name     string
+		delay    time.Duration
+		wantWarn bool
+	}{
+		{name: "Fast request", delay: 0},
+		{name: "Slow request", delay: 50 * time.Millisecond, wantWarn: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			log := slog.New(slog.NewJSONHandler(&out, nil))
+
+			r := chi.NewRouter()
+			r.Use(mwLogger.New(log, mwLogger.WithSlowThreshold(20*time.Millisecond)))
+			r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tc.delay)
+			})
+
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/alias", nil))
+
+			if !tc.wantWarn {
+				assert.Contains(t, out.String(), `"msg":"request completed"`)
+				assert.NotContains(t, out.String(), `"level":"WARN"`)
+
+				return
+			}
+
+			assert.Contains(t, out.String(), `"level":"WARN"`)
+			assert.Contains(t, out.String(), `"msg":"slow request"`)
+			assert.Contains(t, out.String(), `"route":"/{alias}"`)
+			assert.NotContains(t, out.String(), `"msg":"request completed"`)
+		})
+	}
+}
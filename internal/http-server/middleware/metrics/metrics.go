@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/lib/metrics"
+)
+
+const (
+	// Requests counts served requests.
+	Requests = "http_requests_total"
+	// Duration is the time spent serving requests.
+	Duration = "http_request_duration"
+	// RequestSize is the size of request bodies in bytes.
+	RequestSize = "http_request_size_bytes"
+	// ResponseSize is the size of response bodies in bytes.
+	ResponseSize = "http_response_size_bytes"
+)
+
+// New records the count, duration and body sizes of every request,
+// labelled with the method, route pattern and status. The route pattern
+// is used instead of the path, and methods other than the standard ones
+// are labelled "OTHER", to keep the number of series bounded.
+func New(sink metrics.Sink) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			start := time.Now()
+
+			body := &countingBody{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+
+			next.ServeHTTP(ww, r)
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := []metrics.Label{
+				{Name: "method", Value: method(r.Method)},
+				{Name: "route", Value: route},
+				{Name: "status", Value: strconv.Itoa(status)},
+			}
+
+			sink.Count(Requests, 1, labels...)
+			sink.Timing(Duration, time.Since(start), labels...)
+			sink.Observe(RequestSize, float64(requestSize(r, body)), labels...)
+			sink.Observe(ResponseSize, float64(ww.BytesWritten()), labels...)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// knownMethods are the methods used as labels as is.
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// method returns the label of the request method m.
+func method(m string) string {
+	if knownMethods[m] {
+		return m
+	}
+
+	return "OTHER"
+}
+
+// requestSize is the number of body bytes read by the handler, or the
+// declared length if the handler left the body unread.
+func requestSize(r *http.Request, body *countingBody) int64 {
+	if r.ContentLength > body.n {
+		return r.ContentLength
+	}
+
+	return body.n
+}
+
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+
+	return n, err
+}
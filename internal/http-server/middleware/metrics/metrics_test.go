@@ -0,0 +1,97 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	mwMetrics "url-shortener/internal/http-server/middleware/metrics"
+	"url-shortener/internal/lib/metrics"
+)
+
+func TestMetrics_RoutePatternAndStatus(t *testing.T) {
+	sink := metrics.NewPrometheus("")
+
+	r := chi.NewRouter()
+	r.Use(mwMetrics.New(sink))
+	r.Get("/{alias}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusFound)
+	})
+	r.Handle("/metrics", sink)
+
+	for _, path := range []string{"/abc", "/def"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(),
+		`http_requests_total{method="GET",route="/{alias}",status="302"} 2`)
+	assert.Contains(t, rr.Body.String(),
+		`http_request_duration_seconds_count{method="GET",route="/{alias}",status="302"} 2`)
+}
+
+func TestMetrics_BodySizes(t *testing.T) {
+	sink := metrics.NewPrometheus("")
+
+	r := chi.NewRouter()
+	r.Use(mwMetrics.New(sink))
+	r.Post("/url", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		_, _ = w.Write(append(body, body...))
+	})
+	r.Post("/ignored", func(w http.ResponseWriter, _ *http.Request) {})
+	r.Handle("/metrics", sink)
+
+	// unknown length, the read bytes are counted
+	req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader("0123456789"))
+	req.ContentLength = -1
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/url", strings.NewReader("abcde")))
+
+	// unread body, the declared length is counted
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/ignored", strings.NewReader("xyz")))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `http_request_size_bytes_bucket{method="POST",route="/url",status="200",le="64"} 2`)
+	assert.Contains(t, body, `http_request_size_bytes_sum{method="POST",route="/url",status="200"} 15`)
+	assert.Contains(t, body, `http_request_size_bytes_count{method="POST",route="/url",status="200"} 2`)
+	assert.Contains(t, body, `http_response_size_bytes_sum{method="POST",route="/url",status="200"} 30`)
+	assert.Contains(t, body, `http_requests_total{method="POST",route="/url",status="200"} 2`)
+	assert.Contains(t, body, `http_request_size_bytes_sum{method="POST",route="/ignored",status="200"} 3`)
+	assert.Contains(t, body, `http_response_size_bytes_sum{method="POST",route="/ignored",status="200"} 0`)
+}
+
+func TestMetrics_UnknownMethod(t *testing.T) {
+	sink := metrics.NewPrometheus("")
+
+	r := chi.NewRouter()
+	r.Use(mwMetrics.New(sink))
+	r.Handle("/metrics", sink)
+
+	for _, m := range []string{"FOO", "BAR", http.MethodDelete} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(m, "/abc", nil))
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+
+	assert.Contains(t, body, `http_requests_total{method="OTHER",route="unmatched",status="405"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="DELETE",route="unmatched",status="404"} 1`)
+	assert.NotContains(t, body, "FOO")
+}
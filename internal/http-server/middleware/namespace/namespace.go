@@ -0,0 +1,71 @@
+package namespace
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Separator joins a namespace and an alias in the stored alias.
+const Separator = "/"
+
+type ctxKey struct{}
+
+// New puts the namespace of the authenticated user into the request context.
+// namespaces maps a user name to its namespace; users without one
+// work in the global namespace.
+func New(namespaces map[string]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, _, _ := r.BasicAuth()
+
+			if ns := namespaces[user]; ns != "" {
+				r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, ns))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// FromContext returns the namespace of the request or an empty string.
+func FromContext(ctx context.Context) string {
+	ns, _ := ctx.Value(ctxKey{}).(string)
+
+	return ns
+}
+
+// Qualify prefixes alias with the namespace of the request, if any.
+func Qualify(ctx context.Context, alias string) string {
+	return Join(FromContext(ctx), alias)
+}
+
+// Join prefixes alias with ns. An empty ns leaves alias as is.
+func Join(ns string, alias string) string {
+	if ns == "" {
+		return alias
+	}
+
+	return ns + Separator + alias
+}
+
+// IsUnqualified reports whether alias has no namespace, so a user
+// can't create or delete aliases in a namespace other than its own.
+func IsUnqualified(alias string) bool {
+	return !strings.Contains(alias, Separator)
+}
+
+// Owns reports whether alias belongs to the namespace of the request.
+// Users without a namespace own only unqualified aliases.
+func Owns(ctx context.Context, alias string) bool {
+	ns := FromContext(ctx)
+	if ns == "" {
+		return IsUnqualified(alias)
+	}
+
+	rest, ok := strings.CutPrefix(alias, ns+Separator)
+
+	return ok && IsUnqualified(rest)
+}
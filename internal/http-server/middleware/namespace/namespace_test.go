@@ -0,0 +1,107 @@
+package namespace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/redirect"
+	"url-shortener/internal/http-server/handlers/url/save"
+	"url-shortener/internal/http-server/middleware/namespace"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+type memStorage struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func (s *memStorage) SaveURL(urlToSave string, alias string, _ ...storage.SaveOption) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.urls[alias]; ok {
+		return 0, storage.ErrURLExists
+	}
+
+	s.urls[alias] = urlToSave
+
+	return int64(len(s.urls)), nil
+}
+
+func (s *memStorage) GetURL(alias string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, ok := s.urls[alias]
+	if !ok {
+		return "", storage.ErrURLNotFound
+	}
+
+	return url, nil
+}
+
+func TestNamespace_TenantsShareUnqualifiedAlias(t *testing.T) {
+	log := slogdiscard.NewDiscardLogger()
+	store := &memStorage{urls: make(map[string]string)}
+
+	r := chi.NewRouter()
+	r.With(namespace.New(map[string]string{
+		"acme":   "acme",
+		"globex": "globex",
+	})).Post("/url", save.New(log, store))
+	r.Get("/{alias}", redirect.New(log, store))
+	r.Get("/{namespace}/{alias}", redirect.New(log, store))
+
+	create := func(user string, url string, alias string) save.Response {
+		body, err := json.Marshal(save.Request{URL: url, Alias: alias})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader(body))
+		req.SetBasicAuth(user, "secret")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		var resp save.Response
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		return resp
+	}
+
+	resolve := func(path string) string {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+		return rr.Header().Get("Location")
+	}
+
+	acme := create("acme", "https://acme.com/sale", "summer-sale")
+	globex := create("globex", "https://globex.com/sale", "summer-sale")
+	global := create("admin", "https://example.com/sale", "summer-sale")
+
+	assert.Empty(t, acme.Error)
+	assert.Empty(t, globex.Error)
+	assert.Empty(t, global.Error)
+
+	assert.Equal(t, "acme/summer-sale", acme.Alias)
+	assert.Equal(t, "globex/summer-sale", globex.Alias)
+	assert.Equal(t, "summer-sale", global.Alias)
+
+	assert.Equal(t, "https://acme.com/sale", resolve("/acme/summer-sale"))
+	assert.Equal(t, "https://globex.com/sale", resolve("/globex/summer-sale"))
+	assert.Equal(t, "https://example.com/sale", resolve("/summer-sale"))
+
+	// a tenant can't create an alias in another namespace
+	resp := create("acme", "https://acme.com/evil", "globex/summer-sale")
+	assert.Equal(t, `alias must not contain "/"`, resp.Error)
+	assert.Equal(t, "https://globex.com/sale", resolve("/globex/summer-sale"))
+}
@@ -0,0 +1,116 @@
+package prettyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// QueryParam requests indented JSON for a single response, e.g. "?pretty=true".
+const QueryParam = "pretty"
+
+const indent = "  "
+
+// New indents JSON responses if always is set or the request has
+// the "pretty" query parameter set to true. Other responses pass through.
+// JSON bodies are buffered to be indented, so it is meant for debugging.
+func New(always bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !wantPretty(r, always) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			pw := &prettyWriter{ResponseWriter: w, status: http.StatusOK}
+			defer pw.finish()
+
+			next.ServeHTTP(pw, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func wantPretty(r *http.Request, always bool) bool {
+	v := r.URL.Query().Get(QueryParam)
+	if v == "" {
+		return always
+	}
+
+	pretty, err := strconv.ParseBool(v)
+	if err != nil {
+		return always
+	}
+
+	return pretty
+}
+
+// prettyWriter buffers a JSON body to indent it in finish
+// and writes any other body through.
+type prettyWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	json        bool
+	buf         bytes.Buffer
+}
+
+func (w *prettyWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.json = mediaType == "application/json"
+
+	if !w.json {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *prettyWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.json {
+		return w.buf.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working, JSON ones are flushed in finish.
+func (w *prettyWriter) Flush() {
+	if w.json {
+		return
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *prettyWriter) finish() {
+	if !w.json {
+		return
+	}
+
+	body := w.buf.Bytes()
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", indent); err == nil {
+		body = out.Bytes()
+	}
+
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	_, _ = w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,89 @@
+package prettyjson_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/prettyjson"
+)
+
+func TestPrettyJSON(t *testing.T) {
+	jsonHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, map[string]string{"status": "OK"})
+	})
+	textHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(`{"status":"OK"}`))
+	})
+
+	const (
+		compact  = "{\"status\":\"OK\"}\n"
+		indented = "{\n  \"status\": \"OK\"\n}\n"
+	)
+
+	cases := []struct {
+		name     string
+		always   bool
+		handler  http.Handler
+		target   string
+		wantBody string
+		wantCode int
+	}{
+		{
+			name:     "Compact by default",
+			handler:  jsonHandler,
+			target:   "/",
+			wantBody: compact,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Requested",
+			handler:  jsonHandler,
+			target:   "/?pretty=true",
+			wantBody: indented,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Always",
+			always:   true,
+			handler:  jsonHandler,
+			target:   "/",
+			wantBody: indented,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Disabled per request",
+			always:   true,
+			handler:  jsonHandler,
+			target:   "/?pretty=false",
+			wantBody: compact,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Not JSON",
+			handler:  textHandler,
+			target:   "/?pretty=true",
+			wantBody: `{"status":"OK"}`,
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rr := httptest.NewRecorder()
+			prettyjson.New(tc.always)(tc.handler).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			assert.Equal(t, tc.wantCode, rr.Code)
+			assert.Equal(t, tc.wantBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package readonly
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Mode is a read-only (maintenance) switch which may be flipped at runtime.
+type Mode struct {
+	enabled atomic.Bool
+}
+
+func NewMode(enabled bool) *Mode {
+	m := &Mode{}
+	m.enabled.Store(enabled)
+
+	return m
+}
+
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+func (m *Mode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// New rejects mutating requests with 503 while read-only mode is enabled.
+// Safe methods (GET, HEAD, OPTIONS) are always passed through.
+func New(log *slog.Logger, mode *Mode) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/readonly"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !mode.Enabled() || isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log.Info("request rejected in read-only mode",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			resp.NoStore(w)
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, resp.Error("service is in maintenance mode, changes are temporarily disabled"))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+package readonly_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/readonly"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestReadOnly(t *testing.T) {
+	mode := readonly.NewMode(true)
+
+	ok := func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	r := chi.NewRouter()
+	r.Use(readonly.New(slogdiscard.NewDiscardLogger(), mode))
+	r.Post("/url", ok)
+	r.Delete("/url/{alias}", ok)
+	r.Get("/{alias}", ok)
+
+	cases := []struct {
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{method: http.MethodPost, path: "/url", wantStatus: http.StatusServiceUnavailable},
+		{method: http.MethodDelete, path: "/url/abc", wantStatus: http.StatusServiceUnavailable},
+		{method: http.MethodGet, path: "/abc", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		assert.Equal(t, tc.wantStatus, rr.Code, "%s %s", tc.method, tc.path)
+	}
+
+	// flipping the mode at runtime lets mutations through again
+	mode.Set(false)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/url", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
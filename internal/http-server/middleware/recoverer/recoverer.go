@@ -0,0 +1,91 @@
+package recoverer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	resp "url-shortener/internal/lib/api/response"
+)
+
+// Response is sent on a recovered panic. IncidentID is logged
+// along with the stack, so that a report can be matched to it.
+type Response struct {
+	resp.Response
+	IncidentID string `json:"incident_id"`
+}
+
+var panics atomic.Int64
+
+// Panics returns the number of panics recovered since the start.
+func Panics() int64 {
+	return panics.Load()
+}
+
+// New replaces middleware.Recoverer: it logs the panic value and the
+// stack through log and responds 500 with an incident ID.
+// http.ErrAbortHandler is re-panicked to abort the response.
+func New(log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/recoverer"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rvr := recover()
+				if rvr == nil {
+					return
+				}
+				if rvr == http.ErrAbortHandler {
+					panic(rvr)
+				}
+
+				panics.Add(1)
+
+				incidentID := newIncidentID()
+
+				log.Error("panic recovered",
+					slog.String("panic", fmt.Sprint(rvr)),
+					slog.String("stack", string(debug.Stack())),
+					slog.String("incident_id", incidentID),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+				)
+
+				// the response can't be changed on upgraded connections
+				if r.Header.Get("Connection") == "Upgrade" {
+					return
+				}
+
+				resp.NoStore(w)
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, Response{
+					Response:   resp.ErrorWithCode(resp.CodeInternal, "internal error"),
+					IncidentID: incidentID,
+				})
+			}()
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func newIncidentID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", panics.Load())
+	}
+
+	return hex.EncodeToString(b)
+}
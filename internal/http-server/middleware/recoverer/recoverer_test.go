@@ -0,0 +1,112 @@
+package recoverer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/http-server/middleware/recoverer"
+	"url-shortener/internal/lib/api/response"
+)
+
+type record struct {
+	Msg        string `json:"msg"`
+	Panic      string `json:"panic"`
+	Stack      string `json:"stack"`
+	IncidentID string `json:"incident_id"`
+	RequestID  string `json:"request_id"`
+	Path       string `json:"path"`
+}
+
+// syncBuffer is written by the server goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestRecoverer(t *testing.T) {
+	var logs syncBuffer
+
+	log := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(recoverer.New(log))
+	router.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("something broke")
+	})
+	router.Get("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	before := recoverer.Panics()
+
+	res, err := http.Get(srv.URL + "/panic")
+	require.NoError(t, err)
+
+	var body recoverer.Response
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.NoError(t, res.Body.Close())
+
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, response.StatusError, body.Status)
+	assert.Equal(t, response.CodeInternal, body.Code)
+	assert.Equal(t, "internal error", body.Error)
+	assert.NotEmpty(t, body.IncidentID)
+	assert.Equal(t, before+1, recoverer.Panics())
+
+	var rec record
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(logs.String())), &rec))
+
+	assert.Equal(t, "panic recovered", rec.Msg)
+	assert.Equal(t, "something broke", rec.Panic)
+	assert.Equal(t, body.IncidentID, rec.IncidentID)
+	assert.NotEmpty(t, rec.RequestID)
+	assert.Equal(t, "/panic", rec.Path)
+	assert.Contains(t, rec.Stack, "recoverer_test.go")
+
+	// the server keeps serving
+	res, err = http.Get(srv.URL + "/ok")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestRecoverer_AbortHandler(t *testing.T) {
+	handler := recoverer.New(slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			panic(http.ErrAbortHandler)
+		}),
+	)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,71 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// DefaultHeader is the request ID header used if none is configured.
+const DefaultHeader = "X-Request-ID"
+
+// maxLength bounds inbound IDs, so that clients can't bloat the logs.
+const maxLength = 128
+
+// New stores the request ID in the context, so that it is available
+// via middleware.GetReqID, and echoes it in the response header.
+// A valid inbound ID is kept for cross-service correlation,
+// otherwise a new one is generated.
+func New(header string) func(next http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if !Valid(id) {
+				id = generate()
+			}
+
+			w.Header().Set(header, id)
+
+			ctx := context.WithValue(r.Context(), middleware.RequestIDKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// Valid reports whether id is non-empty, at most 128 characters long
+// and consists of letters, digits and "-", "_", ".", ":", "/".
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func generate() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatUint(middleware.NextRequestID(), 10)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		name      string
+		header    string
+		inboundID string
+		wantID    string
+	}{
+		{
+			name:      "Inbound ID honored",
+			inboundID: "abc-123",
+			wantID:    "abc-123",
+		},
+		{
+			name:      "Custom header",
+			header:    "X-Correlation-ID",
+			inboundID: "trace:42/7",
+			wantID:    "trace:42/7",
+		},
+		{
+			name: "Absent ID generated",
+		},
+		{
+			name:      "Invalid ID replaced",
+			inboundID: "bad id\n",
+		},
+		{
+			name:      "Too long ID replaced",
+			inboundID: strings.Repeat("a", 129),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := tc.header
+			if header == "" {
+				header = requestid.DefaultHeader
+			}
+
+			var gotID string
+
+			handler := requestid.New(tc.header)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = middleware.GetReqID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.inboundID != "" {
+				req.Header.Set(header, tc.inboundID)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.wantID != "" {
+				assert.Equal(t, tc.wantID, gotID)
+			} else {
+				assert.NotEmpty(t, gotID)
+				assert.NotEqual(t, tc.inboundID, gotID)
+				assert.True(t, requestid.Valid(gotID))
+			}
+
+			assert.Equal(t, gotID, rr.Header().Get(header), "echo header")
+		})
+	}
+}
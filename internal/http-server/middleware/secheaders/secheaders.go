@@ -0,0 +1,20 @@
+package secheaders
+
+import "net/http"
+
+// New sets headers on every response, e.g. X-Content-Type-Options.
+// They are set before the handler runs, so a handler may override them
+// for its own responses.
+func New(headers map[string]string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
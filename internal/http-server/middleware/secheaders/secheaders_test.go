@@ -0,0 +1,37 @@
+package secheaders_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/http-server/middleware/secheaders"
+)
+
+func TestNew(t *testing.T) {
+	handler := secheaders.New(map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// handlers may override a header for their own responses
+		if r.URL.Path == "/embed" {
+			w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		}
+
+		http.NotFound(w, r)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+	assert.Empty(t, rr.Header().Values("Referrer-Policy"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/embed", nil))
+
+	assert.Equal(t, "SAMEORIGIN", rr.Header().Get("X-Frame-Options"))
+}
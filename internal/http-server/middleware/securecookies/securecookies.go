@@ -0,0 +1,80 @@
+package securecookies
+
+import (
+	"net/http"
+
+	"url-shortener/internal/lib/cookie"
+)
+
+// New rewrites every cookie set by next to carry the attributes of
+// policy, so handlers can't leak a cookie by forgetting them. Set-Cookie
+// headers which can't be parsed are dropped.
+func New(policy cookie.Policy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&writer{ResponseWriter: w, policy: policy}, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+type writer struct {
+	http.ResponseWriter
+	policy      cookie.Policy
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.secureCookies()
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writer) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *writer) secureCookies() {
+	header := w.Header()
+
+	values := header.Values("Set-Cookie")
+	if len(values) == 0 {
+		return
+	}
+
+	header.Del("Set-Cookie")
+
+	for _, v := range values {
+		cookies := (&http.Response{Header: http.Header{"Set-Cookie": {v}}}).Cookies()
+		if len(cookies) == 0 {
+			continue
+		}
+
+		w.policy.Apply(cookies[0])
+
+		header.Add("Set-Cookie", cookies[0].String())
+	}
+}
@@ -0,0 +1,55 @@
+package securecookies_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/middleware/securecookies"
+	"url-shortener/internal/lib/cookie"
+)
+
+func TestSecureCookies(t *testing.T) {
+	cases := []struct {
+		name   string
+		secure bool
+	}{
+		{name: "Secure", secure: true},
+		{name: "Dev", secure: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "token", Path: "/admin"})
+				w.Header().Add("Set-Cookie", "csrf=abc; SameSite=None")
+				w.Header().Add("Set-Cookie", "=")
+
+				_, _ = w.Write([]byte("ok"))
+			})
+
+			rr := httptest.NewRecorder()
+			securecookies.New(cookie.NewPolicy(tc.secure))(next).
+				ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/admin/", nil))
+
+			assert.Equal(t, "ok", rr.Body.String())
+
+			cookies := rr.Result().Cookies()
+			require.Len(t, cookies, 2, "unparsable cookie is dropped")
+
+			for _, c := range cookies {
+				assert.Equal(t, tc.secure, c.Secure, c.Name)
+				assert.True(t, c.HttpOnly, c.Name)
+				assert.Equal(t, http.SameSiteStrictMode, c.SameSite, c.Name)
+			}
+
+			assert.Equal(t, "/admin", cookies[0].Path, "path is kept")
+			assert.Equal(t, "/", cookies[1].Path)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"url-shortener/internal/lib/tracing"
+)
+
+// New starts a server span for every request, continuing the trace
+// from the incoming traceparent header if present.
+func New(tracer *tracing.Tracer) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracing.Extract(r.Context(), r.Header)
+
+			ctx, span := tracer.Start(ctx, "HTTP "+r.Method, tracing.SpanKindServer)
+			if span == nil {
+				next.ServeHTTP(w, r.WithContext(ctx))
+
+				return
+			}
+			defer span.End()
+
+			span.SetAttribute("http.method", r.Method)
+			span.SetAttribute("http.target", r.URL.Path)
+			span.SetAttribute("http.request_id", middleware.GetReqID(ctx))
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetAttribute("http.route", rctx.RoutePattern())
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			span.SetAttribute("http.status_code", strconv.Itoa(status))
+
+			if status >= http.StatusInternalServerError {
+				span.RecordError(errStatus(status))
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+type errStatus int
+
+func (e errStatus) Error() string {
+	return http.StatusText(int(e))
+}
@@ -0,0 +1,79 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/http-server/handlers/redirect"
+	mwTracing "url-shortener/internal/http-server/middleware/tracing"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/tracing"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/traced"
+)
+
+type fakeStorage struct{}
+
+func (fakeStorage) SaveURL(string, string, ...storage.SaveOption) (int64, error) { return 1, nil }
+func (fakeStorage) GetURL(string) (string, error)                                { return "https://example.com", nil }
+func (fakeStorage) UpsertURL(string, string, string, ...storage.SaveOption) (bool, error) {
+	return true, nil
+}
+func (fakeStorage) DeleteURL(string, string) error { return nil }
+
+func TestTracing_RequestWithStorageSpan(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	tracer := tracing.New(slogdiscard.NewDiscardLogger(), exporter, 1)
+
+	r := chi.NewRouter()
+	r.Use(mwTracing.New(tracer))
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), traced.New(fakeStorage{}, tracer)))
+
+	req := httptest.NewRequest(http.MethodGet, "/test_alias", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusFound, rr.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, tracer.Shutdown(ctx))
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+
+	// the storage span ends first
+	storageSpan, requestSpan := spans[0], spans[1]
+
+	assert.Equal(t, "HTTP GET", requestSpan.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", requestSpan.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", requestSpan.ParentSpanID.String())
+	assert.Equal(t, "/{alias}", requestSpan.Attributes["http.route"])
+	assert.Equal(t, "302", requestSpan.Attributes["http.status_code"])
+
+	assert.Equal(t, "storage.GetURL", storageSpan.Name)
+	assert.Equal(t, requestSpan.TraceID, storageSpan.TraceID)
+	assert.Equal(t, requestSpan.SpanID, storageSpan.ParentSpanID)
+	assert.Equal(t, "test_alias", storageSpan.Attributes["url.alias"])
+}
+
+func TestTracing_Disabled(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(mwTracing.New(nil))
+	r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), traced.New(fakeStorage{}, nil)))
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test_alias", nil))
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+}
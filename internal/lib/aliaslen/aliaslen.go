@@ -0,0 +1,151 @@
+// Package aliaslen grows the length of generated aliases
+// when they collide with existing ones too often.
+package aliaslen
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+// minAttempts is the number of generated aliases in a window
+// below which the collision rate is not evaluated.
+const minAttempts = 10
+
+// Store persists the length, so that it survives restarts and is shared
+// by the replicas, e.g. sqlite.Storage. AliasLength returns zero if no
+// length was saved.
+type Store interface {
+	AliasLength() (int, error)
+	SetAliasLength(length int) error
+}
+
+// Autoscaler tracks the collision rate of generated aliases and increases
+// their length by one when the rate over a window exceeds the threshold.
+type Autoscaler struct {
+	log       *slog.Logger
+	max       int
+	threshold float64
+	window    time.Duration
+	now       func() time.Time
+	store     Store
+
+	mu          sync.Mutex
+	length      int
+	windowStart time.Time
+	attempts    int
+	collisions  int
+}
+
+// Option configures the Autoscaler.
+type Option func(*Autoscaler)
+
+// WithStore makes the Autoscaler resume from the length saved in store,
+// if it is longer, and save the length whenever it grows. Without it the
+// length starts over on every restart.
+func WithStore(store Store) Option {
+	return func(a *Autoscaler) {
+		a.store = store
+	}
+}
+
+// New returns an Autoscaler starting at length and growing up to max.
+// threshold is the collision rate, e.g. 0.01 for 1%.
+func New(log *slog.Logger, length int, max int, threshold float64, window time.Duration, opts ...Option) *Autoscaler {
+	a := &Autoscaler{
+		log:       log.With(slog.String("component", "aliaslen")),
+		length:    length,
+		max:       max,
+		threshold: threshold,
+		window:    window,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.windowStart = a.now()
+
+	if a.store != nil {
+		a.load()
+	}
+
+	return a
+}
+
+// load resumes from the saved length, capped at max.
+func (a *Autoscaler) load() {
+	saved, err := a.store.AliasLength()
+	if err != nil {
+		a.log.Error("failed to load alias length", sl.Err(err))
+
+		return
+	}
+
+	if saved > a.max {
+		saved = a.max
+	}
+	if saved > a.length {
+		a.length = saved
+	}
+}
+
+// Length returns the current length of generated aliases.
+func (a *Autoscaler) Length() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.length
+}
+
+// Observe records an attempt to save a generated alias. The collision
+// rate of a window is evaluated on the first attempt after it ends.
+func (a *Autoscaler) Observe(collision bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if now.Sub(a.windowStart) >= a.window {
+		a.evaluate()
+
+		a.windowStart = now
+		a.attempts = 0
+		a.collisions = 0
+	}
+
+	a.attempts++
+	if collision {
+		a.collisions++
+	}
+}
+
+// evaluate grows the length if the collision rate of the current window
+// exceeds the threshold. It must be called with mu held.
+func (a *Autoscaler) evaluate() {
+	if a.attempts < minAttempts || a.length >= a.max {
+		return
+	}
+
+	rate := float64(a.collisions) / float64(a.attempts)
+	if rate <= a.threshold {
+		return
+	}
+
+	a.length++
+
+	if a.store != nil {
+		if err := a.store.SetAliasLength(a.length); err != nil {
+			a.log.Error("failed to save alias length", sl.Err(err))
+		}
+	}
+
+	a.log.Warn("alias collision rate exceeded threshold, increasing alias length",
+		slog.Float64("rate", rate),
+		slog.Float64("threshold", a.threshold),
+		slog.Int("length", a.length),
+		slog.Int("max", a.max),
+	)
+}
@@ -0,0 +1,114 @@
+package aliaslen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func newTestAutoscaler(length int, max int) (*Autoscaler, *testClock) {
+	clock := &testClock{now: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	a := New(slogdiscard.NewDiscardLogger(), length, max, 0.1, time.Minute)
+	a.now = func() time.Time { return clock.now }
+	a.windowStart = clock.now
+
+	return a, clock
+}
+
+// observeWindow records attempts and moves the clock to the next window.
+func observeWindow(a *Autoscaler, clock *testClock, attempts int, collisions int) {
+	for i := 0; i < attempts; i++ {
+		a.Observe(i < collisions)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+}
+
+func TestAutoscaler_GrowsOnHighCollisionRate(t *testing.T) {
+	a, clock := newTestAutoscaler(6, 8)
+
+	observeWindow(a, clock, 20, 5)
+	assert.Equal(t, 6, a.Length(), "evaluated only when the window ends")
+
+	a.Observe(false)
+	assert.Equal(t, 7, a.Length())
+
+	observeWindow(a, clock, 19, 5)
+	a.Observe(false)
+	assert.Equal(t, 8, a.Length())
+}
+
+func TestAutoscaler_CapsAtMax(t *testing.T) {
+	a, clock := newTestAutoscaler(6, 7)
+
+	for i := 0; i < 5; i++ {
+		observeWindow(a, clock, 10, 10)
+	}
+	a.Observe(false)
+
+	assert.Equal(t, 7, a.Length())
+}
+
+func TestAutoscaler_LowCollisionRate(t *testing.T) {
+	a, clock := newTestAutoscaler(6, 8)
+
+	observeWindow(a, clock, 100, 10)
+	a.Observe(false)
+
+	assert.Equal(t, 6, a.Length())
+}
+
+func TestAutoscaler_TooFewAttempts(t *testing.T) {
+	a, clock := newTestAutoscaler(6, 8)
+
+	observeWindow(a, clock, minAttempts-1, minAttempts-1)
+	a.Observe(false)
+
+	assert.Equal(t, 6, a.Length())
+}
+
+type memStore struct {
+	length int
+}
+
+func (s *memStore) AliasLength() (int, error) {
+	return s.length, nil
+}
+
+func (s *memStore) SetAliasLength(length int) error {
+	s.length = length
+
+	return nil
+}
+
+func TestAutoscaler_Store(t *testing.T) {
+	store := &memStore{}
+
+	a, clock := newTestAutoscaler(6, 8)
+	a.store = store
+
+	observeWindow(a, clock, 20, 5)
+	a.Observe(false)
+	assert.Equal(t, 7, store.length, "saved on growth")
+
+	// a restart resumes from the saved length
+	resumed := New(slogdiscard.NewDiscardLogger(), 6, 8, 0.1, time.Minute, WithStore(store))
+	assert.Equal(t, 7, resumed.Length())
+
+	// capped at max
+	store.length = 10
+	capped := New(slogdiscard.NewDiscardLogger(), 6, 8, 0.1, time.Minute, WithStore(store))
+	assert.Equal(t, 8, capped.Length())
+
+	// a longer configured length wins
+	longer := New(slogdiscard.NewDiscardLogger(), 9, 12, 0.1, time.Minute, WithStore(&memStore{length: 7}))
+	assert.Equal(t, 9, longer.Length())
+}
@@ -0,0 +1,131 @@
+package aliaspolicy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"url-shortener/internal/lib/checksum"
+)
+
+// Validator checks a custom alias against a policy. The error message
+// is returned to the client as is.
+type Validator interface {
+	Validate(alias string) error
+}
+
+// Func is an adapter to use ordinary functions as validators.
+type Func func(alias string) error
+
+func (f Func) Validate(alias string) error {
+	return f(alias)
+}
+
+// DefaultReserved are aliases which would shadow the service routes.
+var DefaultReserved = []string{"admin", "api", "audit", "metrics", "readyz", "url", "urls"}
+
+// ErrInvalidCharacters is returned for aliases which can't be used in a short link path.
+var ErrInvalidCharacters = errors.New("alias contains invalid characters")
+
+// Default returns the built-in policy: the alias length is within
+// [min, max], it has only printable characters and it isn't reserved.
+func Default(min int, max int) Validator {
+	return Chain(Format(), Length(min, max), Reserved(DefaultReserved...))
+}
+
+// Chain runs validators in order and returns the first error.
+func Chain(validators ...Validator) Validator {
+	return Func(func(alias string) error {
+		for _, v := range validators {
+			if err := v.Validate(alias); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Format rejects invalid UTF-8, spaces and control characters.
+func Format() Validator {
+	return Func(func(alias string) error {
+		if !utf8.ValidString(alias) {
+			return ErrInvalidCharacters
+		}
+
+		for _, r := range alias {
+			if r == utf8.RuneError || unicode.IsSpace(r) || unicode.IsControl(r) {
+				return ErrInvalidCharacters
+			}
+		}
+
+		return nil
+	})
+}
+
+// Length rejects aliases shorter than min or longer than max runes.
+func Length(min int, max int) Validator {
+	return Func(func(alias string) error {
+		if n := utf8.RuneCountInString(alias); n < min || n > max {
+			return fmt.Errorf("alias length must be between %d and %d characters", min, max)
+		}
+
+		return nil
+	})
+}
+
+// Reserved rejects aliases equal to one of words, ignoring case.
+func Reserved(words ...string) Validator {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[strings.ToLower(w)] = struct{}{}
+	}
+
+	return Func(func(alias string) error {
+		if _, ok := set[strings.ToLower(alias)]; ok {
+			return fmt.Errorf("alias %q is reserved", alias)
+		}
+
+		return nil
+	})
+}
+
+// Blocklist rejects aliases containing one of words, ignoring case.
+// It may be used as a simple profanity filter.
+func Blocklist(words ...string) Validator {
+	lower := make([]string, 0, len(words))
+	for _, w := range words {
+		if w != "" {
+			lower = append(lower, strings.ToLower(w))
+		}
+	}
+
+	return Func(func(alias string) error {
+		a := strings.ToLower(alias)
+
+		for _, w := range lower {
+			if strings.Contains(a, w) {
+				return errors.New("alias contains a blocked word")
+			}
+		}
+
+		return nil
+	})
+}
+
+// ErrInvalidChecksum is returned for aliases without a valid check character.
+var ErrInvalidChecksum = errors.New("alias has an invalid check character")
+
+// Checksum rejects aliases not ending with their check character,
+// see checksum.Append.
+func Checksum() Validator {
+	return Func(func(alias string) error {
+		if !checksum.Valid(alias) {
+			return ErrInvalidChecksum
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,80 @@
+package aliaspolicy_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/aliaspolicy"
+	"url-shortener/internal/lib/checksum"
+)
+
+func TestDefault(t *testing.T) {
+	v := aliaspolicy.Default(3, 10)
+
+	cases := []struct {
+		name    string
+		alias   string
+		wantErr string
+	}{
+		{name: "Valid", alias: "my_alias"},
+		{name: "Multi-byte runes", alias: "ёёёёёёёёёё"},
+		{name: "Too short", alias: "ab", wantErr: "alias length must be between 3 and 10 characters"},
+		{name: "Too long", alias: "abcdefghijk", wantErr: "alias length must be between 3 and 10 characters"},
+		{name: "Space", alias: "my alias", wantErr: "alias contains invalid characters"},
+		{name: "Invalid UTF-8", alias: "ab\xffcd", wantErr: "alias contains invalid characters"},
+		{name: "Reserved", alias: "AUDIT", wantErr: `alias "AUDIT" is reserved`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := v.Validate(tc.alias)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	v := aliaspolicy.Blocklist("darn", "heck")
+
+	assert.NoError(t, v.Validate("summer-sale"))
+	assert.EqualError(t, v.Validate("oh-DARN-it"), "alias contains a blocked word")
+	assert.EqualError(t, v.Validate("heck"), "alias contains a blocked word")
+}
+
+func TestChecksum(t *testing.T) {
+	v := aliaspolicy.Checksum()
+
+	assert.NoError(t, v.Validate(checksum.Append("summer")))
+	assert.ErrorIs(t, v.Validate("summer"), aliaspolicy.ErrInvalidChecksum)
+}
+
+func TestChain(t *testing.T) {
+	errOrg := errors.New("alias must start with acme-")
+
+	orgPattern := aliaspolicy.Func(func(alias string) error {
+		if len(alias) < 5 || alias[:5] != "acme-" {
+			return errOrg
+		}
+
+		return nil
+	})
+
+	v := aliaspolicy.Chain(aliaspolicy.Default(3, 20), aliaspolicy.Blocklist("darn"), orgPattern)
+
+	assert.NoError(t, v.Validate("acme-sale"))
+	assert.EqualError(t, v.Validate("ab"), "alias length must be between 3 and 20 characters")
+	assert.EqualError(t, v.Validate("acme-darn"), "alias contains a blocked word")
+	assert.ErrorIs(t, v.Validate("globex-sale"), errOrg)
+}
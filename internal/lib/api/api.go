@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -10,21 +12,39 @@ var (
 	ErrInvalidStatusCode = errors.New("invalid status code")
 )
 
+// maxDrain limits how much of an unread body is discarded
+// to let the connection be reused.
+const maxDrain = 64 << 10
+
+// client is shared by all calls to reuse connections.
+var client = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse // stop after 1st redirect
+	},
+}
+
 // GetRedirect returns the final URL after redirection.
 func GetRedirect(url string) (string, error) {
+	return GetRedirectContext(context.Background(), url)
+}
+
+// GetRedirectContext is like GetRedirect, but the request is canceled with ctx.
+func GetRedirectContext(ctx context.Context, url string) (string, error) {
 	const op = "api.GetRedirect"
 
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse // stop after 1st redirect
-		},
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("%s: %w", op, err)
 	}
-	defer func() { _ = resp.Body.Close() }()
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+		_ = resp.Body.Close()
+	}()
 
 	if resp.StatusCode != http.StatusFound {
 		return "", fmt.Errorf("%s: %w: %d", op, ErrInvalidStatusCode, resp.StatusCode)
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// trackingTransport records whether every response body was closed.
+type trackingTransport struct {
+	mu     sync.Mutex
+	bodies []*trackingBody
+}
+
+type trackingBody struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+
+	return b.ReadCloser.Close()
+}
+
+func (t *trackingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &trackingBody{ReadCloser: resp.Body}
+	resp.Body = body
+
+	t.mu.Lock()
+	t.bodies = append(t.bodies, body)
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+func (t *trackingTransport) allClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, b := range t.bodies {
+		if !b.closed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func withTransport(t *testing.T) *trackingTransport {
+	t.Helper()
+
+	transport := &trackingTransport{}
+
+	prev := client.Transport
+	client.Transport = transport
+
+	t.Cleanup(func() { client.Transport = prev })
+
+	return transport
+}
+
+func TestGetRedirect_ClosesBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "https://example.com", http.StatusFound)
+		case "/error":
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{name: "Redirect", path: "/redirect", want: "https://example.com"},
+		{name: "Error response", path: "/error", wantErr: ErrInvalidStatusCode},
+		{name: "Not found", path: "/missing", wantErr: ErrInvalidStatusCode},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := withTransport(t)
+
+			got, err := GetRedirect(ts.URL + tc.path)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.want, got)
+			require.Len(t, transport.bodies, 1)
+			assert.True(t, transport.allClosed())
+		})
+	}
+}
+
+func TestGetRedirectContext_Canceled(t *testing.T) {
+	release := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	transport := withTransport(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := GetRedirectContext(ctx, ts.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.True(t, transport.allClosed())
+}
+
+func TestChecker_CheckReachable(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/get-only":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	checker := NewChecker(200*time.Millisecond, true)
+
+	for _, path := range []string{"/ok", "/moved", "/get-only"} {
+		assert.NoError(t, checker.CheckReachable(context.Background(), srv.URL+path), path)
+	}
+
+	for _, path := range []string{"/missing", "/slow"} {
+		assert.ErrorIs(t, checker.CheckReachable(context.Background(), srv.URL+path), ErrUnreachable, path)
+	}
+}
+
+func TestChecker_ForbiddenAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := NewChecker(time.Second, false).CheckReachable(context.Background(), srv.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestResolver_ResolveFinal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/final":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/once":
+			http.Redirect(w, r, "/final", http.StatusMovedPermanently)
+		case "/twice":
+			http.Redirect(w, r, "/once", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	resolver := NewResolver(time.Second, 2, true)
+
+	for _, path := range []string{"/final", "/once", "/twice"} {
+		final, err := resolver.ResolveFinal(context.Background(), srv.URL+path)
+		require.NoError(t, err, path)
+		assert.Equal(t, srv.URL+"/final", final, path)
+	}
+
+	_, err := resolver.ResolveFinal(context.Background(), srv.URL+"/loop")
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+
+	_, err = resolver.ResolveFinal(context.Background(), srv.URL+"/missing")
+	assert.ErrorIs(t, err, ErrUnreachable)
+
+	_, err = NewResolver(time.Second, 2, false).ResolveFinal(context.Background(), srv.URL+"/once")
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
+
+func TestResolver_Redirect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/once" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	location, err := NewResolver(time.Second, 0, true).Redirect(context.Background(), srv.URL+"/once")
+	require.NoError(t, err)
+	assert.Equal(t, "/final", location)
+
+	_, err = NewResolver(time.Second, 0, true).Redirect(context.Background(), srv.URL+"/final")
+	assert.ErrorIs(t, err, ErrInvalidStatusCode)
+
+	_, err = NewResolver(time.Second, 0, false).Redirect(context.Background(), srv.URL+"/once")
+	assert.ErrorIs(t, err, ErrForbiddenAddress)
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrUnreachable = errors.New("url is unreachable")
+	// ErrForbiddenAddress is returned for URLs resolving to loopback,
+	// private and other internal addresses.
+	ErrForbiddenAddress = errors.New("forbidden address")
+)
+
+// Checker checks that URLs are reachable without following redirects.
+type Checker struct {
+	client *http.Client
+}
+
+// NewChecker returns a Checker which gives up after timeout. Unless
+// allowPrivate is set, it refuses to connect to internal addresses,
+// which are checked after DNS resolution to prevent rebinding.
+func NewChecker(timeout time.Duration, allowPrivate bool) *Checker {
+	return &Checker{
+		client: &http.Client{
+			Transport: guardedTransport(timeout, allowPrivate),
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// CheckReachable requests url with HEAD, or GET if HEAD isn't allowed,
+// and returns ErrUnreachable unless it responds with 2xx or 3xx.
+func (c *Checker) CheckReachable(ctx context.Context, url string) error {
+	const op = "api.CheckReachable"
+
+	status, err := c.status(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.status(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return fmt.Errorf("%s: %w", op, ErrForbiddenAddress)
+		}
+
+		return fmt.Errorf("%s: %w: %v", op, ErrUnreachable, err)
+	}
+
+	if status < 200 || status >= 400 {
+		return fmt.Errorf("%s: %w: status %d", op, ErrUnreachable, status)
+	}
+
+	return nil
+}
+
+func (c *Checker) status(ctx context.Context, method string, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+		_ = resp.Body.Close()
+	}()
+
+	return resp.StatusCode, nil
+}
+
+// guardedTransport returns a transport which refuses to connect to internal
+// addresses unless allowPrivate is set. Proxies are not used, since they
+// would bypass the check.
+func guardedTransport(timeout time.Duration, allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = guardAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return transport
+}
+
+// guardAddress is a net.Dialer Control rejecting internal addresses.
+func guardAddress(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package request
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/render"
+)
+
+// MaxDecompressedSize caps the size of a gzip-encoded body after
+// decompression, so that a small compressed body can't exhaust memory.
+const MaxDecompressedSize = 10 << 20
+
+var ErrTooLarge = errors.New("decompressed request body is too large")
+
+// DecodeJSON decodes the body of r into v. Bodies sent with
+// "Content-Encoding: gzip" are decompressed first, up to MaxDecompressedSize.
+// An empty body yields an error wrapping io.EOF.
+func DecodeJSON(r *http.Request, v any) error {
+	const op = "request.DecodeJSON"
+
+	var body io.Reader = r.Body
+
+	if isGzip(r.Header.Get("Content-Encoding")) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		defer gz.Close()
+
+		body = &limitedReader{r: gz, n: MaxDecompressedSize}
+	}
+
+	if err := render.DecodeJSON(body, v); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ErrorMessage describes a DecodeJSON error for API clients: an empty
+// body, malformed JSON with its offset, or a field of the wrong type.
+func ErrorMessage(err error) string {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return "request body is empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "malformed JSON: unexpected end of input"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return fmt.Sprintf("field %q must be %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return fmt.Sprintf("request body must be %s, got %s", typeErr.Type, typeErr.Value)
+	default:
+		return "failed to decode request"
+	}
+}
+
+func isGzip(encoding string) bool {
+	return strings.EqualFold(strings.TrimSpace(encoding), "gzip")
+}
+
+// limitedReader is like io.LimitedReader, but fails with ErrTooLarge
+// instead of stopping silently when more than n bytes are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+
+	return n, err
+}
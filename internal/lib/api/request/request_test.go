@@ -0,0 +1,142 @@
+package request_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/api/request"
+)
+
+type payload struct {
+	URL string `json:"url"`
+}
+
+func gzipped(t *testing.T, data []byte) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	return &buf
+}
+
+func TestDecodeJSON(t *testing.T) {
+	cases := []struct {
+		name     string
+		encoding string
+		body     io.Reader
+		wantURL  string
+		wantErr  error
+	}{
+		{
+			name:    "Plain",
+			body:    strings.NewReader(`{"url":"https://example.com"}`),
+			wantURL: "https://example.com",
+		},
+		{
+			name:     "Gzip",
+			encoding: "gzip",
+			body:     gzipped(t, []byte(`{"url":"https://example.com"}`)),
+			wantURL:  "https://example.com",
+		},
+		{
+			name:     "GzipCase",
+			encoding: " GZIP ",
+			body:     gzipped(t, []byte(`{"url":"https://example.com"}`)),
+			wantURL:  "https://example.com",
+		},
+		{
+			name:    "Empty",
+			body:    strings.NewReader(""),
+			wantErr: io.EOF,
+		},
+		{
+			name:     "GzipEmpty",
+			encoding: "gzip",
+			body:     strings.NewReader(""),
+			wantErr:  io.EOF,
+		},
+		{
+			name:     "Bomb",
+			encoding: "gzip",
+			// a valid json string that decompresses past the cap
+			body:    gzipped(t, []byte(`{"url":"`+strings.Repeat("a", request.MaxDecompressedSize)+`"}`)),
+			wantErr: request.ErrTooLarge,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/", tc.body)
+			require.NoError(t, err)
+			if tc.encoding != "" {
+				req.Header.Set("Content-Encoding", tc.encoding)
+			}
+
+			var p payload
+
+			err = request.DecodeJSON(req, &p)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantURL, p.URL)
+		})
+	}
+}
+
+func TestDecodeJSON_InvalidGzip(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"url":"https://example.com"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	var p payload
+
+	assert.Error(t, request.DecodeJSON(req, &p))
+}
+
+func TestErrorMessage(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "Empty", body: "", want: "request body is empty"},
+		{name: "Truncated", body: `{"url":`, want: "malformed JSON: unexpected end of input"},
+		{name: "Syntax", body: `{"url":}`, want: "malformed JSON at offset 8"},
+		{name: "Field type", body: `{"url":true}`, want: `field "url" must be string, got bool`},
+		{name: "Body type", body: `[]`, want: "request body must be request_test.payload, got array"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			var p payload
+
+			err = request.DecodeJSON(req, &p)
+			require.Error(t, err)
+
+			assert.Equal(t, tc.want, request.ErrorMessage(err))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// Resolver follows redirect chains to the final URL.
+type Resolver struct {
+	client *http.Client
+	hop    *http.Client
+}
+
+// NewResolver returns a Resolver which follows at most maxRedirects
+// redirects and gives up after timeout. Internal addresses are refused
+// on every hop unless allowPrivate is set, see NewChecker.
+func NewResolver(timeout time.Duration, maxRedirects int, allowPrivate bool) *Resolver {
+	transport := guardedTransport(timeout, allowPrivate)
+
+	return &Resolver{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return ErrTooManyRedirects
+				}
+
+				return nil
+			},
+		},
+		hop: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Redirect is like GetRedirectContext, but internal addresses are
+// refused unless the resolver allows them.
+func (r *Resolver) Redirect(ctx context.Context, url string) (string, error) {
+	const op = "api.Resolver.Redirect"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := r.hop.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) {
+			return "", fmt.Errorf("%s: %w", op, ErrForbiddenAddress)
+		}
+
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", fmt.Errorf("%s: %w: %d", op, ErrInvalidStatusCode, resp.StatusCode)
+	}
+
+	return resp.Header.Get("Location"), nil
+}
+
+// ResolveFinal requests url with HEAD, or GET if HEAD isn't allowed,
+// following redirects, and returns the URL of the final response.
+// It returns ErrUnreachable unless the final response is 2xx.
+func (r *Resolver) ResolveFinal(ctx context.Context, url string) (string, error) {
+	const op = "api.ResolveFinal"
+
+	final, status, err := r.final(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		final, status, err = r.final(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrForbiddenAddress):
+			return "", fmt.Errorf("%s: %w", op, ErrForbiddenAddress)
+		case errors.Is(err, ErrTooManyRedirects):
+			return "", fmt.Errorf("%s: %w", op, ErrTooManyRedirects)
+		}
+
+		return "", fmt.Errorf("%s: %w: %v", op, ErrUnreachable, err)
+	}
+
+	if status < 200 || status >= 300 {
+		return "", fmt.Errorf("%s: %w: status %d", op, ErrUnreachable, status)
+	}
+
+	return final, nil
+}
+
+func (r *Resolver) final(ctx context.Context, method string, url string) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", 0, err
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+		_ = resp.Body.Close()
+	}()
+
+	return resp.Request.URL.String(), resp.StatusCode, nil
+}
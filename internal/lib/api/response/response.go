@@ -2,6 +2,7 @@ package response
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -10,6 +11,8 @@ import (
 type Response struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
+	// Code is a machine-readable error code.
+	Code string `json:"code,omitempty"`
 }
 
 const (
@@ -17,6 +20,29 @@ const (
 	StatusError = "Error"
 )
 
+// Error codes.
+const (
+	CodeInvalidRequest  = "INVALID_REQUEST"
+	CodeNotFound        = "NOT_FOUND"
+	CodeNotYetActive    = "NOT_YET_ACTIVE"
+	CodeInternal        = "INTERNAL"
+	CodeUnauthorized    = "UNAUTHORIZED"
+	CodeGone            = "GONE"
+	CodeForbidden       = "FORBIDDEN"
+	CodeAliasExists     = "ALIAS_EXISTS"
+	CodeUnavailable     = "UNAVAILABLE"
+	CodeQuotaExceeded   = "QUOTA_EXCEEDED"
+	CodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	CodeConflict        = "CONFLICT"
+)
+
+// NoStore forbids caching of the response. It is set on errors,
+// so that intermediaries don't cache a transient failure,
+// and on responses to mutations.
+func NoStore(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", "no-store")
+}
+
 func OK() Response {
 	return Response{
 		Status: StatusOK,
@@ -30,6 +56,14 @@ func Error(msg string) Response {
 	}
 }
 
+func ErrorWithCode(code string, msg string) Response {
+	return Response{
+		Status: StatusError,
+		Error:  msg,
+		Code:   code,
+	}
+}
+
 func ValidationError(errs validator.ValidationErrors) Response {
 	var errMsgs []string
 
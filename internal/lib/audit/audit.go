@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+const (
+	ActionSave   = "save"
+	ActionDelete = "delete"
+	ActionUpdate = "update"
+)
+
+const (
+	ResultSuccess = "success"
+
+	anonymousActor = "anonymous"
+)
+
+const bufferSize = 1024
+
+// Store persists audit entries.
+type Store interface {
+	AuditLog(entry storage.AuditEntry) error
+}
+
+// Logger writes audit entries in the background, so that a slow or
+// failing store never blocks the request. Entries are dropped with
+// a warning when the buffer is full.
+type Logger struct {
+	log     *slog.Logger
+	store   Store
+	clock   clock.Clock
+	entries chan storage.AuditEntry
+	wg      sync.WaitGroup
+}
+
+func New(log *slog.Logger, store Store, clock clock.Clock) *Logger {
+	l := &Logger{
+		log:     log.With(slog.String("component", "audit")),
+		store:   store,
+		clock:   clock,
+		entries: make(chan storage.AuditEntry, bufferSize),
+	}
+
+	l.wg.Add(1)
+	go l.run()
+
+	return l
+}
+
+// Record queues entry for writing. CreatedAt is set if it is empty.
+func (l *Logger) Record(entry storage.AuditEntry) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = l.clock.Now().UTC()
+	}
+
+	select {
+	case l.entries <- entry:
+	default:
+		l.log.Warn("audit buffer is full, entry dropped",
+			slog.String("actor", entry.Actor),
+			slog.String("action", entry.Action),
+			slog.String("alias", entry.Alias),
+		)
+	}
+}
+
+// Close flushes queued entries. Record must not be called after Close.
+func (l *Logger) Close() {
+	close(l.entries)
+	l.wg.Wait()
+}
+
+func (l *Logger) run() {
+	defer l.wg.Done()
+
+	for entry := range l.entries {
+		l.log.Info("audit",
+			slog.String("actor", entry.Actor),
+			slog.String("action", entry.Action),
+			slog.String("alias", entry.Alias),
+			slog.String("result", entry.Result),
+		)
+
+		if err := l.store.AuditLog(entry); err != nil {
+			l.log.Error("failed to write audit entry", sl.Err(err))
+		}
+	}
+}
+
+// Actor returns the authenticated user of the request.
+func Actor(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		return user
+	}
+
+	return anonymousActor
+}
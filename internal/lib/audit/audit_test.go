@@ -0,0 +1,52 @@
+package audit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/audit"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	entries []storage.AuditEntry
+}
+
+func (s *fakeStore) AuditLog(entry storage.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+
+	return nil
+}
+
+func TestLogger_Record(t *testing.T) {
+	store := &fakeStore{}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := audit.New(slogdiscard.NewDiscardLogger(), store, clock.NewFake(now))
+
+	l.Record(storage.AuditEntry{
+		Actor:  "admin",
+		Action: audit.ActionSave,
+		Alias:  "test_alias",
+		Result: audit.ResultSuccess,
+	})
+	l.Close()
+
+	require.Len(t, store.entries, 1)
+
+	entry := store.entries[0]
+	assert.Equal(t, "admin", entry.Actor)
+	assert.Equal(t, audit.ActionSave, entry.Action)
+	assert.Equal(t, "test_alias", entry.Alias)
+	assert.Equal(t, audit.ResultSuccess, entry.Result)
+	assert.Equal(t, now, entry.CreatedAt)
+}
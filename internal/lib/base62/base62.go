@@ -0,0 +1,60 @@
+package base62
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// Alphabet are the digits of the encoding, in order of value.
+const Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = int64(len(Alphabet))
+
+var (
+	ErrInvalid  = errors.New("invalid base62 string")
+	ErrOverflow = errors.New("base62 value overflows int64")
+)
+
+// Encode returns the shortest base62 representation of n, which must
+// not be negative.
+func Encode(n int64) string {
+	if n == 0 {
+		return Alphabet[:1]
+	}
+
+	var buf [11]byte // enough for math.MaxInt64
+
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = Alphabet[n%base]
+		n /= base
+	}
+
+	return string(buf[i:])
+}
+
+// Decode returns the value of s encoded with Encode.
+func Decode(s string) (int64, error) {
+	if s == "" {
+		return 0, ErrInvalid
+	}
+
+	var n int64
+
+	for i := 0; i < len(s); i++ {
+		d := strings.IndexByte(Alphabet, s[i])
+		if d < 0 {
+			return 0, ErrInvalid
+		}
+
+		if n > (math.MaxInt64-int64(d))/base {
+			return 0, ErrOverflow
+		}
+
+		n = n*base + int64(d)
+	}
+
+	return n, nil
+}
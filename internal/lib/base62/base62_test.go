@@ -0,0 +1,36 @@
+package base62_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/base62"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	for _, n := range []int64{0, 1, 61, 62, 3843, 3844, 1 << 40, math.MaxInt64} {
+		s := base62.Encode(n)
+
+		got, err := base62.Decode(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, n, got, s)
+	}
+
+	assert.Equal(t, "0", base62.Encode(0))
+	assert.Equal(t, "z", base62.Encode(61))
+	assert.Equal(t, "10", base62.Encode(62))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	_, err := base62.Decode("")
+	assert.ErrorIs(t, err, base62.ErrInvalid)
+
+	_, err = base62.Decode("ab-c")
+	assert.ErrorIs(t, err, base62.ErrInvalid)
+
+	_, err = base62.Decode(base62.Encode(math.MaxInt64) + "0")
+	assert.ErrorIs(t, err, base62.ErrOverflow)
+}
@@ -0,0 +1,52 @@
+// Package checksum appends and verifies a Luhn mod 62 check character,
+// which detects any single mistyped character and most transpositions
+// of adjacent characters in aliases.
+package checksum
+
+import (
+	"strings"
+
+	"url-shortener/internal/lib/random"
+)
+
+// alphabet is the base62 alphabet of check characters. Other runes of
+// an alias, e.g. "-" of wordlist aliases, are not checked.
+const alphabet = random.DefaultCharset
+
+const base = len(alphabet)
+
+// Append returns alias with its check character.
+func Append(alias string) string {
+	sum := sum(alias, 2)
+
+	return alias + string(alphabet[(base-sum%base)%base])
+}
+
+// Valid reports whether the last character of alias is its check character.
+func Valid(alias string) bool {
+	if alias == "" || strings.IndexByte(alphabet, alias[len(alias)-1]) < 0 {
+		return false
+	}
+
+	return sum(alias, 1)%base == 0
+}
+
+// sum is the Luhn mod N sum of alias, doubling every other character
+// starting from the rightmost one if factor is 2, or the next one if 1.
+func sum(alias string, factor int) int {
+	sum := 0
+
+	for i := len(alias) - 1; i >= 0; i-- {
+		code := strings.IndexByte(alphabet, alias[i])
+		if code < 0 {
+			continue
+		}
+
+		addend := factor * code
+		sum += addend/base + addend%base
+
+		factor = 3 - factor
+	}
+
+	return sum
+}
@@ -0,0 +1,43 @@
+package checksum_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/checksum"
+	"url-shortener/internal/lib/random"
+)
+
+func TestAppend(t *testing.T) {
+	for _, alias := range []string{"a", "abc123", "ZZZZZZ", "brave-otter-42", "0"} {
+		withSum := checksum.Append(alias)
+
+		assert.Len(t, withSum, len(alias)+1, alias)
+		assert.Equal(t, alias, withSum[:len(alias)], alias)
+		assert.True(t, checksum.Valid(withSum), withSum)
+	}
+
+	assert.Equal(t, checksum.Append("abc123"), checksum.Append("abc123"))
+}
+
+func TestValid_SingleCharacterTypo(t *testing.T) {
+	alias := checksum.Append("Xy7kQ2")
+
+	// every substitution of every character is detected
+	for i := 0; i < len(alias); i++ {
+		for _, c := range []byte(random.DefaultCharset) {
+			if c == alias[i] {
+				continue
+			}
+
+			typo := alias[:i] + string(c) + alias[i+1:]
+			assert.False(t, checksum.Valid(typo), typo)
+		}
+	}
+}
+
+func TestValid_Invalid(t *testing.T) {
+	assert.False(t, checksum.Valid(""))
+	assert.False(t, checksum.Valid("abc-"))
+}
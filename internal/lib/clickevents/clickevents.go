@@ -0,0 +1,84 @@
+package clickevents
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/sl"
+)
+
+const bufferSize = 1024
+
+// Event is a single sampled redirect.
+type Event struct {
+	Alias     string    `json:"alias"`
+	Time      time.Time `json:"time"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+// Sink receives sampled events.
+type Sink interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// Sampler sends a share of clicks to a sink in the background, so that
+// redirects never wait for it. Events are dropped when the buffer is full.
+type Sampler struct {
+	log    *slog.Logger
+	sink   Sink
+	rate   float64
+	clock  clock.Clock
+	events chan Event
+}
+
+// New returns a sampler emitting clicks with probability rate, from 0 to 1.
+// Events are sent only while Run is running.
+func New(log *slog.Logger, sink Sink, rate float64, clock clock.Clock) *Sampler {
+	return &Sampler{
+		log:    log.With(slog.String("component", "click events")),
+		sink:   sink,
+		rate:   rate,
+		clock:  clock,
+		events: make(chan Event, bufferSize),
+	}
+}
+
+// Sample queues an event of the redirect of alias if it is sampled.
+func (s *Sampler) Sample(r *http.Request, alias string) {
+	if s.rate <= 0 || (s.rate < 1 && rand.Float64() >= s.rate) {
+		return
+	}
+
+	event := Event{
+		Alias:     alias,
+		Time:      s.clock.Now().UTC(),
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+	}
+
+	select {
+	case s.events <- event:
+	default:
+		s.log.Warn("click events buffer is full, event dropped", slog.String("alias", alias))
+	}
+}
+
+// Run sends queued events to the sink until ctx is done.
+func (s *Sampler) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			if err := s.sink.Emit(ctx, event); err != nil {
+				s.log.Error("failed to emit click event", sl.Err(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package clickevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/clickevents"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+)
+
+type chanSink chan clickevents.Event
+
+func (s chanSink) Emit(_ context.Context, event clickevents.Event) error {
+	s <- event
+
+	return nil
+}
+
+func newRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	r.Header.Set("Referer", "https://ref.example.com/page")
+	r.Header.Set("User-Agent", "test-agent/1.0")
+
+	return r
+}
+
+var testNow = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// sample runs n redirects of alias through a sampler with rate
+// and returns the emitted events, timed at testNow.
+func sample(t *testing.T, rate float64, n int) []clickevents.Event {
+	t.Helper()
+
+	sink := make(chanSink, n)
+	s := clickevents.New(slogdiscard.NewDiscardLogger(), sink, rate, clock.NewFake(testNow))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	for i := 0; i < n; i++ {
+		s.Sample(newRequest(), "abc")
+	}
+
+	// wait for the queue to drain
+	var events []clickevents.Event
+drain:
+	for {
+		select {
+		case event := <-sink:
+			events = append(events, event)
+		case <-time.After(100 * time.Millisecond):
+			break drain
+		}
+	}
+
+	cancel()
+	<-done
+
+	return events
+}
+
+func TestSampler_Rate(t *testing.T) {
+	const n = 1000
+
+	assert.Len(t, sample(t, 1, n), n)
+	assert.Empty(t, sample(t, 0, n))
+
+	// 10% of 1000 with a margin of about 4 standard deviations
+	got := len(sample(t, 0.1, n))
+	assert.InDelta(t, 100, got, 40)
+}
+
+func TestSampler_Fields(t *testing.T) {
+	events := sample(t, 1, 1)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	assert.Equal(t, "abc", event.Alias)
+	assert.Equal(t, "https://ref.example.com/page", event.Referrer)
+	assert.Equal(t, "test-agent/1.0", event.UserAgent)
+	assert.Equal(t, testNow, event.Time)
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clicks.jsonl")
+
+	sink, err := clickevents.NewFileSink(path)
+	require.NoError(t, err)
+
+	event := clickevents.Event{Alias: "abc", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	require.NoError(t, sink.Emit(context.Background(), event))
+	require.NoError(t, sink.Emit(context.Background(), event))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t,
+		`{"alias":"abc","time":"2024-01-02T03:04:05Z"}`+"\n"+`{"alias":"abc","time":"2024-01-02T03:04:05Z"}`+"\n",
+		string(data),
+	)
+}
+
+func TestWebhookSink(t *testing.T) {
+	received := make(chan clickevents.Event, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event clickevents.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil && r.Method == http.MethodPost {
+			received <- event
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := clickevents.NewWebhookSink(srv.URL, time.Second)
+
+	event := clickevents.Event{Alias: "abc", Time: time.Now().UTC(), UserAgent: "test-agent/1.0"}
+	require.NoError(t, sink.Emit(context.Background(), event))
+	assert.Equal(t, event.Alias, (<-received).Alias)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	assert.Error(t, clickevents.NewWebhookSink(failing.URL, time.Second).Emit(context.Background(), event))
+}
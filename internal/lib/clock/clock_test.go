@@ -0,0 +1,46 @@
+package clock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/clock"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	assert.Equal(t, start, clk.Now())
+
+	clk.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), clk.Now())
+
+	clk.Set(start)
+	assert.Equal(t, start, clk.Now())
+}
+
+func TestFake_Sleep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	require.NoError(t, clk.Sleep(context.Background(), time.Second))
+	assert.Equal(t, start.Add(time.Second), clk.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, clk.Sleep(ctx, time.Second), context.Canceled)
+	assert.Equal(t, start.Add(time.Second), clk.Now())
+}
+
+func TestReal_SleepCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, clock.Real{}.Sleep(ctx, time.Hour), context.Canceled)
+}
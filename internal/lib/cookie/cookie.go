@@ -0,0 +1,40 @@
+package cookie
+
+import "net/http"
+
+// Policy is the set of attributes enforced on cookies, so that session
+// and auth cookies are never sent over plain http or read by scripts.
+type Policy struct {
+	// Secure restricts cookies to https. Disable it only for local
+	// development over plain http.
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// NewPolicy returns a policy making cookies HttpOnly and SameSite=Strict,
+// and Secure unless secure is false.
+func NewPolicy(secure bool) Policy {
+	return Policy{
+		Secure:   secure,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// Apply sets the attributes of p on c. Cookies without a path get "/",
+// so they are not scoped to the path of the request setting them.
+func (p Policy) Apply(c *http.Cookie) {
+	c.HttpOnly = true
+	c.Secure = p.Secure
+	c.SameSite = p.SameSite
+
+	if c.Path == "" {
+		c.Path = "/"
+	}
+}
+
+// Set applies p to c and adds it to the response.
+func (p Policy) Set(w http.ResponseWriter, c *http.Cookie) {
+	p.Apply(c)
+
+	http.SetCookie(w, c)
+}
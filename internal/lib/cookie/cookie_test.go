@@ -0,0 +1,41 @@
+package cookie_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/cookie"
+)
+
+func TestPolicy_Set(t *testing.T) {
+	cases := []struct {
+		name   string
+		secure bool
+	}{
+		{name: "Secure", secure: true},
+		{name: "Dev", secure: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			cookie.NewPolicy(tc.secure).Set(rr, &http.Cookie{Name: "session", Value: "token"})
+
+			cookies := rr.Result().Cookies()
+			require.Len(t, cookies, 1)
+
+			c := cookies[0]
+			assert.Equal(t, "token", c.Value)
+			assert.Equal(t, tc.secure, c.Secure)
+			assert.True(t, c.HttpOnly)
+			assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+			assert.Equal(t, "/", c.Path)
+		})
+	}
+}
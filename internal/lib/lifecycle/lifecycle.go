@@ -0,0 +1,90 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Manager runs long-lived background workers with a shared context
+// and stops them together on shutdown.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: make(map[string]int),
+	}
+}
+
+// Go runs fn in a new goroutine. fn must return soon after ctx is done.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name]++
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+		defer m.finished(name)
+
+		fn(m.ctx)
+	}()
+}
+
+func (m *Manager) finished(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running[name]--
+	if m.running[name] == 0 {
+		delete(m.running, name)
+	}
+}
+
+// Shutdown cancels the context of the workers and waits for them to
+// return until ctx is done. The error names the workers still running.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	const op = "lifecycle.Manager.Shutdown"
+
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: workers still running: %s: %w", op, m.runningNames(), ctx.Err())
+	}
+}
+
+func (m *Manager) runningNames() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,59 @@
+package lifecycle_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/lifecycle"
+)
+
+func TestManager_Shutdown(t *testing.T) {
+	m := lifecycle.New()
+
+	var cancelled, drained atomic.Bool
+
+	started := make(chan struct{})
+	m.Go("worker", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+
+		// cleanup after cancellation must be waited for
+		time.Sleep(50 * time.Millisecond)
+		drained.Store(true)
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, m.Shutdown(ctx))
+	assert.True(t, cancelled.Load())
+	assert.True(t, drained.Load())
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	m := lifecycle.New()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	m.Go("stuck", func(context.Context) {
+		<-release
+	})
+	m.Go("quick", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "workers still running: stuck:")
+}
@@ -3,15 +3,49 @@ package slogpretty
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	stdLog "log"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/go-chi/chi/v5/middleware"
 	"golang.org/x/exp/slog"
 )
 
+// requestIDKey is the attribute the request ID is logged with.
+const requestIDKey = "request_id"
+
+// FieldsFormat is the format of the record fields printed after the message.
+type FieldsFormat string
+
+const (
+	FieldsFormatJSON FieldsFormat = "json"
+	FieldsFormatText FieldsFormat = "text"
+)
+
+func (f FieldsFormat) Validate() error {
+	switch f {
+	case FieldsFormatJSON, FieldsFormatText:
+		return nil
+	default:
+		return fmt.Errorf("unknown fields format %q, expected %q or %q", f, FieldsFormatJSON, FieldsFormatText)
+	}
+}
+
 type PrettyHandlerOptions struct {
 	SlogOpts *slog.HandlerOptions
+	// FieldsFormat defaults to FieldsFormatJSON.
+	FieldsFormat FieldsFormat
+	// SourceRequestID appends the request ID to the source printed
+	// with SlogOpts.AddSource. It is taken from the request_id attribute
+	// or the context of the record.
+	SourceRequestID bool
+	// SourceGoroutine appends the ID of the logging goroutine to the source.
+	SourceGoroutine bool
 }
 
 type PrettyHandler struct {
@@ -24,7 +58,12 @@ type PrettyHandler struct {
 func (opts PrettyHandlerOptions) NewPrettyHandler(
 	out io.Writer,
 ) *PrettyHandler {
+	if opts.FieldsFormat == "" {
+		opts.FieldsFormat = FieldsFormatJSON
+	}
+
 	h := &PrettyHandler{
+		opts:    opts,
 		Handler: slog.NewJSONHandler(out, opts.SlogOpts),
 		l:       stdLog.New(out, "", 0),
 	}
@@ -32,7 +71,7 @@ func (opts PrettyHandlerOptions) NewPrettyHandler(
 	return h
 }
 
-func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
+func (h *PrettyHandler) Handle(ctx context.Context, r slog.Record) error {
 	level := r.Level.String() + ":"
 
 	switch r.Level {
@@ -62,7 +101,7 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	var err error
 
 	if len(fields) > 0 {
-		b, err = json.MarshalIndent(fields, "", "  ")
+		b, err = h.formatFields(fields)
 		if err != nil {
 			return err
 		}
@@ -71,18 +110,108 @@ func (h *PrettyHandler) Handle(_ context.Context, r slog.Record) error {
 	timeStr := r.Time.Format("[15:05:05.000]")
 	msg := color.CyanString(r.Message)
 
-	h.l.Println(
-		timeStr,
-		level,
-		msg,
-		color.WhiteString(string(b)),
-	)
+	args := []interface{}{timeStr, level}
+
+	if h.opts.SlogOpts != nil && h.opts.SlogOpts.AddSource && r.PC != 0 {
+		args = append(args, color.HiBlackString(h.recordFormatSource(ctx, r, fields)))
+	}
+
+	args = append(args, msg, color.WhiteString(string(b)))
+
+	h.l.Println(args...)
 
 	return nil
 }
 
+// recordFormatSource returns file:line:function of the log call
+// followed by the enabled correlation IDs, e.g. "save.go:42:save.New req=abc g=17".
+func (h *PrettyHandler) recordFormatSource(ctx context.Context, r slog.Record, fields map[string]interface{}) string {
+	frames := runtime.CallersFrames([]uintptr{r.PC})
+	frame, _ := frames.Next()
+
+	function := frame.Function
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+
+	file := frame.File
+	if i := strings.LastIndex(file, "/"); i >= 0 {
+		file = file[i+1:]
+	}
+
+	source := fmt.Sprintf("%s:%d:%s", file, frame.Line, function)
+
+	if h.opts.SourceRequestID {
+		if id := requestID(ctx, fields); id != "" {
+			source += " req=" + id
+		}
+	}
+
+	if h.opts.SourceGoroutine {
+		if id := goroutineID(); id != "" {
+			source += " g=" + id
+		}
+	}
+
+	return source
+}
+
+func requestID(ctx context.Context, fields map[string]interface{}) string {
+	if id, ok := fields[requestIDKey].(string); ok && id != "" {
+		return id
+	}
+
+	if ctx != nil {
+		return middleware.GetReqID(ctx)
+	}
+
+	return ""
+}
+
+// goroutineID parses the ID of the current goroutine from its stack header,
+// "goroutine 17 [running]:". It is meant for debugging only.
+func goroutineID() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+
+	header, ok := strings.CutPrefix(string(buf), "goroutine ")
+	if !ok {
+		return ""
+	}
+
+	id, _, _ := strings.Cut(header, " ")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return ""
+	}
+
+	return id
+}
+
+func (h *PrettyHandler) formatFields(fields map[string]interface{}) ([]byte, error) {
+	switch h.opts.FieldsFormat {
+	case FieldsFormatJSON:
+		return json.MarshalIndent(fields, "", "  ")
+	case FieldsFormatText:
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, fields[k]))
+		}
+
+		return []byte(strings.Join(pairs, " ")), nil
+	default:
+		return nil, h.opts.FieldsFormat.Validate()
+	}
+}
+
 func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &PrettyHandler{
+		opts:    h.opts,
 		Handler: h.Handler,
 		l:       h.l,
 		attrs:   attrs,
@@ -92,6 +221,7 @@ func (h *PrettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (h *PrettyHandler) WithGroup(name string) slog.Handler {
 	// TODO: implement
 	return &PrettyHandler{
+		opts:    h.opts,
 		Handler: h.Handler.WithGroup(name),
 		l:       h.l,
 	}
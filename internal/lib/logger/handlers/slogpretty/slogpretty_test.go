@@ -0,0 +1,89 @@
+package slogpretty_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/handlers/slogpretty"
+)
+
+func newLogger(out *bytes.Buffer) *slog.Logger {
+	opts := slogpretty.PrettyHandlerOptions{
+		SlogOpts:        &slog.HandlerOptions{AddSource: true},
+		FieldsFormat:    slogpretty.FieldsFormatText,
+		SourceRequestID: true,
+		SourceGoroutine: true,
+	}
+
+	return slog.New(opts.NewPrettyHandler(out))
+}
+
+func TestPrettyHandler_Source(t *testing.T) {
+	cases := []struct {
+		name   string
+		log    func(log *slog.Logger)
+		wantID string
+	}{
+		{
+			name: "Request ID attribute",
+			log: func(log *slog.Logger) {
+				log.Info("hello", slog.String("request_id", "req-1"))
+			},
+			wantID: "req-1",
+		},
+		{
+			name: "Request ID of the logger",
+			log: func(log *slog.Logger) {
+				log.With(slog.String("request_id", "req-2")).Info("hello")
+			},
+			wantID: "req-2",
+		},
+		{
+			name: "Request ID in context",
+			log: func(log *slog.Logger) {
+				ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-3")
+				log.InfoCtx(ctx, "hello")
+			},
+			wantID: "req-3",
+		},
+		{
+			name: "No request ID",
+			log: func(log *slog.Logger) {
+				log.Info("hello")
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		var out bytes.Buffer
+
+		tc.log(newLogger(&out))
+
+		line := out.String()
+
+		assert.Regexp(t, regexp.MustCompile(`slogpretty_test\.go:\d+:slogpretty_test\.`), line, tc.name)
+		assert.Regexp(t, regexp.MustCompile(` g=\d+ `), line, tc.name)
+
+		if tc.wantID != "" {
+			assert.Contains(t, line, " req="+tc.wantID+" ", tc.name)
+		} else {
+			assert.NotContains(t, line, "req=", tc.name)
+		}
+	}
+}
+
+func TestPrettyHandler_SourceDisabled(t *testing.T) {
+	var out bytes.Buffer
+
+	opts := slogpretty.PrettyHandlerOptions{SourceRequestID: true, SourceGoroutine: true}
+	slog.New(opts.NewPrettyHandler(&out)).Info("hello", slog.String("request_id", "req-1"))
+
+	assert.NotContains(t, out.String(), "slogpretty_test.go")
+	assert.NotContains(t, out.String(), "req=")
+}
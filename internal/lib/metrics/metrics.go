@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Label is a dimension of a metric, e.g. the response status.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sink receives measurements. Implementations must be safe for
+// concurrent use and must not block the caller for long.
+type Sink interface {
+	// Count adds value to the counter name.
+	Count(name string, value int64, labels ...Label)
+	// Timing records a duration of name.
+	Timing(name string, d time.Duration, labels ...Label)
+	// Observe records a value of name, e.g. a body size in bytes.
+	Observe(name string, value float64, labels ...Label)
+}
+
+// Nop discards measurements.
+type Nop struct{}
+
+func (Nop) Count(string, int64, ...Label) {}
+
+func (Nop) Timing(string, time.Duration, ...Label) {}
+
+func (Nop) Observe(string, float64, ...Label) {}
+
+// sorted returns a copy of labels ordered by name, so that the same
+// labels passed in any order identify the same series.
+func sorted(labels []Label) []Label {
+	res := make([]Label, len(labels))
+	copy(res, labels)
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+
+	return res
+}
+
+// join returns prefix and name joined with an underscore.
+func join(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
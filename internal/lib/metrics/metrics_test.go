@@ -0,0 +1,85 @@
+package metrics_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/metrics"
+)
+
+func TestStatsD_Packets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := metrics.NewStatsD(conn.LocalAddr().String(), "shortener")
+	require.NoError(t, err)
+	defer sink.Close()
+
+	sink.Count("http_requests_total", 1,
+		metrics.Label{Name: "status", Value: "200"},
+		metrics.Label{Name: "method", Value: "GET"},
+	)
+	sink.Timing("http_request_duration", 1500*time.Microsecond)
+	sink.Observe("http_request_size_bytes", 512)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	buf := make([]byte, 512)
+
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "shortener_http_requests_total:1|c|#method:GET,status:200", string(buf[:n]))
+
+	n, _, err = conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "shortener_http_request_duration:1.5|ms", string(buf[:n]))
+
+	n, _, err = conn.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "shortener_http_request_size_bytes:512|h", string(buf[:n]))
+}
+
+func TestPrometheus_ServeHTTP(t *testing.T) {
+	sink := metrics.NewPrometheus("shortener")
+
+	sink.Count("http_requests_total", 1, metrics.Label{Name: "route", Value: `/{alias}`})
+	sink.Count("http_requests_total", 2, metrics.Label{Name: "route", Value: `/{alias}`})
+	sink.Count("alias_collisions_total", 1)
+	sink.Timing("http_request_duration", 500*time.Millisecond)
+	sink.Timing("http_request_duration", time.Second)
+	sink.Observe("http_request_size_bytes", 100)
+
+	rr := httptest.NewRecorder()
+	sink.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, `# TYPE shortener_alias_collisions_total counter
+shortener_alias_collisions_total 1
+# TYPE shortener_http_requests_total counter
+shortener_http_requests_total{route="/{alias}"} 3
+# TYPE shortener_http_request_duration_seconds summary
+shortener_http_request_duration_seconds_sum 1.5
+shortener_http_request_duration_seconds_count 2
+# TYPE shortener_http_request_size_bytes histogram
+shortener_http_request_size_bytes_bucket{le="64"} 0
+shortener_http_request_size_bytes_bucket{le="256"} 1
+shortener_http_request_size_bytes_bucket{le="1024"} 1
+shortener_http_request_size_bytes_bucket{le="4096"} 1
+shortener_http_request_size_bytes_bucket{le="16384"} 1
+shortener_http_request_size_bytes_bucket{le="65536"} 1
+shortener_http_request_size_bytes_bucket{le="262144"} 1
+shortener_http_request_size_bytes_bucket{le="1048576"} 1
+shortener_http_request_size_bytes_bucket{le="4194304"} 1
+shortener_http_request_size_bytes_bucket{le="+Inf"} 1
+shortener_http_request_size_bytes_sum 100
+shortener_http_request_size_bytes_count 1
+`, rr.Body.String())
+}
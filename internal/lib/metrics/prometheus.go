@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SizeBuckets are the upper bounds of the histogram buckets of
+// observed values, fit for body sizes in bytes: 64 B to 4 MiB.
+var SizeBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+// Prometheus keeps measurements in memory and serves them in the
+// Prometheus text format. Timings, in seconds, are exposed as summaries
+// without quantiles, observed values as histograms of SizeBuckets.
+type Prometheus struct {
+	prefix string
+
+	mu         sync.Mutex
+	counters   map[string]*series
+	summaries  map[string]*series
+	histograms map[string]*series
+}
+
+type series struct {
+	name   string
+	labels []Label
+	value  float64
+	count  int64
+	// buckets are the cumulative counts of SizeBuckets, of histograms only
+	buckets []int64
+}
+
+func NewPrometheus(prefix string) *Prometheus {
+	return &Prometheus{
+		prefix:     prefix,
+		counters:   make(map[string]*series),
+		summaries:  make(map[string]*series),
+		histograms: make(map[string]*series),
+	}
+}
+
+func (p *Prometheus) Count(name string, value int64, labels ...Label) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := lookup(p.counters, join(p.prefix, name), labels)
+	s.value += float64(value)
+}
+
+func (p *Prometheus) Timing(name string, d time.Duration, labels ...Label) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := lookup(p.summaries, join(p.prefix, name+"_seconds"), labels)
+	s.value += d.Seconds()
+	s.count++
+}
+
+func (p *Prometheus) Observe(name string, value float64, labels ...Label) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := lookup(p.histograms, join(p.prefix, name), labels)
+	if s.buckets == nil {
+		s.buckets = make([]int64, len(SizeBuckets))
+	}
+
+	s.value += value
+	s.count++
+
+	for i, le := range SizeBuckets {
+		if value <= le {
+			s.buckets[i]++
+		}
+	}
+}
+
+func lookup(m map[string]*series, name string, labels []Label) *series {
+	labels = sorted(labels)
+	key := name + formatLabels(labels)
+
+	s, ok := m[key]
+	if !ok {
+		s = &series{name: name, labels: labels}
+		m[key] = s
+	}
+
+	return s
+}
+
+// ServeHTTP writes the measurements, e.g. at /metrics.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var b strings.Builder
+
+	p.mu.Lock()
+	writeFamilies(&b, p.counters, "counter", func(b *strings.Builder, s *series) {
+		fmt.Fprintf(b, "%s%s %v\n", s.name, formatLabels(s.labels), s.value)
+	})
+	writeFamilies(&b, p.summaries, "summary", func(b *strings.Builder, s *series) {
+		fmt.Fprintf(b, "%s_sum%s %v\n", s.name, formatLabels(s.labels), s.value)
+		fmt.Fprintf(b, "%s_count%s %d\n", s.name, formatLabels(s.labels), s.count)
+	})
+	writeFamilies(&b, p.histograms, "histogram", func(b *strings.Builder, s *series) {
+		for i, le := range SizeBuckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", s.name, formatLabels(withLE(s.labels, strconv.FormatFloat(le, 'f', -1, 64))), s.buckets[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", s.name, formatLabels(withLE(s.labels, "+Inf")), s.count)
+		fmt.Fprintf(b, "%s_sum%s %v\n", s.name, formatLabels(s.labels), s.value)
+		fmt.Fprintf(b, "%s_count%s %d\n", s.name, formatLabels(s.labels), s.count)
+	})
+	p.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+// writeFamilies writes the series of m grouped by name, in a stable order.
+func writeFamilies(b *strings.Builder, m map[string]*series, typ string, write func(*strings.Builder, *series)) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	family := ""
+	for _, k := range keys {
+		s := m[k]
+
+		if s.name != family {
+			family = s.name
+			fmt.Fprintf(b, "# TYPE %s %s\n", family, typ)
+		}
+
+		write(b, s)
+	}
+}
+
+// withLE returns labels with the bucket bound le of a histogram.
+func withLE(labels []Label, le string) []Label {
+	res := make([]Label, len(labels), len(labels)+1)
+	copy(res, labels)
+
+	return append(res, Label{Name: "le", Value: le})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, l.Name+`="`+labelEscaper.Replace(l.Value)+`"`)
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatsD pushes measurements over UDP in the DogStatsD format, e.g.
+// "prefix_requests:1|c|#status:200". Every measurement is a packet;
+// send errors are dropped, since metrics must not fail requests.
+type StatsD struct {
+	prefix string
+	conn   net.Conn
+}
+
+// NewStatsD returns a sink sending to the UDP address addr, e.g. "127.0.0.1:8125".
+func NewStatsD(addr string, prefix string) (*StatsD, error) {
+	const op = "lib.metrics.NewStatsD"
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &StatsD{prefix: prefix, conn: conn}, nil
+}
+
+func (s *StatsD) Count(name string, value int64, labels ...Label) {
+	s.send(name, strconv.FormatInt(value, 10), "c", labels)
+}
+
+func (s *StatsD) Timing(name string, d time.Duration, labels ...Label) {
+	ms := float64(d) / float64(time.Millisecond)
+
+	s.send(name, strconv.FormatFloat(ms, 'f', -1, 64), "ms", labels)
+}
+
+func (s *StatsD) Observe(name string, value float64, labels ...Label) {
+	s.send(name, strconv.FormatFloat(value, 'f', -1, 64), "h", labels)
+}
+
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsD) send(name string, value string, typ string, labels []Label) {
+	var b strings.Builder
+
+	b.WriteString(join(s.prefix, name))
+	b.WriteByte(':')
+	b.WriteString(value)
+	b.WriteByte('|')
+	b.WriteString(typ)
+
+	for i, l := range sorted(labels) {
+		if i == 0 {
+			b.WriteString("|#")
+		} else {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(l.Name)
+		b.WriteByte(':')
+		b.WriteString(l.Value)
+	}
+
+	_, _ = s.conn.Write([]byte(b.String()))
+}
@@ -0,0 +1,122 @@
+// Package profanity filters generated aliases which spell offensive words.
+package profanity
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Modes of matching aliases against the words.
+const (
+	// ModeSubstring blocks aliases containing a word anywhere, also when
+	// spelled with digits like "sh1t" or split by separators like "fu-ck".
+	// It blocks innocent aliases too (the Scunthorpe problem), which is
+	// cheap for generated aliases as they are simply regenerated.
+	ModeSubstring = "substring"
+	// ModeWord blocks aliases only if one of their words, split on
+	// anything but letters, equals a blocked word.
+	ModeWord = "word"
+)
+
+// leet maps digits and symbols to the letters they are used for.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "@", "a", "$", "s",
+)
+
+// separators are dropped before matching, so "fu-ck" is caught.
+var separators = strings.NewReplacer("-", "", "_", "", ".", "", "~", "")
+
+// Filter matches aliases against a list of words, ignoring case.
+type Filter struct {
+	words map[string]struct{}
+	mode  string
+}
+
+// New returns a Filter of the embedded words along with extra ones.
+func New(mode string, extra ...string) (*Filter, error) {
+	return newFilter(append(parse(defaultWords), extra...), mode)
+}
+
+// Load is like New, but replaces the embedded words with the ones
+// in the file at path, one per line. Empty lines and lines starting
+// with "#" are skipped.
+func Load(path string, mode string, extra ...string) (*Filter, error) {
+	const op = "lib.profanity.Load"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f, err := newFilter(append(parse(string(data)), extra...), mode)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
+
+func newFilter(words []string, mode string) (*Filter, error) {
+	if mode != ModeSubstring && mode != ModeWord {
+		return nil, fmt.Errorf("unknown profanity mode %q", mode)
+	}
+
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			set[w] = struct{}{}
+		}
+	}
+
+	return &Filter{words: set, mode: mode}, nil
+}
+
+// Blocked reports whether alias matches one of the words.
+func (f *Filter) Blocked(alias string) bool {
+	alias = strings.ToLower(alias)
+
+	if f.mode == ModeWord {
+		for _, token := range strings.FieldsFunc(alias, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if _, ok := f.words[token]; ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	variants := []string{alias, leet.Replace(separators.Replace(alias))}
+
+	for w := range f.words {
+		for _, v := range variants {
+			if strings.Contains(v, w) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parse(data string) []string {
+	var words []string
+
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words = append(words, line)
+	}
+
+	return words
+}
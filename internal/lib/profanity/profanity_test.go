@@ -0,0 +1,59 @@
+package profanity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/profanity"
+)
+
+func TestFilter_Blocked(t *testing.T) {
+	substring, err := profanity.New(profanity.ModeSubstring, "frak")
+	require.NoError(t, err)
+
+	word, err := profanity.New(profanity.ModeWord, "frak")
+	require.NoError(t, err)
+
+	cases := []struct {
+		alias         string
+		wantSubstring bool
+		wantWord      bool
+	}{
+		{alias: "brave-otter-42"},
+		{alias: "aB3xQ9"},
+		{alias: "shit", wantSubstring: true, wantWord: true},
+		{alias: "SHIT", wantSubstring: true, wantWord: true},
+		{alias: "brave-shit-42", wantSubstring: true, wantWord: true},
+		{alias: "xShitx", wantSubstring: true},
+		{alias: "sh1t", wantSubstring: true},
+		{alias: "fu-ck", wantSubstring: true},
+		{alias: "scunthorpe", wantSubstring: true},
+		{alias: "frak", wantSubstring: true, wantWord: true},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.wantSubstring, substring.Blocked(tc.alias), "substring: "+tc.alias)
+		assert.Equal(t, tc.wantWord, word.Blocked(tc.alias), "word: "+tc.alias)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# custom\nbadger\n\n"), 0o600))
+
+	f, err := profanity.Load(path, profanity.ModeSubstring)
+	require.NoError(t, err)
+
+	assert.True(t, f.Blocked("honey-badger"))
+	// the embedded words are replaced
+	assert.False(t, f.Blocked("shit"))
+}
+
+func TestNew_UnknownMode(t *testing.T) {
+	_, err := profanity.New("fuzzy")
+	assert.EqualError(t, err, `unknown profanity mode "fuzzy"`)
+}
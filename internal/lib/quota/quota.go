@@ -0,0 +1,19 @@
+package quota
+
+// Limits are the numbers of urls users may own. The storage enforces
+// them when saving, see storage.WithQuota.
+type Limits struct {
+	// Default applies to users without an entry in Users.
+	Default int64
+	// Users are per user limits, zero means unlimited.
+	Users map[string]int64
+}
+
+// For returns the limit of user, zero if it is unlimited.
+func (l Limits) For(user string) int64 {
+	if n, ok := l.Users[user]; ok {
+		return n
+	}
+
+	return l.Default
+}
@@ -0,0 +1,18 @@
+package quota_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/quota"
+)
+
+func TestLimits_For(t *testing.T) {
+	limits := quota.Limits{Default: 5, Users: map[string]int64{"alice": 2, "admin": 0}}
+
+	assert.Equal(t, int64(2), limits.For("alice"))
+	assert.Equal(t, int64(0), limits.For("admin"))
+	assert.Equal(t, int64(5), limits.For("bob"))
+	assert.Equal(t, int64(0), quota.Limits{}.For("bob"))
+}
@@ -1,22 +1,96 @@
 package random
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
 )
 
+// DefaultCharset is the charset of NewRandomString.
+const DefaultCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"0123456789"
+
+// MinCharsetSize is the minimum number of distinct runes of a charset
+// accepted by ValidateCharset.
+const MinCharsetSize = 16
+
+// urlSafePunct are the unreserved ASCII punctuation runes of RFC 3986.
+const urlSafePunct = "-._~"
+
 // NewRandomString generates random string with given size.
 func NewRandomString(size int) string {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return NewRandomStringFromCharset(size, DefaultCharset)
+}
 
-	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
-		"abcdefghijklmnopqrstuvwxyz" +
-		"0123456789")
+// NewRandomStringFromCharset generates random string of size runes of charset.
+// The runes are picked with crypto/rand, so that the strings can't be
+// predicted from each other or from the time they were generated.
+func NewRandomStringFromCharset(size int, charset string) string {
+	chars := []rune(charset)
+	n := big.NewInt(int64(len(chars)))
 
 	b := make([]rune, size)
 	for i := range b {
-		b[i] = chars[rnd.Intn(len(chars))]
+		j, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			// the system random source is unavailable, nothing to fall back to
+			panic(fmt.Sprintf("random: %v", err))
+		}
+
+		b[i] = chars[j.Int64()]
 	}
 
 	return string(b)
 }
+
+// Namespace returns the number of distinct strings of size runes of charset.
+func Namespace(size int, charset string) *big.Int {
+	n := big.NewInt(int64(utf8.RuneCountInString(charset)))
+
+	return n.Exp(n, big.NewInt(int64(size)), nil)
+}
+
+// Entropy returns the bits of entropy of a random string of size runes
+// of charset, size * log2(charset size).
+func Entropy(size int, charset string) float64 {
+	return float64(size) * math.Log2(float64(utf8.RuneCountInString(charset)))
+}
+
+// ValidateCharset checks that charset is valid UTF-8 of at least
+// MinCharsetSize distinct runes, each of them an ASCII letter or digit
+// or one of "-._~". Other runes are rejected as they get mangled in URLs
+// or spoofed, e.g. Cyrillic "а" for Latin "a".
+func ValidateCharset(charset string) error {
+	if !utf8.ValidString(charset) {
+		return fmt.Errorf("charset is not valid UTF-8")
+	}
+
+	seen := make(map[rune]struct{}, len(charset))
+
+	for _, c := range charset {
+		if !urlSafe(c) {
+			return fmt.Errorf("charset rune %q is not URL-safe", c)
+		}
+
+		if _, ok := seen[c]; ok {
+			return fmt.Errorf("charset rune %q is repeated", c)
+		}
+
+		seen[c] = struct{}{}
+	}
+
+	if len(seen) < MinCharsetSize {
+		return fmt.Errorf("charset has %d runes, at least %d required", len(seen), MinCharsetSize)
+	}
+
+	return nil
+}
+
+func urlSafe(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.ContainsRune(urlSafePunct, c)
+}
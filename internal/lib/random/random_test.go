@@ -2,6 +2,7 @@ package random
 
 import (
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -47,3 +48,110 @@ func TestNewRandomString(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRandomStringFromCharset(t *testing.T) {
+	charset := "abcdefghijklmnopqrstuvwxyz-._~"
+
+	str := NewRandomStringFromCharset(20, charset)
+
+	assert.Equal(t, 20, utf8.RuneCountInString(str))
+	for _, c := range str {
+		assert.Contains(t, charset, string(c))
+	}
+}
+
+func TestValidateCharset(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset string
+		wantErr string
+	}{
+		{
+			name:    "default",
+			charset: DefaultCharset,
+		},
+		{
+			name:    "extended",
+			charset: DefaultCharset + "-._~",
+		},
+		{
+			name:    "non-ASCII letter",
+			charset: DefaultCharset + "ä",
+			wantErr: `charset rune 'ä' is not URL-safe`,
+		},
+		{
+			name:    "homoglyph",
+			charset: "0123456789bcdef\u0430",
+			wantErr: `charset rune 'а' is not URL-safe`,
+		},
+		{
+			name:    "unsafe",
+			charset: DefaultCharset + "/",
+			wantErr: `charset rune '/' is not URL-safe`,
+		},
+		{
+			name:    "space",
+			charset: DefaultCharset + " ",
+			wantErr: `charset rune ' ' is not URL-safe`,
+		},
+		{
+			name:    "zero width",
+			charset: DefaultCharset + "\u200b",
+			wantErr: `charset rune '\u200b' is not URL-safe`,
+		},
+		{
+			name:    "combining mark",
+			charset: DefaultCharset + "\u0301",
+			wantErr: "charset rune '\u0301' is not URL-safe",
+		},
+		{
+			name:    "repeated",
+			charset: DefaultCharset + "a",
+			wantErr: `charset rune 'a' is repeated`,
+		},
+		{
+			name:    "too small",
+			charset: "abcdef",
+			wantErr: "charset has 6 runes, at least 16 required",
+		},
+		{
+			name:    "invalid utf-8",
+			charset: DefaultCharset + "\xff",
+			wantErr: "charset is not valid UTF-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCharset(tt.charset)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		charset       string
+		wantBits      float64
+		wantNamespace string
+	}{
+		{name: "default charset", size: 6, charset: DefaultCharset, wantBits: 35.72, wantNamespace: "56800235584"},
+		{name: "hex", size: 8, charset: "0123456789abcdef", wantBits: 32, wantNamespace: "4294967296"},
+		{name: "non-ASCII runes count once", size: 2, charset: "0123456789abcdeä", wantBits: 8, wantNamespace: "256"},
+		{name: "beyond int64", size: 12, charset: DefaultCharset, wantBits: 71.45, wantNamespace: "3226266762397899821056"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.wantBits, Entropy(tt.size, tt.charset), 0.01)
+			assert.Equal(t, tt.wantNamespace, Namespace(tt.size, tt.charset).String())
+		})
+	}
+}
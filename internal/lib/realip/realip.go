@@ -0,0 +1,104 @@
+// Package realip determines the client IP of requests coming
+// through trusted reverse proxies.
+package realip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver returns the client IP of requests. The zero value trusts
+// no proxies and always uses the remote address.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New returns a Resolver trusting X-Forwarded-For set by proxies
+// from the given CIDRs or IPs, e.g. "10.0.0.0/8".
+func New(trustedProxies []string) (*Resolver, error) {
+	const op = "lib.realip.New"
+
+	r := &Resolver{}
+
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+
+	return r, nil
+}
+
+// ClientIP returns the remote IP of req or, if it is a trusted proxy,
+// the rightmost untrusted IP of X-Forwarded-For.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	if !r.isTrusted(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+
+		if !r.isTrusted(hop) {
+			return hop
+		}
+
+		host = hop
+	}
+
+	return host
+}
+
+// FromTrustedProxy reports whether req came from a trusted proxy, so
+// that the headers it set may be relied on.
+func (r *Resolver) FromTrustedProxy(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	return r.isTrusted(host)
+}
+
+func (r *Resolver) isTrusted(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
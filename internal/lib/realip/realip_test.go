@@ -0,0 +1,57 @@
+package realip_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/realip"
+)
+
+func TestResolver_ClientIP(t *testing.T) {
+	r, err := realip.New([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantClientIP string
+	}{
+		{name: "Direct", remoteAddr: "1.2.3.4:5678", wantClientIP: "1.2.3.4"},
+		{name: "Untrusted proxy ignored", remoteAddr: "1.2.3.4:5678", forwardedFor: "5.6.7.8", wantClientIP: "1.2.3.4"},
+		{name: "Trusted proxy", remoteAddr: "10.1.2.3:5678", forwardedFor: "5.6.7.8", wantClientIP: "5.6.7.8"},
+		{name: "Proxy chain", remoteAddr: "10.1.2.3:5678", forwardedFor: "9.9.9.9, 5.6.7.8, 192.168.1.1", wantClientIP: "5.6.7.8"},
+		{name: "Trusted proxy without header", remoteAddr: "10.1.2.3:5678", wantClientIP: "10.1.2.3"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remoteAddr
+		if tc.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+		}
+
+		assert.Equal(t, tc.wantClientIP, r.ClientIP(req), tc.name)
+	}
+}
+
+func TestResolver_FromTrustedProxy(t *testing.T) {
+	r, err := realip.New([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	req.RemoteAddr = "10.1.2.3:5678"
+	assert.True(t, r.FromTrustedProxy(req))
+
+	req.RemoteAddr = "1.2.3.4:5678"
+	assert.False(t, r.FromTrustedProxy(req))
+}
+
+func TestNew_InvalidProxy(t *testing.T) {
+	_, err := realip.New([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package redirectrule
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"url-shortener/internal/storage"
+)
+
+// DefaultMax is the default number of redirect rules of a url.
+const DefaultMax = 10
+
+// DefaultCountryHeader is the header Cloudflare sets to the country
+// of the client.
+const DefaultCountryHeader = "CF-IPCountry"
+
+// Devices.
+const (
+	DeviceMobile  = "mobile"
+	DeviceDesktop = "desktop"
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+)
+
+var ErrInvalid = errors.New("invalid redirect rule")
+
+// Device classifies a User-Agent as DeviceIOS, DeviceAndroid,
+// DeviceMobile for other phones and tablets, or DeviceDesktop.
+func Device(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return DeviceIOS
+	case strings.Contains(ua, "android"):
+		return DeviceAndroid
+	case strings.Contains(ua, "mobile"), strings.Contains(ua, "windows phone"), strings.Contains(ua, "blackberry"):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+// Language returns the primary subtag of the language the client
+// prefers most in Accept-Language, e.g. "de" for "de-CH, en;q=0.8".
+func Language(acceptLanguage string) string {
+	best, bestQ := "", 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+		if primary == "" || primary == "*" || q <= bestQ {
+			continue
+		}
+
+		best, bestQ = strings.ToLower(primary), q
+	}
+
+	return best
+}
+
+// Matcher picks the target of a request among redirect rules.
+type Matcher struct {
+	// CountryHeader is the header a CDN sets to the country of the client.
+	CountryHeader string
+}
+
+// Match returns the URL of the first rule r matches.
+func (m Matcher) Match(rules []storage.RedirectRule, r *http.Request) (string, bool) {
+	if len(rules) == 0 {
+		return "", false
+	}
+
+	device := Device(r.UserAgent())
+	language := Language(r.Header.Get("Accept-Language"))
+
+	var country string
+	if m.CountryHeader != "" {
+		country = strings.ToUpper(strings.TrimSpace(r.Header.Get(m.CountryHeader)))
+	}
+
+	for _, rule := range rules {
+		if rule.Device != "" && !deviceMatches(rule.Device, device) {
+			continue
+		}
+		if rule.Language != "" && !strings.EqualFold(rule.Language, language) {
+			continue
+		}
+		if rule.Country != "" && !strings.EqualFold(rule.Country, country) {
+			continue
+		}
+
+		return rule.URL, true
+	}
+
+	return "", false
+}
+
+// Vary lists the request headers Match depends on, for the Vary header.
+func (m Matcher) Vary() []string {
+	headers := []string{"User-Agent", "Accept-Language"}
+	if m.CountryHeader != "" {
+		headers = append(headers, m.CountryHeader)
+	}
+
+	return headers
+}
+
+func deviceMatches(want string, device string) bool {
+	if want == DeviceMobile {
+		return device != DeviceDesktop
+	}
+
+	return want == device
+}
+
+var devices = []string{DeviceMobile, DeviceDesktop, DeviceIOS, DeviceAndroid}
+
+var knownDevices = map[string]bool{
+	DeviceMobile:  true,
+	DeviceDesktop: true,
+	DeviceIOS:     true,
+	DeviceAndroid: true,
+}
+
+// Validate checks that there are at most max rules, each with at least
+// one known condition and an absolute http(s) URL.
+func Validate(rules []storage.RedirectRule, max int) error {
+	if len(rules) > max {
+		return fmt.Errorf("%w: too many rules, max is %d", ErrInvalid, max)
+	}
+
+	for i, rule := range rules {
+		if rule.Device == "" && rule.Language == "" && rule.Country == "" {
+			return fmt.Errorf("%w %d: device, language or country is required", ErrInvalid, i)
+		}
+
+		if rule.Device != "" && !knownDevices[rule.Device] {
+			return fmt.Errorf("%w %d: unknown device %q, expected one of %s", ErrInvalid, i, rule.Device, strings.Join(devices, ", "))
+		}
+
+		if rule.Country != "" && len(rule.Country) != 2 {
+			return fmt.Errorf("%w %d: country must be a two-letter code, got %q", ErrInvalid, i, rule.Country)
+		}
+
+		u, err := url.Parse(rule.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w %d: url must be an absolute http(s) URL", ErrInvalid, i)
+		}
+	}
+
+	return nil
+}
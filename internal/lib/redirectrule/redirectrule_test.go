@@ -0,0 +1,106 @@
+package redirectrule_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/redirectrule"
+	"url-shortener/internal/storage"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	desktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+)
+
+func TestDevice(t *testing.T) {
+	assert.Equal(t, redirectrule.DeviceIOS, redirectrule.Device(iPhoneUA))
+	assert.Equal(t, redirectrule.DeviceAndroid, redirectrule.Device(androidUA))
+	assert.Equal(t, redirectrule.DeviceDesktop, redirectrule.Device(desktopUA))
+	assert.Equal(t, redirectrule.DeviceMobile, redirectrule.Device("Opera/9.80 (Windows Phone; Opera Mobi)"))
+	assert.Equal(t, redirectrule.DeviceDesktop, redirectrule.Device(""))
+}
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                      "",
+		"de-CH, en;q=0.8":       "de",
+		"en;q=0.5, FR;q=0.9":    "fr",
+		"*, es;q=0.7":           "es",
+		"en;q=bad, it":          "it",
+		"pt-BR;q=0.9, pt;q=0.9": "pt",
+		"ja;q=0, ko;q=0.1":      "ko",
+	}
+
+	for header, want := range cases {
+		assert.Equal(t, want, redirectrule.Language(header), header)
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	rules := []storage.RedirectRule{
+		{Device: redirectrule.DeviceIOS, URL: "https://apps.apple.com/app/id1"},
+		{Device: redirectrule.DeviceMobile, URL: "https://play.google.com/store/apps/details?id=app"},
+		{Language: "de", Country: "AT", URL: "https://example.at"},
+		{Language: "de", URL: "https://example.de"},
+	}
+
+	cases := []struct {
+		name      string
+		userAgent string
+		language  string
+		country   string
+		wantURL   string
+	}{
+		{name: "iOS", userAgent: iPhoneUA, wantURL: "https://apps.apple.com/app/id1"},
+		{name: "Android", userAgent: androidUA, wantURL: "https://play.google.com/store/apps/details?id=app"},
+		{name: "Desktop falls back", userAgent: desktopUA},
+		{name: "Language", userAgent: desktopUA, language: "de-DE", wantURL: "https://example.de"},
+		{name: "Language and country", userAgent: desktopUA, language: "de", country: "at", wantURL: "https://example.at"},
+		{name: "Other language falls back", userAgent: desktopUA, language: "fr"},
+	}
+
+	m := redirectrule.Matcher{CountryHeader: redirectrule.DefaultCountryHeader}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			r.Header.Set("User-Agent", tc.userAgent)
+			if tc.language != "" {
+				r.Header.Set("Accept-Language", tc.language)
+			}
+			if tc.country != "" {
+				r.Header.Set(redirectrule.DefaultCountryHeader, tc.country)
+			}
+
+			got, ok := m.Match(rules, r)
+
+			assert.Equal(t, tc.wantURL != "", ok)
+			assert.Equal(t, tc.wantURL, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := storage.RedirectRule{Device: redirectrule.DeviceMobile, URL: "https://example.com"}
+
+	assert.NoError(t, redirectrule.Validate([]storage.RedirectRule{valid}, 1))
+	assert.NoError(t, redirectrule.Validate(nil, 1))
+
+	invalid := map[string][]storage.RedirectRule{
+		"too many":       {valid, valid},
+		"no condition":   {{URL: "https://example.com"}},
+		"unknown device": {{Device: "tv", URL: "https://example.com"}},
+		"bad country":    {{Country: "DEU", URL: "https://example.com"}},
+		"relative url":   {{Device: redirectrule.DeviceIOS, URL: "/app"}},
+		"ftp url":        {{Device: redirectrule.DeviceIOS, URL: "ftp://example.com"}},
+	}
+
+	for name, rules := range invalid {
+		assert.ErrorIs(t, redirectrule.Validate(rules, 1), redirectrule.ErrInvalid, name)
+	}
+}
@@ -0,0 +1,401 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNil is returned when the requested key does not exist.
+var ErrNil = errors.New("redis: nil")
+
+// ErrUnavailable is returned without dialing while the client backs off
+// after redis failed to respond.
+var ErrUnavailable = errors.New("redis: unavailable")
+
+const (
+	maxIdleConns = 16
+
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+// Client is a minimal RESP client supporting the commands used by the cache.
+// Commands run concurrently over a pool of connections. After a network
+// error the client fails fast with ErrUnavailable for a backoff period,
+// doubling up to maxBackoff while redis keeps failing, so that a dead
+// redis costs callers nothing instead of a timeout each.
+type Client struct {
+	addr    string
+	timeout time.Duration
+
+	mu        sync.Mutex
+	idle      []*conn
+	closed    bool
+	backoff   time.Duration
+	downUntil time.Time
+}
+
+type conn struct {
+	net.Conn
+	rd *bufio.Reader
+}
+
+func New(addr string, timeout time.Duration) *Client {
+	return &Client{
+		addr:    addr,
+		timeout: timeout,
+	}
+}
+
+func (c *Client) Get(key string) (string, error) {
+	const op = "redis.Get"
+
+	reply, err := c.do("GET", key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if reply == nil {
+		return "", ErrNil
+	}
+
+	s, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("%s: unexpected reply %T", op, reply)
+	}
+
+	return s, nil
+}
+
+func (c *Client) Set(key string, value string, ttl time.Duration) error {
+	const op = "redis.Set"
+
+	args := []string{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	if _, err := c.do(args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *Client) Del(key string) error {
+	const op = "redis.Del"
+
+	if _, err := c.do("DEL", key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *Client) Publish(channel string, message string) error {
+	const op = "redis.Publish"
+
+	if _, err := c.do("PUBLISH", channel, message); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Subscribe opens a dedicated connection subscribed to channel and returns
+// the received messages. The returned channel is closed when ctx is done
+// or the connection fails; the connection is closed along with it.
+func (c *Client) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	const op = "redis.Subscribe"
+
+	cn, err := c.dial()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if c.timeout > 0 {
+		_ = cn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	if err := writeCommand(cn, "SUBSCRIBE", channel); err != nil {
+		_ = cn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// first reply confirms the subscription
+	if _, err := readReply(cn.rd); err != nil {
+		_ = cn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// messages may take arbitrarily long to arrive
+	_ = cn.SetDeadline(time.Time{})
+
+	messages := make(chan string)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = cn.Close()
+	}()
+
+	go func() {
+		defer close(messages)
+		defer close(done)
+
+		for {
+			reply, err := readReply(cn.rd)
+			if err != nil {
+				return
+			}
+
+			parts, ok := reply.([]interface{})
+			if !ok || len(parts) != 3 || parts[0] != "message" {
+				continue
+			}
+
+			msg, _ := parts[2].(string)
+
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+// Close closes the idle connections. Connections in use are closed when
+// their commands complete.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+	c.closed = true
+	c.mu.Unlock()
+
+	var errs []error
+	for _, cn := range idle {
+		if err := cn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Client) do(args ...string) (interface{}, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.timeout > 0 {
+		_ = cn.SetDeadline(time.Now().Add(c.timeout))
+	}
+
+	if err := writeCommand(cn, args...); err != nil {
+		c.fail(cn)
+		return nil, err
+	}
+
+	reply, err := readReply(cn.rd)
+	if err != nil {
+		var replyErr Error
+		if !errors.As(err, &replyErr) {
+			// connection state is unknown, do not reuse it
+			c.fail(cn)
+			return nil, err
+		}
+	}
+
+	c.put(cn)
+
+	return reply, err
+}
+
+// get returns an idle connection or dials a new one.
+func (c *Client) get() (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	return c.dial()
+}
+
+func (c *Client) dial() (*conn, error) {
+	c.mu.Lock()
+	if time.Now().Before(c.downUntil) {
+		c.mu.Unlock()
+
+		return nil, ErrUnavailable
+	}
+	c.mu.Unlock()
+
+	nc, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		c.fail(nil)
+		return nil, err
+	}
+
+	return &conn{Conn: nc, rd: bufio.NewReader(nc)}, nil
+}
+
+// put returns a healthy connection to the pool and resets the backoff.
+func (c *Client) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.backoff = 0
+
+	if c.closed || len(c.idle) >= maxIdleConns {
+		_ = cn.Close()
+		return
+	}
+
+	c.idle = append(c.idle, cn)
+}
+
+// fail closes cn, if any, and backs off: redis is considered down, so the
+// idle connections are dropped too.
+func (c *Client) fail(cn *conn) {
+	if cn != nil {
+		_ = cn.Close()
+	}
+
+	c.mu.Lock()
+	idle := c.idle
+	c.idle = nil
+
+	if c.backoff == 0 {
+		c.backoff = minBackoff
+	} else if c.backoff < maxBackoff {
+		c.backoff *= 2
+		if c.backoff > maxBackoff {
+			c.backoff = maxBackoff
+		}
+	}
+	c.downUntil = time.Now().Add(c.backoff)
+	c.mu.Unlock()
+
+	for _, cn := range idle {
+		_ = cn.Close()
+	}
+}
+
+// Error is an error reply returned by the server.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+func writeCommand(w io.Writer, args ...string) error {
+	buf := make([]byte, 0, 64)
+
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	_, err := w.Write(buf)
+
+	return err
+}
+
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := readLine(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(rd)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func readLine(rd *bufio.Reader) (string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed line %q", line)
+	}
+
+	return line[:len(line)-2], nil
+}
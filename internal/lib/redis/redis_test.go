@@ -0,0 +1,117 @@
+package redis_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/redis"
+)
+
+// serve accepts connections on a local port and answers every command
+// with reply. It returns the address and the number of accepted
+// connections so far.
+func serve(t *testing.T, reply string) (string, *atomic.Int32) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	var accepted atomic.Int32
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+
+			go func() {
+				defer conn.Close()
+
+				rd := bufio.NewReader(conn)
+				for {
+					// every command is sent as a single array of bulk strings
+					line, err := rd.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line[0] != '*' {
+						continue
+					}
+					if _, err := conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), &accepted
+}
+
+func TestClient_ReusesConnections(t *testing.T) {
+	addr, accepted := serve(t, "$3\r\nurl\r\n")
+
+	client := redis.New(addr, time.Second)
+	defer func() { _ = client.Close() }()
+
+	for i := 0; i < 5; i++ {
+		got, err := client.Get("alias")
+		require.NoError(t, err)
+		assert.Equal(t, "url", got)
+	}
+
+	assert.EqualValues(t, 1, accepted.Load())
+}
+
+func TestClient_Concurrent(t *testing.T) {
+	addr, _ := serve(t, "+OK\r\n")
+
+	client := redis.New(addr, time.Second)
+	defer func() { _ = client.Close() }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.NoError(t, client.Set("alias", "url", time.Minute))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClient_BacksOffWhenDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	client := redis.New(addr, time.Second)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.Get("alias")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, redis.ErrUnavailable)
+
+	// the following calls fail fast without dialing
+	_, err = client.Get("alias")
+	assert.ErrorIs(t, err, redis.ErrUnavailable)
+
+	// and redis is dialed again once the backoff passes
+	require.Eventually(t, func() bool {
+		_, err := client.Get("alias")
+		return !errors.Is(err, redis.ErrUnavailable)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,65 @@
+package routeconflict
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AliasFinder looks up stored aliases, e.g. sqlite.Storage.
+type AliasFinder interface {
+	AliasesMatching(exact []string, prefixes []string, limit int) ([]string, error)
+}
+
+// Find returns up to limit stored aliases whose redirect is shadowed by a
+// GET route of router, e.g. the alias "readyz" by GET /readyz. A route
+// with a parameter or wildcard shadows every alias starting with its
+// static part, e.g. GET /admin/* the namespaced alias "admin/panel".
+func Find(router chi.Routes, finder AliasFinder, limit int) ([]string, error) {
+	const op = "lib.routeconflict.Find"
+
+	exact, prefixes, err := staticRoutes(router)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	aliases, err := finder.AliasesMatching(exact, prefixes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// staticRoutes returns the static GET routes of router as aliases, and the
+// static parts of the others as alias prefixes. Routes starting with a
+// parameter, e.g. the redirect one, are skipped.
+func staticRoutes(router chi.Routes) (exact []string, prefixes []string, err error) {
+	seen := make(map[string]bool)
+
+	err = chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if method != http.MethodGet || seen[route] {
+			return nil
+		}
+		seen[route] = true
+
+		i := strings.IndexAny(route, "{*")
+		if i == -1 {
+			if alias := strings.Trim(route, "/"); alias != "" {
+				exact = append(exact, alias)
+			}
+
+			return nil
+		}
+
+		if prefix := strings.TrimPrefix(route[:i], "/"); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+
+		return nil
+	})
+
+	return exact, prefixes, err
+}
@@ -0,0 +1,48 @@
+package routeconflict_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/routeconflict"
+)
+
+type fakeFinder struct {
+	exact    []string
+	prefixes []string
+}
+
+func (f *fakeFinder) AliasesMatching(exact []string, prefixes []string, _ int) ([]string, error) {
+	f.exact = exact
+	f.prefixes = prefixes
+
+	return []string{"readyz"}, nil
+}
+
+func TestFind(t *testing.T) {
+	handler := http.NotFoundHandler()
+
+	r := chi.NewRouter()
+	r.Get("/{alias}", handler.ServeHTTP)
+	r.Get("/{namespace}/{alias}", handler.ServeHTTP)
+	r.Get("/readyz", handler.ServeHTTP)
+	r.Post("/import", handler.ServeHTTP)
+	r.Handle("/admin/*", handler)
+	r.Route("/urls", func(r chi.Router) {
+		r.Get("/", handler.ServeHTTP)
+		r.Get("/{alias}/clicks", handler.ServeHTTP)
+	})
+
+	finder := &fakeFinder{}
+
+	aliases, err := routeconflict.Find(r, finder, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"readyz"}, aliases)
+	assert.ElementsMatch(t, []string{"readyz", "urls"}, finder.exact)
+	assert.ElementsMatch(t, []string{"admin/", "urls/"}, finder.prefixes)
+}
@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// Locker takes named locks shared by the replicas, so that each job
+// runs on one of them at a time.
+type Locker interface {
+	TryLock(name string, ttl time.Duration) (ok bool, lock storage.Lock, err error)
+}
+
+// Job is a maintenance task run every Interval. A job with
+// a non-positive interval is disabled.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs maintenance jobs at their intervals.
+type Scheduler struct {
+	log    *slog.Logger
+	locker Locker
+	jobs   []Job
+}
+
+// New creates a scheduler. With a nil locker jobs run on every replica.
+func New(log *slog.Logger, locker Locker) *Scheduler {
+	return &Scheduler{
+		log:    log.With(slog.String("component", "scheduler")),
+		locker: locker,
+	}
+}
+
+// Add registers the job unless it is disabled.
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		return
+	}
+
+	s.jobs = append(s.jobs, job)
+}
+
+// Jobs returns the names of the registered jobs.
+func (s *Scheduler) Jobs() []string {
+	names := make([]string, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		names = append(names, job.Name)
+	}
+
+	return names
+}
+
+// Run runs the jobs until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, job := range s.jobs {
+		wg.Add(1)
+
+		go func(job Job) {
+			defer wg.Done()
+
+			s.loop(ctx, job)
+		}(job)
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.runOnce(ctx, job)
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	log := s.log.With(slog.String("job", job.Name))
+
+	var lock storage.Lock
+
+	if s.locker != nil {
+		// the lock is kept for an interval after the job started or was
+		// last extended, so that the other replicas skip their next run,
+		// and it outlives a crashed holder by at most one interval
+		locked, l, err := s.locker.TryLock(job.Name, job.Interval)
+		if err != nil {
+			log.Error("failed to lock job", sl.Err(err))
+
+			return
+		}
+		if !locked {
+			log.Debug("job skipped, another replica runs it")
+
+			return
+		}
+
+		lock = l
+
+		stop := keepLocked(log, lock, job.Interval)
+		defer stop()
+	}
+
+	start := time.Now()
+
+	if err := job.Run(ctx); err != nil {
+		log.Error("job failed", sl.Err(err), slog.Duration("duration", time.Since(start)))
+
+		// a failed job may be retried by another replica at once
+		if lock != nil {
+			lock.Release()
+		}
+
+		return
+	}
+
+	log.Info("job done", slog.Duration("duration", time.Since(start)))
+}
+
+// keepLocked extends lock by ttl every half of ttl until stop is
+// called, so that a job running longer than its interval keeps it.
+func keepLocked(log *slog.Logger, lock storage.Lock, ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(ttl / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if err := lock.Extend(ttl); err != nil {
+				log.Error("failed to extend job lock", sl.Err(err))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
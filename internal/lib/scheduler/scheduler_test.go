@@ -0,0 +1,184 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/scheduler"
+	"url-shortener/internal/storage"
+)
+
+// run runs s for d and waits for it to stop.
+func run(s *scheduler.Scheduler, d time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	s.Run(ctx)
+}
+
+func TestScheduler_RunsRepeatedly(t *testing.T) {
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), nil)
+
+	var runs atomic.Int32
+	s.Add(scheduler.Job{
+		Name:     "tick",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	run(s, 100*time.Millisecond)
+
+	assert.GreaterOrEqual(t, runs.Load(), int32(3))
+}
+
+func TestScheduler_KeepsRunningAfterFailure(t *testing.T) {
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), nil)
+
+	var runs atomic.Int32
+	s.Add(scheduler.Job{
+		Name:     "failing",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return errors.New("boom")
+		},
+	})
+
+	run(s, 100*time.Millisecond)
+
+	assert.GreaterOrEqual(t, runs.Load(), int32(2))
+}
+
+func TestScheduler_Disabled(t *testing.T) {
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), nil)
+
+	var runs atomic.Int32
+	s.Add(scheduler.Job{
+		Name:     "disabled",
+		Interval: 0,
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	assert.Empty(t, s.Jobs())
+
+	run(s, 50*time.Millisecond)
+
+	assert.Zero(t, runs.Load())
+}
+
+type lockerFunc func(name string, ttl time.Duration) (bool, storage.Lock, error)
+
+func (f lockerFunc) TryLock(name string, ttl time.Duration) (bool, storage.Lock, error) {
+	return f(name, ttl)
+}
+
+type fakeLock struct {
+	extended atomic.Int32
+	released atomic.Int32
+}
+
+func (l *fakeLock) Extend(time.Duration) error {
+	l.extended.Add(1)
+	return nil
+}
+
+func (l *fakeLock) Release() {
+	l.released.Add(1)
+}
+
+func TestScheduler_Lock(t *testing.T) {
+	var (
+		held atomic.Bool
+		runs atomic.Int32
+		lock fakeLock
+	)
+
+	locker := lockerFunc(func(name string, ttl time.Duration) (bool, storage.Lock, error) {
+		assert.Equal(t, "purge", name)
+		assert.Equal(t, 10*time.Millisecond, ttl)
+
+		if held.Load() {
+			return false, nil, nil
+		}
+
+		return true, &lock, nil
+	})
+
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), locker)
+	s.Add(scheduler.Job{
+		Name:     "purge",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+
+	run(s, 50*time.Millisecond)
+
+	assert.Positive(t, runs.Load())
+	assert.Zero(t, lock.released.Load(), "the lock is kept for the interval")
+
+	// another replica holds the lock
+	held.Store(true)
+	runs.Store(0)
+
+	run(s, 50*time.Millisecond)
+
+	assert.Zero(t, runs.Load())
+}
+
+func TestScheduler_LockReleasedOnFailure(t *testing.T) {
+	var lock fakeLock
+
+	locker := lockerFunc(func(string, time.Duration) (bool, storage.Lock, error) {
+		return true, &lock, nil
+	})
+
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), locker)
+	s.Add(scheduler.Job{
+		Name:     "failing",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) error {
+			return errors.New("boom")
+		},
+	})
+
+	run(s, 50*time.Millisecond)
+
+	assert.Positive(t, lock.released.Load())
+}
+
+func TestScheduler_LockExtendedForLongJobs(t *testing.T) {
+	var lock fakeLock
+
+	locker := lockerFunc(func(string, time.Duration) (bool, storage.Lock, error) {
+		return true, &lock, nil
+	})
+
+	s := scheduler.New(slogdiscard.NewDiscardLogger(), locker)
+	s.Add(scheduler.Job{
+		Name:     "slow",
+		Interval: 10 * time.Millisecond,
+		Run: func(context.Context) error {
+			time.Sleep(40 * time.Millisecond)
+			return nil
+		},
+	})
+
+	run(s, 30*time.Millisecond)
+
+	assert.GreaterOrEqual(t, lock.extended.Load(), int32(2))
+}
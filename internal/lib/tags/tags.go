@@ -0,0 +1,51 @@
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+const (
+	// DefaultMax is the default number of tags of a url.
+	DefaultMax = 10
+	// MaxLength is the maximum length of a tag.
+	MaxLength = 32
+)
+
+var (
+	ErrInvalid = errors.New("invalid tag")
+	ErrTooMany = errors.New("too many tags")
+)
+
+var pattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateTag checks that tag consists of lowercase letters, digits,
+// "-" and "_", starts with a letter or a digit and is at most
+// MaxLength long.
+func ValidateTag(tag string) error {
+	if len(tag) > MaxLength || !pattern.MatchString(tag) {
+		return fmt.Errorf("%w %q", ErrInvalid, tag)
+	}
+
+	return nil
+}
+
+// Validate checks every tag and that there are at most max distinct ones.
+func Validate(tags []string, max int) error {
+	set := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		if err := ValidateTag(tag); err != nil {
+			return err
+		}
+
+		set[tag] = true
+	}
+
+	if len(set) > max {
+		return fmt.Errorf("%w, max is %d", ErrTooMany, max)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package tags_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/lib/tags"
+)
+
+func TestValidateTag(t *testing.T) {
+	for _, tag := range []string{"summer-sale", "q3_2024", "a"} {
+		assert.NoError(t, tags.ValidateTag(tag), tag)
+	}
+
+	for _, tag := range []string{"", "Summer", "-sale", "with space", "a/b", strings.Repeat("a", tags.MaxLength+1)} {
+		assert.ErrorIs(t, tags.ValidateTag(tag), tags.ErrInvalid, tag)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, tags.Validate([]string{"a", "b", "a"}, 2), "duplicates count once")
+	assert.ErrorIs(t, tags.Validate([]string{"a", "b", "c"}, 2), tags.ErrTooMany)
+	assert.ErrorIs(t, tags.Validate([]string{"a", "B"}, 2), tags.ErrInvalid)
+}
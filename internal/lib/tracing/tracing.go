@@ -0,0 +1,369 @@
+// Package tracing is a small tracer compatible with OpenTelemetry:
+// it propagates W3C trace context and exports spans over OTLP/HTTP.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+)
+
+const (
+	traceparentHeader = "traceparent"
+
+	queueSize = 2048
+	batchSize = 256
+)
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+type SpanKind int
+
+// Values match the OTLP span kinds.
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// SpanData is a finished span handed to the exporter.
+type SpanData struct {
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Name         string
+	Kind         SpanKind
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Err          string
+}
+
+// Exporter sends finished spans to a tracing backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Span is an operation in progress. A nil *Span is a valid no-op span,
+// so callers don't need to check whether tracing is enabled.
+type Span struct {
+	tracer *Tracer
+
+	mu   sync.Mutex
+	data SpanData
+}
+
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes[key] = value
+}
+
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Err = err.Error()
+}
+
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+
+	return s.data.TraceID
+}
+
+type spanKey struct{}
+
+// remoteKey marks a parent span context received from another service.
+type remoteKey struct{}
+
+type remote struct {
+	traceID TraceID
+	spanID  SpanID
+	sampled bool
+}
+
+// SpanFromContext returns the current span or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+
+	return s
+}
+
+// Tracer starts spans and exports them in the background.
+// A nil *Tracer is a valid tracer which doesn't record anything.
+type Tracer struct {
+	log      *slog.Logger
+	exporter Exporter
+	ratio    float64
+
+	// mu guards closing queue: spans are enqueued under the read lock,
+	// so none is sent on the closed queue.
+	mu     sync.RWMutex
+	closed bool
+	queue  chan SpanData
+	done   chan struct{}
+}
+
+// New creates a tracer sampling the given ratio of new traces.
+// Traces started by an upstream service follow the upstream decision.
+func New(log *slog.Logger, exporter Exporter, ratio float64) *Tracer {
+	t := &Tracer{
+		log:      log.With(slog.String("component", "tracing")),
+		exporter: exporter,
+		ratio:    ratio,
+		queue:    make(chan SpanData, queueSize),
+		done:     make(chan struct{}),
+	}
+
+	go t.run()
+
+	return t
+}
+
+// Start starts a span which is a child of the span in ctx, if any.
+// The returned span is nil if the trace is not sampled.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	data := SpanData{
+		SpanID:     newSpanID(),
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: make(map[string]string),
+	}
+
+	switch parent := SpanFromContext(ctx); {
+	case parent != nil:
+		data.TraceID = parent.data.TraceID
+		data.ParentSpanID = parent.data.SpanID
+	default:
+		if r, ok := ctx.Value(remoteKey{}).(remote); ok {
+			if !r.sampled {
+				return ctx, nil
+			}
+
+			data.TraceID = r.traceID
+			data.ParentSpanID = r.spanID
+		} else {
+			data.TraceID = newTraceID()
+
+			if !t.sample(data.TraceID) {
+				return ctx, nil
+			}
+		}
+	}
+
+	s := &Span{tracer: t, data: data}
+
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// Extract reads the W3C traceparent header into the returned context.
+func Extract(ctx context.Context, h http.Header) context.Context {
+	r, ok := parseTraceparent(h.Get(traceparentHeader))
+	if !ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, remoteKey{}, r)
+}
+
+// Inject writes the current span into the W3C traceparent header.
+func Inject(ctx context.Context, h http.Header) {
+	s := SpanFromContext(ctx)
+	if s == nil {
+		return
+	}
+
+	h.Set(traceparentHeader, fmt.Sprintf("00-%s-%s-01", s.data.TraceID, s.data.SpanID))
+}
+
+// Shutdown exports the queued spans. Spans ended after Shutdown are dropped.
+// It is safe to call more than once.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.closed {
+		return
+	}
+
+	select {
+	case t.queue <- data:
+	default:
+		t.log.Warn("span queue is full, span dropped", slog.String("span", data.Name))
+	}
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := t.exporter.Export(context.Background(), batch); err != nil {
+			t.log.Warn("failed to export spans", sl.Err(err), slog.Int("count", len(batch)))
+		}
+
+		batch = make([]SpanData, 0, batchSize)
+	}
+
+	for {
+		select {
+		case data, ok := <-t.queue:
+			if !ok {
+				flush()
+
+				return
+			}
+
+			batch = append(batch, data)
+			if len(batch) == batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// sample makes a deterministic decision based on the trace ID,
+// like the OpenTelemetry TraceIDRatioBased sampler.
+func (t *Tracer) sample(id TraceID) bool {
+	if t.ratio >= 1 {
+		return true
+	}
+	if t.ratio <= 0 {
+		return false
+	}
+
+	var x uint64
+	for _, b := range id[8:] {
+		x = x<<8 | uint64(b)
+	}
+
+	return x>>1 < uint64(t.ratio*(math.MaxUint64>>1))
+}
+
+func parseTraceparent(v string) (remote, bool) {
+	parts := strings.Split(v, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return remote{}, false
+	}
+
+	var r remote
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(r.traceID) {
+		return remote{}, false
+	}
+
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(r.spanID) {
+		return remote{}, false
+	}
+
+	copy(r.traceID[:], traceID)
+	copy(r.spanID[:], spanID)
+
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return remote{}, false
+	}
+
+	if !r.traceID.IsValid() || !r.spanID.IsValid() {
+		return remote{}, false
+	}
+
+	r.sampled = flags[0]&1 == 1
+
+	return r, true
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+
+	return id
+}
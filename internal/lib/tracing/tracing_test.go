@@ -0,0 +1,121 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/tracing"
+)
+
+func TestTracer_Sampling(t *testing.T) {
+	cases := []struct {
+		name        string
+		ratio       float64
+		traceparent string
+		wantSampled bool
+	}{
+		{name: "Always", ratio: 1, wantSampled: true},
+		{name: "Never", ratio: 0, wantSampled: false},
+		{
+			name:        "Upstream sampled",
+			ratio:       0,
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantSampled: true,
+		},
+		{
+			name:        "Upstream not sampled",
+			ratio:       1,
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			wantSampled: false,
+		},
+		{
+			name:        "Invalid traceparent",
+			ratio:       1,
+			traceparent: "00-xyz-00f067aa0ba902b7-01",
+			wantSampled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer := tracing.New(slogdiscard.NewDiscardLogger(), tracing.NewMemoryExporter(), tc.ratio)
+
+			h := http.Header{}
+			if tc.traceparent != "" {
+				h.Set("traceparent", tc.traceparent)
+			}
+
+			_, span := tracer.Start(tracing.Extract(context.Background(), h), "test", tracing.SpanKindServer)
+
+			assert.Equal(t, tc.wantSampled, span != nil)
+		})
+	}
+}
+
+func TestTracer_Shutdown(t *testing.T) {
+	exporter := tracing.NewMemoryExporter()
+	tracer := tracing.New(slogdiscard.NewDiscardLogger(), exporter, 1)
+
+	_, span := tracer.Start(context.Background(), "exported", tracing.SpanKindServer)
+	span.End()
+
+	_, late := tracer.Start(context.Background(), "dropped", tracing.SpanKindServer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, tracer.Shutdown(ctx))
+	require.NoError(t, tracer.Shutdown(ctx), "second shutdown")
+
+	late.End()
+
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "exported", spans[0].Name)
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer srv.Close()
+
+	tracer := tracing.New(slogdiscard.NewDiscardLogger(), tracing.NewOTLPExporter(srv.URL, time.Second), 1)
+
+	ctx, span := tracer.Start(context.Background(), "parent", tracing.SpanKindServer)
+	_, child := tracer.Start(ctx, "child", tracing.SpanKindInternal)
+	child.End()
+	span.End()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, tracer.Shutdown(shutdownCtx))
+
+	resourceSpans := body["resourceSpans"].([]interface{})
+	require.Len(t, resourceSpans, 1)
+
+	scopeSpans := resourceSpans[0].(map[string]interface{})["scopeSpans"].([]interface{})
+	spans := scopeSpans[0].(map[string]interface{})["spans"].([]interface{})
+	require.Len(t, spans, 2)
+
+	childSpan := spans[0].(map[string]interface{})
+	parentSpan := spans[1].(map[string]interface{})
+
+	assert.Equal(t, "child", childSpan["name"])
+	assert.Equal(t, parentSpan["spanId"], childSpan["parentSpanId"])
+	assert.Equal(t, parentSpan["traceId"], childSpan["traceId"])
+}
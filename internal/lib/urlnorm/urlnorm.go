@@ -0,0 +1,59 @@
+package urlnorm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalize returns the canonical form of rawURL, so equal URLs written
+// differently are stored and looked up the same way: the scheme and
+// host are lowercased and the default port is removed.
+func Normalize(rawURL string) (string, error) {
+	const op = "lib.urlnorm.Normalize"
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if host, port, err := net.SplitHostPort(u.Host); err == nil && defaultPorts[u.Scheme] == port {
+		u.Host = host
+		if strings.Contains(host, ":") {
+			u.Host = "[" + host + "]"
+		}
+	}
+
+	return u.String(), nil
+}
+
+// CheckScheme returns an error naming the allowed schemes unless
+// the scheme of rawURL is one of them. Scheme-less urls are rejected.
+// An empty allowed list allows any scheme.
+func CheckScheme(rawURL string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url")
+	}
+
+	for _, scheme := range allowed {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("url scheme must be one of: %s", strings.Join(allowed, ", "))
+}
@@ -0,0 +1,66 @@
+package urlnorm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/urlnorm"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Already canonical", in: "https://google.com/path?q=1", want: "https://google.com/path?q=1"},
+		{name: "Upper case host", in: "HTTPS://Google.COM/Path", want: "https://google.com/Path"},
+		{name: "Default port", in: "https://google.com:443/", want: "https://google.com/"},
+		{name: "Non-default port", in: "http://google.com:8080", want: "http://google.com:8080"},
+		{name: "IPv6 default port", in: "http://[::1]:80/", want: "http://[::1]/"},
+		{name: "Surrounding spaces", in: "  https://google.com  ", want: "https://google.com"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := urlnorm.Normalize(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCheckScheme(t *testing.T) {
+	cases := []struct {
+		name    string
+		url     string
+		allowed []string
+		wantErr string
+	}{
+		{name: "Allowed", url: "https://example.com", allowed: []string{"https"}},
+		{name: "Case insensitive", url: "HTTPS://example.com", allowed: []string{"https"}},
+		{name: "Disallowed", url: "http://example.com", allowed: []string{"https"}, wantErr: "url scheme must be one of: https"},
+		{name: "Other scheme", url: "ftp://example.com", allowed: []string{"http", "https"}, wantErr: "url scheme must be one of: http, https"},
+		{name: "Scheme-less", url: "example.com/path", allowed: []string{"http", "https"}, wantErr: "url scheme must be one of: http, https"},
+		{name: "Any scheme", url: "ftp://example.com"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := urlnorm.CheckScheme(tc.url, tc.allowed)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
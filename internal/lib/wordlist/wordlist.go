@@ -0,0 +1,108 @@
+// Package wordlist generates memorable aliases like "brave-otter-42".
+package wordlist
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	//go:embed adjectives.txt
+	defaultAdjectives string
+	//go:embed nouns.txt
+	defaultNouns string
+)
+
+// maxNumber bounds the number appended to the words.
+const maxNumber = 100
+
+// Generator composes aliases of words adjectives followed by a noun
+// and a number, e.g. "brave-quiet-otter-42" for 3 words.
+type Generator struct {
+	adjectives []string
+	nouns      []string
+	words      int
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New returns a Generator of the embedded wordlist. words below 1 is treated as 1.
+func New(words int) *Generator {
+	return newGenerator(parse(defaultAdjectives), parse(defaultNouns), words)
+}
+
+// Load returns a Generator of the words in the file at path, one per line,
+// which are used both as adjectives and nouns. Empty lines and lines
+// starting with "#" are skipped.
+func Load(path string, words int) (*Generator, error) {
+	const op = "lib.wordlist.Load"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	list := parse(string(data))
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, errors.New("wordlist is empty"))
+	}
+
+	return newGenerator(list, list, words), nil
+}
+
+func newGenerator(adjectives []string, nouns []string, words int) *Generator {
+	if words < 1 {
+		words = 1
+	}
+
+	return &Generator{
+		adjectives: adjectives,
+		nouns:      nouns,
+		words:      words,
+		rnd:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Generate returns a new alias.
+func (g *Generator) Generate() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	parts := make([]string, 0, g.words+1)
+
+	for i := 0; i < g.words-1; i++ {
+		parts = append(parts, g.adjectives[g.rnd.Intn(len(g.adjectives))])
+	}
+
+	parts = append(parts,
+		g.nouns[g.rnd.Intn(len(g.nouns))],
+		strconv.Itoa(g.rnd.Intn(maxNumber)),
+	)
+
+	return strings.Join(parts, "-")
+}
+
+func parse(data string) []string {
+	var words []string
+
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		word := strings.ToLower(strings.TrimSpace(sc.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+
+		words = append(words, word)
+	}
+
+	return words
+}
@@ -0,0 +1,69 @@
+package wordlist_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/wordlist"
+)
+
+func TestGenerator_Format(t *testing.T) {
+	cases := []struct {
+		words int
+		want  *regexp.Regexp
+	}{
+		{words: 1, want: regexp.MustCompile(`^[a-z]+-\d{1,2}$`)},
+		{words: 2, want: regexp.MustCompile(`^[a-z]+-[a-z]+-\d{1,2}$`)},
+		{words: 3, want: regexp.MustCompile(`^[a-z]+-[a-z]+-[a-z]+-\d{1,2}$`)},
+	}
+
+	for _, tc := range cases {
+		g := wordlist.New(tc.words)
+
+		for i := 0; i < 100; i++ {
+			assert.Regexp(t, tc.want, g.Generate())
+		}
+	}
+}
+
+func TestGenerator_Uniqueness(t *testing.T) {
+	const n = 1000
+
+	g := wordlist.New(3)
+
+	seen := make(map[string]struct{}, n)
+	for i := 0; i < n; i++ {
+		seen[g.Generate()] = struct{}{}
+	}
+
+	// collisions are possible, but rare enough to be resolved by a retry
+	assert.Greater(t, len(seen), n*99/100)
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# custom words\nAlpha\n\nbeta\n"), 0o600))
+
+	g, err := wordlist.Load(path, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		assert.Regexp(t, `^(alpha|beta)-(alpha|beta)-\d{1,2}$`, g.Generate())
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte("# nothing\n"), 0o600))
+
+	_, err := wordlist.Load(empty, 2)
+	assert.ErrorContains(t, err, "wordlist is empty")
+
+	_, err = wordlist.Load(filepath.Join(t.TempDir(), "missing.txt"), 2)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
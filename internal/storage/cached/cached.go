@@ -0,0 +1,101 @@
+package cached
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/cache"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// URLStorage is the storage wrapped by the cache.
+type URLStorage interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+	GetURL(alias string) (string, error)
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+	DeleteURL(alias string, owner string) error
+}
+
+// ExpiryGetter tells when an alias expires. Aliases it doesn't know
+// are taken as not expiring.
+type ExpiryGetter interface {
+	ExpiresAt(alias string) (time.Time, error)
+}
+
+// Storage serves GetURL from the cache and falls back to the wrapped
+// storage on a miss or when the cache is unavailable. Expiring aliases
+// are not cached, since entries have no per-alias lifetime.
+type Storage struct {
+	URLStorage
+	expiry ExpiryGetter
+	cache  cache.Cache
+	log    *slog.Logger
+}
+
+func New(log *slog.Logger, s URLStorage, expiry ExpiryGetter, c cache.Cache) *Storage {
+	return &Storage{
+		URLStorage: s,
+		expiry:     expiry,
+		cache:      c,
+		log:        log.With(slog.String("component", "storage/cached")),
+	}
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	url, err := s.cache.Get(alias)
+	if err == nil {
+		return url, nil
+	}
+	if !errors.Is(err, cache.ErrMiss) {
+		s.log.Warn("cache is unavailable", sl.Err(err))
+	}
+
+	url, err = s.URLStorage.GetURL(alias)
+	if err != nil {
+		return "", err
+	}
+
+	expiresAt, err := s.expiry.ExpiresAt(alias)
+	if err != nil && !errors.Is(err, storage.ErrURLNotFound) {
+		s.log.Warn("failed to get url expiry", sl.Err(err))
+
+		return url, nil
+	}
+	if !expiresAt.IsZero() {
+		return url, nil
+	}
+
+	if err := s.cache.Set(alias, url); err != nil {
+		s.log.Warn("failed to cache url", sl.Err(err))
+	}
+
+	return url, nil
+}
+
+func (s *Storage) UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error) {
+	created, err := s.URLStorage.UpsertURL(alias, urlToSave, owner, opts...)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.cache.Delete(alias); err != nil {
+		s.log.Warn("failed to invalidate cached url", sl.Err(err))
+	}
+
+	return created, nil
+}
+
+func (s *Storage) DeleteURL(alias string, owner string) error {
+	if err := s.URLStorage.DeleteURL(alias, owner); err != nil {
+		return err
+	}
+
+	if err := s.cache.Delete(alias); err != nil {
+		s.log.Warn("failed to invalidate cached url", sl.Err(err))
+	}
+
+	return nil
+}
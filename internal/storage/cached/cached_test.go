@@ -0,0 +1,109 @@
+package cached_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/cache/memory"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/cached"
+)
+
+type fakeStorage struct {
+	urls    map[string]string
+	expires map[string]time.Time
+	reads   int
+}
+
+func (s *fakeStorage) ExpiresAt(alias string) (time.Time, error) {
+	return s.expires[alias], nil
+}
+
+func (s *fakeStorage) SaveURL(urlToSave string, alias string, _ ...storage.SaveOption) (int64, error) {
+	s.urls[alias] = urlToSave
+	return int64(len(s.urls)), nil
+}
+
+func (s *fakeStorage) GetURL(alias string) (string, error) {
+	s.reads++
+
+	url, ok := s.urls[alias]
+	if !ok {
+		return "", storage.ErrURLNotFound
+	}
+
+	return url, nil
+}
+
+func (s *fakeStorage) UpsertURL(alias string, urlToSave string, _ string, _ ...storage.SaveOption) (bool, error) {
+	_, exists := s.urls[alias]
+	s.urls[alias] = urlToSave
+	return !exists, nil
+}
+
+func (s *fakeStorage) DeleteURL(alias string, _ string) error {
+	delete(s.urls, alias)
+	return nil
+}
+
+type brokenCache struct{}
+
+func (brokenCache) Get(string) (string, error) { return "", errors.New("connection refused") }
+func (brokenCache) Set(string, string) error   { return errors.New("connection refused") }
+func (brokenCache) Delete(string) error        { return errors.New("connection refused") }
+
+func TestStorage_GetURL_CacheHit(t *testing.T) {
+	fs := &fakeStorage{urls: map[string]string{"alias": "https://example.com"}}
+	s := cached.New(slogdiscard.NewDiscardLogger(), fs, fs, memory.New(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		got, err := s.GetURL("alias")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com", got)
+	}
+
+	assert.Equal(t, 1, fs.reads)
+}
+
+func TestStorage_DeleteURL_Invalidates(t *testing.T) {
+	fs := &fakeStorage{urls: map[string]string{"alias": "https://example.com"}}
+	s := cached.New(slogdiscard.NewDiscardLogger(), fs, fs, memory.New(time.Minute))
+
+	_, err := s.GetURL("alias")
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteURL("alias", "alice"))
+
+	_, err = s.GetURL("alias")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_GetURL_FailOpen(t *testing.T) {
+	fs := &fakeStorage{urls: map[string]string{"alias": "https://example.com"}}
+	s := cached.New(slogdiscard.NewDiscardLogger(), fs, fs, brokenCache{})
+
+	got, err := s.GetURL("alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", got)
+}
+
+func TestStorage_GetURL_ExpiringNotCached(t *testing.T) {
+	fs := &fakeStorage{
+		urls:    map[string]string{"alias": "https://example.com"},
+		expires: map[string]time.Time{"alias": time.Now().Add(time.Minute)},
+	}
+	s := cached.New(slogdiscard.NewDiscardLogger(), fs, fs, memory.New(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		_, err := s.GetURL("alias")
+		require.NoError(t, err)
+	}
+
+	// the storage decides when the alias expires
+	assert.Equal(t, 2, fs.reads)
+}
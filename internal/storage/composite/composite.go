@@ -0,0 +1,80 @@
+package composite
+
+import (
+	"errors"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/storage"
+)
+
+// URLStorage is the primary storage.
+type URLStorage interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+	GetURL(alias string) (string, error)
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+	DeleteURL(alias string, owner string) error
+}
+
+// URLGetter is the read-only fallback storage, e.g. sqlite.ReadOnly.
+type URLGetter interface {
+	GetURL(alias string) (string, error)
+}
+
+// Storage serves GetURL from the fallback storage for aliases missing
+// from the primary one, so legacy aliases keep working during a cutover.
+// Writes only go to the primary storage.
+type Storage struct {
+	URLStorage
+	fallback URLGetter
+	migrate  bool
+	log      *slog.Logger
+}
+
+// New returns the primary storage s falling back to fallback. With
+// migrate, aliases found in the fallback are copied into s.
+func New(log *slog.Logger, s URLStorage, fallback URLGetter, migrate bool) *Storage {
+	return &Storage{
+		URLStorage: s,
+		fallback:   fallback,
+		migrate:    migrate,
+		log:        log.With(slog.String("component", "storage/composite")),
+	}
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	url, err := s.URLStorage.GetURL(alias)
+	// aliases scheduled in the primary storage must not be shadowed
+	if !errors.Is(err, storage.ErrURLNotFound) || errors.Is(err, storage.ErrURLNotActive) {
+		return url, err
+	}
+
+	url, fallbackErr := s.fallback.GetURL(alias)
+	if errors.Is(fallbackErr, storage.ErrURLNotFound) {
+		return "", err
+	}
+	if fallbackErr != nil {
+		return "", fallbackErr
+	}
+
+	if s.migrate {
+		s.copy(alias, url)
+	}
+
+	return url, nil
+}
+
+// copy saves alias into the primary storage. Failures are only logged,
+// since the alias is still served from the fallback.
+func (s *Storage) copy(alias string, url string) {
+	_, err := s.URLStorage.SaveURL(url, alias)
+	switch {
+	case errors.Is(err, storage.ErrURLExists):
+		// copied by a concurrent read
+	case err != nil:
+		s.log.Warn("failed to migrate alias", slog.String("alias", alias), sl.Err(err))
+	default:
+		s.log.Info("alias migrated", slog.String("alias", alias))
+	}
+}
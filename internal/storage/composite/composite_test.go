@@ -0,0 +1,120 @@
+package composite_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/composite"
+)
+
+type fakeStorage struct {
+	urls  map[string]string
+	err   error
+	reads int
+}
+
+func (s *fakeStorage) SaveURL(urlToSave string, alias string, _ ...storage.SaveOption) (int64, error) {
+	if _, ok := s.urls[alias]; ok {
+		return 0, storage.ErrURLExists
+	}
+
+	s.urls[alias] = urlToSave
+
+	return int64(len(s.urls)), nil
+}
+
+func (s *fakeStorage) GetURL(alias string) (string, error) {
+	s.reads++
+
+	if s.err != nil {
+		return "", s.err
+	}
+
+	url, ok := s.urls[alias]
+	if !ok {
+		return "", storage.ErrURLNotFound
+	}
+
+	return url, nil
+}
+
+func (s *fakeStorage) UpsertURL(alias string, urlToSave string, _ string, _ ...storage.SaveOption) (bool, error) {
+	_, exists := s.urls[alias]
+	s.urls[alias] = urlToSave
+
+	return !exists, nil
+}
+
+func (s *fakeStorage) DeleteURL(alias string, _ string) error {
+	delete(s.urls, alias)
+
+	return nil
+}
+
+func newStorages(migrate bool) (*composite.Storage, *fakeStorage, *fakeStorage) {
+	primary := &fakeStorage{urls: map[string]string{"new": "https://new.com"}}
+	fallback := &fakeStorage{urls: map[string]string{"old": "https://old.com", "new": "https://stale.com"}}
+
+	return composite.New(slogdiscard.NewDiscardLogger(), primary, fallback, migrate), primary, fallback
+}
+
+func TestStorage_PrimaryHit(t *testing.T) {
+	s, _, fallback := newStorages(false)
+
+	url, err := s.GetURL("new")
+	require.NoError(t, err)
+	assert.Equal(t, "https://new.com", url)
+	assert.Zero(t, fallback.reads)
+}
+
+func TestStorage_FallbackHit(t *testing.T) {
+	s, primary, _ := newStorages(false)
+
+	url, err := s.GetURL("old")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", url)
+	assert.NotContains(t, primary.urls, "old", "not migrated")
+}
+
+func TestStorage_FallbackMiss(t *testing.T) {
+	s, _, _ := newStorages(false)
+
+	_, err := s.GetURL("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_FallbackError(t *testing.T) {
+	s, _, fallback := newStorages(false)
+	fallback.err = errors.New("disk I/O error")
+
+	_, err := s.GetURL("old")
+	assert.EqualError(t, err, "disk I/O error")
+}
+
+func TestStorage_NotActiveIsNotShadowed(t *testing.T) {
+	s, primary, fallback := newStorages(false)
+	primary.err = storage.NewError("op", storage.KindNotFound, storage.ErrURLNotActive)
+
+	_, err := s.GetURL("old")
+	assert.ErrorIs(t, err, storage.ErrURLNotActive)
+	assert.Zero(t, fallback.reads)
+}
+
+func TestStorage_MigrateOnRead(t *testing.T) {
+	s, primary, fallback := newStorages(true)
+
+	url, err := s.GetURL("old")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", url)
+	assert.Equal(t, "https://old.com", primary.urls["old"])
+
+	url, err = s.GetURL("old")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", url)
+	assert.Equal(t, 1, fallback.reads, "served from the primary storage once copied")
+}
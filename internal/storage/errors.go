@@ -0,0 +1,103 @@
+package storage
+
+import "errors"
+
+// ErrTransient is returned when the storage is temporarily unavailable,
+// e.g. locked by another writer. The operation may be retried.
+var ErrTransient = errors.New("storage is temporarily unavailable")
+
+// Kind classifies storage errors, so callers can handle them
+// regardless of the backend and the operation.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindExists
+	// KindGone is an expired or used up url.
+	KindGone
+	KindForbidden
+	KindTransient
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindExists:
+		return "exists"
+	case KindGone:
+		return "gone"
+	case KindForbidden:
+		return "forbidden"
+	case KindTransient:
+		return "transient"
+	default:
+		return "internal"
+	}
+}
+
+// sentinels are the errors matched by errors.Is for each kind.
+var sentinels = []struct {
+	kind Kind
+	err  error
+}{
+	{KindNotFound, ErrURLNotFound},
+	{KindNotFound, ErrKeyNotFound},
+	{KindExists, ErrURLExists},
+	{KindGone, ErrURLGone},
+	{KindForbidden, ErrNotOwner},
+	{KindTransient, ErrTransient},
+}
+
+// Error is a storage error of a known kind. Besides the wrapped error it
+// matches the first sentinel of its kind with errors.Is, e.g. ErrTransient.
+type Error struct {
+	Op   string
+	Kind Kind
+	Err  error
+}
+
+// NewError returns an Error of kind wrapping err.
+func NewError(op string, kind Kind, err error) error {
+	return &Error{Op: op, Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Op == "" {
+		return e.Err.Error()
+	}
+
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	for _, s := range sentinels {
+		if s.kind == e.Kind {
+			return target == s.err
+		}
+	}
+
+	return false
+}
+
+// KindOf returns the kind of err: the one of an Error in its chain,
+// otherwise the one of a wrapped sentinel and KindInternal by default.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			return s.kind
+		}
+	}
+
+	return KindInternal
+}
@@ -0,0 +1,43 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"url-shortener/internal/storage"
+)
+
+func TestKindOf(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want storage.Kind
+	}{
+		{"error", storage.NewError("op", storage.KindTransient, errors.New("locked")), storage.KindTransient},
+		{"wrapped error", fmt.Errorf("outer: %w", storage.NewError("op", storage.KindGone, storage.ErrURLGone)), storage.KindGone},
+		{"sentinel", fmt.Errorf("op: %w", storage.ErrURLExists), storage.KindExists},
+		{"key not found", storage.ErrKeyNotFound, storage.KindNotFound},
+		{"not owner", storage.ErrNotOwner, storage.KindForbidden},
+		{"unknown", errors.New("boom"), storage.KindInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, storage.KindOf(tc.err))
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("database is locked")
+	err := storage.NewError("storage.sqlite.DeleteURL", storage.KindTransient, cause)
+
+	assert.EqualError(t, err, "storage.sqlite.DeleteURL: database is locked")
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, storage.ErrTransient)
+	assert.NotErrorIs(t, err, storage.ErrURLNotFound)
+	assert.Equal(t, "transient", storage.KindTransient.String())
+}
@@ -0,0 +1,67 @@
+package slowlog
+
+import (
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/storage"
+)
+
+// URLStorage is the storage wrapped by the slow query log.
+type URLStorage interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+	GetURL(alias string) (string, error)
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+	DeleteURL(alias string, owner string) error
+}
+
+// Storage logs a warning for every storage call taking longer than the threshold.
+type Storage struct {
+	URLStorage
+	threshold time.Duration
+	log       *slog.Logger
+}
+
+func New(log *slog.Logger, s URLStorage, threshold time.Duration) *Storage {
+	return &Storage{
+		URLStorage: s,
+		threshold:  threshold,
+		log:        log.With(slog.String("component", "storage/slowlog")),
+	}
+}
+
+func (s *Storage) SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error) {
+	defer s.observe("SaveURL", alias, time.Now())
+
+	return s.URLStorage.SaveURL(urlToSave, alias, opts...)
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	defer s.observe("GetURL", alias, time.Now())
+
+	return s.URLStorage.GetURL(alias)
+}
+
+func (s *Storage) UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error) {
+	defer s.observe("UpsertURL", alias, time.Now())
+
+	return s.URLStorage.UpsertURL(alias, urlToSave, owner, opts...)
+}
+
+func (s *Storage) DeleteURL(alias string, owner string) error {
+	defer s.observe("DeleteURL", alias, time.Now())
+
+	return s.URLStorage.DeleteURL(alias, owner)
+}
+
+func (s *Storage) observe(operation string, alias string, start time.Time) {
+	if d := time.Since(start); d > s.threshold {
+		s.log.Warn("slow query",
+			slog.String("operation", operation),
+			slog.String("alias", alias),
+			slog.Duration("duration", d),
+			slog.Duration("threshold", s.threshold),
+		)
+	}
+}
@@ -0,0 +1,76 @@
+package slowlog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/slowlog"
+)
+
+type fakeStorage struct {
+	delay time.Duration
+}
+
+func (s fakeStorage) SaveURL(string, string, ...storage.SaveOption) (int64, error) {
+	time.Sleep(s.delay)
+	return 1, nil
+}
+
+func (s fakeStorage) GetURL(string) (string, error) {
+	time.Sleep(s.delay)
+	return "https://example.com", nil
+}
+
+func (s fakeStorage) UpsertURL(string, string, string, ...storage.SaveOption) (bool, error) {
+	time.Sleep(s.delay)
+	return true, nil
+}
+
+func (s fakeStorage) DeleteURL(string, string) error {
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestStorage(t *testing.T) {
+	cases := []struct {
+		name     string
+		delay    time.Duration
+		wantWarn bool
+	}{
+		{name: "Fast query", delay: 0},
+		{name: "Slow query", delay: 50 * time.Millisecond, wantWarn: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			log := slog.New(slog.NewJSONHandler(&out, nil))
+			s := slowlog.New(log, fakeStorage{delay: tc.delay}, 20*time.Millisecond)
+
+			url, err := s.GetURL("alias")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com", url)
+
+			if !tc.wantWarn {
+				assert.Empty(t, out.String())
+
+				return
+			}
+
+			assert.Contains(t, out.String(), `"level":"WARN"`)
+			assert.Contains(t, out.String(), `"msg":"slow query"`)
+			assert.Contains(t, out.String(), `"operation":"GetURL"`)
+		})
+	}
+}
@@ -1,27 +1,111 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/mattn/go-sqlite3"
+	"golang.org/x/exp/slog"
 
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"url-shortener/internal/lib/logger/sl"
+	"url-shortener/internal/lib/random"
 	"url-shortener/internal/storage"
 )
 
+// notExpired is the condition on url rows which have not expired,
+// its parameter is the current time.
+const notExpired = "(expires_at IS NULL OR expires_at > ?)"
+
+// unprotected is the condition on url rows whose target may be listed:
+// password-protected and one-time urls only reveal it on redirect.
+const unprotected = "password_hash = '' AND one_time = 0"
+
+// active is the condition on url rows past their activation time,
+// its parameter is the current time.
+const active = "(active_from IS NULL OR active_from <= ?)"
+
+// dayLayout is the format of days in collision_stats.
+const dayLayout = "2006-01-02"
+
 type Storage struct {
-	db *sql.DB
+	db    *sql.DB
+	clock clock.Clock
+	log   *slog.Logger
+
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+
+	// writes counts successful writes, see Writes.
+	writes atomic.Int64
+}
+
+// Option configures the storage.
+type Option func(*Storage)
+
+// WithClock sets the clock used for timestamps and expiration,
+// the system clock by default.
+func WithClock(c clock.Clock) Option {
+	return func(s *Storage) {
+		s.clock = c
+	}
+}
+
+// WithLogger sets the logger schema migrations are reported to.
+// They are not logged by default.
+func WithLogger(log *slog.Logger) Option {
+	return func(s *Storage) {
+		s.log = log.With(slog.String("component", "storage.sqlite"))
+	}
+}
+
+// WithConnMaxLifetime closes connections older than d, see
+// sql.DB.SetConnMaxLifetime. Zero, the default, keeps them forever.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(s *Storage) {
+		s.connMaxLifetime = d
+	}
+}
+
+// WithConnMaxIdleTime closes connections idle for longer than d, see
+// sql.DB.SetConnMaxIdleTime. Zero, the default, keeps them forever.
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return func(s *Storage) {
+		s.connMaxIdleTime = d
+	}
 }
 
-func New(storagePath string) (*Storage, error) {
+func New(storagePath string, opts ...Option) (*Storage, error) {
 	const op = "storage.sqlite.New"
 
+	s := &Storage{
+		clock: clock.Real{},
+		log:   slogdiscard.NewDiscardLogger(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
 	db, err := sql.Open("sqlite3", storagePath)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	db.SetConnMaxLifetime(s.connMaxLifetime)
+	db.SetConnMaxIdleTime(s.connMaxIdleTime)
+
 	stmt, err := db.Prepare(`
 	CREATE TABLE IF NOT EXISTS url(
 		id INTEGER PRIMARY KEY,
@@ -38,24 +122,300 @@ func New(storagePath string) (*Storage, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{db: db}, nil
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_url ON url(url)")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// columns added after the table was introduced
+	columns := []struct{ name, definition string }{
+		{"password_hash", "TEXT NOT NULL DEFAULT ''"},
+		{"failed_attempts", "INTEGER NOT NULL DEFAULT 0"},
+		{"one_time", "INTEGER NOT NULL DEFAULT 0"},
+		{"used", "INTEGER NOT NULL DEFAULT 0"},
+		{"created_at", "DATETIME"},
+		{"owner", "TEXT NOT NULL DEFAULT ''"},
+		{"clicks", "INTEGER NOT NULL DEFAULT 0"},
+		{"expires_at", "DATETIME"},
+		{"cache_ttl", "INTEGER NOT NULL DEFAULT 0"}, // seconds
+		{"active_from", "DATETIME"},
+		{"tags", "TEXT NOT NULL DEFAULT '[]'"},           // JSON array
+		{"redirect_rules", "TEXT NOT NULL DEFAULT '[]'"}, // JSON array
+	}
+	for _, c := range columns {
+		if err := addColumn(s.log, db, "url", c.name, c.definition); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	_, err = db.Exec("CREATE INDEX IF NOT EXISTS idx_url_created_at ON url(created_at)")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_log(
+		id INTEGER PRIMARY KEY,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		result TEXT NOT NULL,
+		created_at DATETIME NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_log(created_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS collision_stats(
+		day TEXT PRIMARY KEY,
+		count INTEGER NOT NULL DEFAULT 0);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS idempotency_key(
+		key TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		header TEXT NOT NULL,
+		body BLOB NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY(key, actor));
+	CREATE INDEX IF NOT EXISTS idx_idempotency_created_at ON idempotency_key(created_at);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := addColumn(s.log, db, "idempotency_key", "request_hash", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS alias_sequence(
+		name TEXT PRIMARY KEY,
+		value INTEGER NOT NULL);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS alias_length(
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		length INTEGER NOT NULL);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS alias_reservation(
+		alias TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at DATETIME NOT NULL);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS job_lock(
+		name TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		expires_at DATETIME NOT NULL);
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.db = db
+
+	return s, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
-	const op = "storage.sqlite.SaveURL"
+// ReadOnly reads aliases from the url table of a database it never
+// writes to, e.g. the one of an old deployment during a migration.
+type ReadOnly struct {
+	db *sql.DB
+}
+
+// OpenReadOnly opens the database at storagePath without creating it or
+// changing its schema. Only the alias and url columns are required.
+func OpenReadOnly(storagePath string) (*ReadOnly, error) {
+	const op = "storage.sqlite.OpenReadOnly"
+
+	if _, err := os.Stat(storagePath); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+storagePath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &ReadOnly{db: db}, nil
+}
+
+func (s *ReadOnly) GetURL(alias string) (string, error) {
+	const op = "storage.sqlite.ReadOnly.GetURL"
+
+	var resURL string
+
+	err := s.db.QueryRow("SELECT url FROM url WHERE alias = ?", alias).Scan(&resURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return "", dbError(op, "execute statement", err)
+	}
+
+	return resURL, nil
+}
+
+func (s *ReadOnly) Close() error {
+	return s.db.Close()
+}
+
+// addColumn adds the column to the table unless it already exists.
+// Each column is a schema migration named "table.column", its outcome
+// is logged to log.
+func addColumn(log *slog.Logger, db *sql.DB, table string, column string, definition string) error {
+	migration := slog.String("migration", table+"."+column)
+	start := time.Now()
+
+	var exists bool
+
+	err := db.QueryRow(
+		"SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?", table, column,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check column %s: %w", column, err)
+	}
+
+	if exists {
+		log.Debug("schema migration skipped", migration)
+
+		return nil
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		log.Error("schema migration failed", migration, sl.Err(err))
+
+		return fmt.Errorf("add column %s: %w", column, err)
+	}
+
+	log.Info("schema migration applied", migration, slog.Duration("duration", time.Since(start)))
+
+	return nil
+}
+
+// now returns the current time of the storage clock in UTC.
+func (s *Storage) now() time.Time {
+	return s.clock.Now().UTC()
+}
+
+// Stats returns the connection pool statistics of the database.
+func (s *Storage) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Writes returns the number of urls saved or deleted since the storage was opened.
+func (s *Storage) Writes() int64 {
+	return s.writes.Load()
+}
+
+// Optimize rebuilds the database file to reclaim the space left by deleted
+// rows and refreshes the query planner statistics.
+func (s *Storage) Optimize(ctx context.Context) error {
+	const op = "storage.sqlite.Optimize"
+
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("%s: vacuum: %w", op, err)
+	}
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES(?, ?)")
+	if _, err := s.db.ExecContext(ctx, "PRAGMA optimize"); err != nil {
+		return fmt.Errorf("%s: optimize: %w", op, err)
+	}
+
+	return nil
+}
+
+// Checkpoint moves the WAL content into the database file and truncates the WAL.
+// It is a no-op if the database is not in WAL mode.
+func (s *Storage) Checkpoint() error {
+	const op = "storage.sqlite.Checkpoint"
+
+	var busy, logFrames, checkpointed int
+
+	err := s.db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if busy != 0 {
+		return fmt.Errorf("%s: database is busy, checkpoint is incomplete", op)
+	}
+
+	return nil
+}
+
+// Ping checks that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "storage.sqlite.Ping"
+
+	if err := s.db.PingContext(ctx); err != nil {
+		return dbError(op, "ping", err)
+	}
+
+	return nil
+}
+
+// Close checkpoints the WAL and closes the database.
+func (s *Storage) Close() error {
+	const op = "storage.sqlite.Close"
+
+	checkpointErr := s.Checkpoint()
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if checkpointErr != nil {
+		return fmt.Errorf("%s: %w", op, checkpointErr)
 	}
 
-	res, err := stmt.Exec(urlToSave, alias)
+	return nil
+}
+
+func (s *Storage) SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error) {
+	const op = "storage.sqlite.SaveURL"
+
+	o := storage.NewSaveOptions(opts...)
+
+	res, err := s.insertURL(s.db, urlToSave, alias, o)
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
-			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+			return 0, storage.NewError(op, storage.KindExists, storage.ErrURLExists)
+		}
+		if errors.Is(err, storage.ErrAliasReserved) {
+			return 0, storage.NewError(op, storage.KindExists, err)
+		}
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			return 0, storage.NewError(op, storage.KindForbidden, err)
 		}
 
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return 0, dbError(op, "execute statement", err)
 	}
 
 	id, err := res.LastInsertId()
@@ -63,30 +423,1568 @@ func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
 		return 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
 	}
 
+	s.writes.Add(1)
+
 	return id, nil
 }
 
+// execer is *sql.DB or *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Conditions of inserting a url. Checking them in the insert statement
+// keeps concurrent saves from getting past them.
+const (
+	// notReserved takes the alias, the owner and the current time: the
+	// alias must not be reserved by someone else.
+	notReserved = `NOT EXISTS (
+		SELECT 1 FROM alias_reservation WHERE alias = ? AND owner != ? AND expires_at > ?)`
+	// underQuota takes the quota, the owner and the quota again: the
+	// owner must own fewer urls than the quota, if any.
+	underQuota = `(? <= 0 OR (SELECT COUNT(*) FROM url WHERE owner = ?) < ?)`
+)
+
+// insertURL fails with storage.ErrAliasReserved if alias is reserved
+// by someone other than the owner of the url, and with
+// storage.ErrQuotaExceeded if the owner has used up its quota.
+func (s *Storage) insertURL(db execer, urlToSave string, alias string, o storage.SaveOptions) (sql.Result, error) {
+	tags, err := encodeTags(o.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := encodeRedirectRules(o.RedirectRules)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+
+	res, err := db.Exec(`
+	INSERT INTO url(url, alias, password_hash, one_time, owner, created_at, expires_at, cache_ttl, active_from, tags, redirect_rules)
+	SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+	WHERE `+notReserved+` AND `+underQuota,
+		urlToSave, alias, o.PasswordHash, o.OneTime, o.Owner, now, o.ExpiresAt, cacheTTLSeconds(o.CacheTTL), o.ActiveFrom, tags, rules,
+		alias, o.Owner, now, o.Quota, o.Owner, o.Quota,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, s.insertBlocked(db, o.Owner, o.Quota)
+	}
+
+	return res, nil
+}
+
+// insertBlocked returns which condition prevented an insert.
+func (s *Storage) insertBlocked(db execer, owner string, quota int64) error {
+	if quota <= 0 {
+		return storage.ErrAliasReserved
+	}
+
+	var n int64
+	if err := db.QueryRow("SELECT COUNT(*) FROM url WHERE owner = ?", owner).Scan(&n); err != nil {
+		return err
+	}
+	if n >= quota {
+		return storage.ErrQuotaExceeded
+	}
+
+	return storage.ErrAliasReserved
+}
+
+// uniqueTags returns tags sorted and without duplicates.
+func uniqueTags(tags []string) []string {
+	set := make(map[string]bool, len(tags))
+	unique := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		if !set[tag] {
+			set[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+
+	sort.Strings(unique)
+
+	return unique
+}
+
+// encodeTags returns tags as a sorted JSON array without duplicates.
+func encodeTags(tags []string) (string, error) {
+	b, err := json.Marshal(uniqueTags(tags))
+	if err != nil {
+		return "", fmt.Errorf("encode tags: %w", err)
+	}
+
+	return string(b), nil
+}
+
+func decodeTags(raw string) ([]string, error) {
+	var tags []string
+	if err := json.Unmarshal([]byte(raw), &tags); err != nil {
+		return nil, fmt.Errorf("decode tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func encodeRedirectRules(rules []storage.RedirectRule) (string, error) {
+	if len(rules) == 0 {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		return "", fmt.Errorf("encode redirect rules: %w", err)
+	}
+
+	return string(b), nil
+}
+
+// maxSequenceSkips bounds the number of sequence values
+// SaveSequentialURL skips for a single url.
+const maxSequenceSkips = 100
+
+// SaveSequentialURL saves urlToSave under the alias encode returns for
+// the next value of the alias sequence, starting from 1. The value is
+// claimed and the url inserted in one transaction, so concurrent callers
+// never share a value. Values encode rejects, or whose alias is already
+// taken, e.g. by a custom alias, are skipped.
+func (s *Storage) SaveSequentialURL(
+	urlToSave string,
+	encode func(n int64) (alias string, ok bool),
+	opts ...storage.SaveOption,
+) (alias string, id int64, err error) {
+	const op = "storage.sqlite.SaveSequentialURL"
+
+	o := storage.NewSaveOptions(opts...)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", 0, dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for skipped := 0; ; skipped++ {
+		if skipped == maxSequenceSkips {
+			return "", 0, fmt.Errorf("%s: %d sequence values skipped", op, skipped)
+		}
+
+		var n int64
+
+		err = tx.QueryRow(`
+		INSERT INTO alias_sequence(name, value) VALUES('url', 1)
+		ON CONFLICT(name) DO UPDATE SET value = value + 1
+		RETURNING value`,
+		).Scan(&n)
+		if err != nil {
+			return "", 0, dbError(op, "claim sequence value", err)
+		}
+
+		var ok bool
+
+		alias, ok = encode(n)
+		if !ok {
+			continue
+		}
+
+		res, err := s.insertURL(tx, urlToSave, alias, o)
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			continue
+		}
+		if errors.Is(err, storage.ErrAliasReserved) {
+			continue
+		}
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			return "", 0, storage.NewError(op, storage.KindForbidden, err)
+		}
+		if err != nil {
+			return "", 0, dbError(op, "insert", err)
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return "", 0, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+		}
+
+		break
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", 0, dbError(op, "commit", err)
+	}
+
+	s.writes.Add(1)
+
+	return alias, id, nil
+}
+
 func (s *Storage) GetURL(alias string) (string, error) {
 	const op = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = ?")
+	// used one-time and expired aliases are gone for good
+	stmt, err := s.db.Prepare("SELECT url, active_from FROM url WHERE alias = ? AND used = 0 AND " + notExpired)
 	if err != nil {
-		return "", fmt.Errorf("%s: prepare statement: %w", op, err)
+		return "", dbError(op, "prepare statement", err)
 	}
 
-	var resURL string
+	var (
+		resURL     string
+		activeFrom sql.NullTime
+	)
+
+	now := s.now()
 
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	err = stmt.QueryRow(alias, now).Scan(&resURL, &activeFrom)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", storage.ErrURLNotFound
+			return "", storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
 		}
 
-		return "", fmt.Errorf("%s: execute statement: %w", op, err)
+		return "", dbError(op, "execute statement", err)
+	}
+
+	if activeFrom.Valid && activeFrom.Time.After(now) {
+		return "", storage.NewError(op, storage.KindNotFound, storage.ErrURLNotActive)
 	}
 
 	return resURL, nil
 }
 
-// TODO: implement method
-// func (s *Storage) DeleteURL(alias string) error
+// GetURLs returns urls for the given aliases. Aliases which are not found,
+// as well as password-protected and one-time ones, are absent from the result.
+func (s *Storage) GetURLs(aliases []string) (map[string]string, error) {
+	const op = "storage.sqlite.GetURLs"
+
+	res := make(map[string]string, len(aliases))
+
+	if len(aliases) == 0 {
+		return res, nil
+	}
+
+	placeholders := strings.Repeat("?, ", len(aliases)-1) + "?"
+
+	now := s.now()
+
+	args := make([]interface{}, 0, len(aliases)+2)
+	args = append(args, now, now)
+	for _, alias := range aliases {
+		args = append(args, alias)
+	}
+
+	stmt, err := s.db.Prepare(
+		"SELECT alias, url FROM url WHERE used = 0 AND " + unprotected + " AND " + notExpired + " AND " + active + " AND alias IN (" + placeholders + ")",
+	)
+	if err != nil {
+		return nil, dbError(op, "prepare statement", err)
+	}
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var alias, url string
+
+		if err := rows.Scan(&alias, &url); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		res[alias] = url
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// ConsumeOneTime marks a one-time alias used and returns its url.
+// Only one of concurrent callers gets the url, others get storage.ErrURLGone.
+// oneTime is false for regular aliases, which are left intact.
+func (s *Storage) ConsumeOneTime(alias string) (url string, oneTime bool, err error) {
+	const op = "storage.sqlite.ConsumeOneTime"
+
+	err = s.db.QueryRow(
+		"UPDATE url SET used = 1 WHERE alias = ? AND one_time = 1 AND used = 0 AND "+notExpired+" AND "+active+" RETURNING url",
+		alias, s.now(), s.now(),
+	).Scan(&url)
+	if err == nil {
+		s.writes.Add(1)
+
+		return url, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", false, dbError(op, "execute statement", err)
+	}
+
+	var used bool
+
+	err = s.db.QueryRow("SELECT one_time, used FROM url WHERE alias = ?", alias).Scan(&oneTime, &used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return "", false, dbError(op, "execute statement", err)
+	}
+
+	if oneTime && used {
+		return "", true, storage.NewError(op, storage.KindGone, storage.ErrURLGone)
+	}
+
+	return "", false, nil
+}
+
+// GetPasswordHash returns the password hash of the alias
+// or an empty string if the alias is not protected.
+func (s *Storage) GetPasswordHash(alias string) (string, error) {
+	const op = "storage.sqlite.GetPasswordHash"
+
+	var hash string
+
+	err := s.db.QueryRow("SELECT password_hash FROM url WHERE alias = ?", alias).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return "", dbError(op, "execute statement", err)
+	}
+
+	return hash, nil
+}
+
+// IsProtected reports whether the alias is password-protected or
+// one-time, so its target must only be revealed on redirect.
+func (s *Storage) IsProtected(alias string) (bool, error) {
+	const op = "storage.sqlite.IsProtected"
+
+	var protected bool
+
+	err := s.db.QueryRow("SELECT NOT ("+unprotected+") FROM url WHERE alias = ?", alias).Scan(&protected)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return false, dbError(op, "execute statement", err)
+	}
+
+	return protected, nil
+}
+
+// RecordFailedAttempt counts a wrong password entered for the alias
+// and returns the number of failed attempts so far.
+func (s *Storage) RecordFailedAttempt(alias string) (int64, error) {
+	const op = "storage.sqlite.RecordFailedAttempt"
+
+	var attempts int64
+
+	err := s.db.QueryRow(
+		"UPDATE url SET failed_attempts = failed_attempts + 1 WHERE alias = ? RETURNING failed_attempts", alias,
+	).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	return attempts, nil
+}
+
+// URLsCreated returns urls created within [since, until] ordered by
+// creation time. Zero since or until leaves the range open on that side;
+// urls without the creation time are only returned if both are zero.
+// Password-protected and one-time urls are left out.
+func (s *Storage) URLsCreated(since time.Time, until time.Time, tag string, limit int, offset int) ([]storage.URL, error) {
+	const op = "storage.sqlite.URLsCreated"
+
+	conds := []string{unprotected}
+	var args []interface{}
+
+	if !since.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, since.UTC())
+	}
+	if !until.IsZero() {
+		conds = append(conds, "created_at <= ?")
+		args = append(args, until.UTC())
+	}
+	if tag != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM json_each(url.tags) WHERE value = ?)")
+		args = append(args, tag)
+	}
+
+	query := "SELECT id, alias, url, created_at, tags FROM url WHERE " + strings.Join(conds, " AND ")
+
+	query += " ORDER BY created_at, id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	urls, err := scanURLs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// AliasesMatching returns up to limit stored aliases equal to one of
+// exact or starting with one of prefixes, case-sensitively.
+func (s *Storage) AliasesMatching(exact []string, prefixes []string, limit int) ([]string, error) {
+	const op = "storage.sqlite.AliasesMatching"
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	if len(exact) > 0 {
+		conds = append(conds, "alias IN ("+strings.Repeat("?, ", len(exact)-1)+"?)")
+		for _, alias := range exact {
+			args = append(args, alias)
+		}
+	}
+	for _, prefix := range prefixes {
+		conds = append(conds, "substr(alias, 1, length(?)) = ?")
+		args = append(args, prefix, prefix)
+	}
+
+	aliases := make([]string, 0)
+
+	if len(conds) == 0 {
+		return aliases, nil
+	}
+
+	args = append(args, limit)
+
+	rows, err := s.db.Query(
+		"SELECT alias FROM url WHERE "+strings.Join(conds, " OR ")+" ORDER BY alias LIMIT ?", args...,
+	)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// SearchAliases returns up to limit urls whose alias contains query,
+// case-insensitively. Aliases starting with query come first,
+// so that it may be used for autocomplete.
+func (s *Storage) SearchAliases(query string, limit int) ([]storage.URL, error) {
+	const op = "storage.sqlite.SearchAliases"
+
+	escaped := escapeLike(query)
+
+	rows, err := s.db.Query(`
+	SELECT id, alias, url, created_at, tags
+	FROM url
+	WHERE alias LIKE ? ESCAPE '\'
+	ORDER BY alias LIKE ? ESCAPE '\' DESC, alias
+	LIMIT ?`,
+		"%"+escaped+"%", escaped+"%", limit,
+	)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	urls, err := scanURLs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s, so that it is matched literally
+// with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// scanURLs reads and closes rows of id, alias, url, created_at and tags.
+func scanURLs(rows *sql.Rows) ([]storage.URL, error) {
+	defer func() { _ = rows.Close() }()
+
+	urls := make([]storage.URL, 0)
+
+	for rows.Next() {
+		var (
+			u         storage.URL
+			createdAt sql.NullTime
+			tags      string
+		)
+
+		if err := rows.Scan(&u.ID, &u.Alias, &u.URL, &createdAt, &tags); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		if createdAt.Valid {
+			u.CreatedAt = &createdAt.Time
+		}
+
+		var err error
+		if u.Tags, err = decodeTags(tags); err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// GetAliasesByURL returns all aliases pointing to urlToFind.
+func (s *Storage) GetAliasesByURL(urlToFind string) ([]string, error) {
+	const op = "storage.sqlite.GetAliasesByURL"
+
+	stmt, err := s.db.Prepare("SELECT alias FROM url WHERE url = ? ORDER BY id")
+	if err != nil {
+		return nil, dbError(op, "prepare statement", err)
+	}
+
+	rows, err := stmt.Query(urlToFind)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var aliases []string
+
+	for rows.Next() {
+		var alias string
+
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// UpsertURL creates the alias or, if it exists and is owned by owner,
+// points it to urlToSave. It returns storage.ErrNotOwner if the alias
+// belongs to another user and storage.ErrAliasReserved if it is reserved
+// by another user. Of opts only the cache TTL, which is replaced
+// on update as well, and the quota, which only limits creating, apply.
+func (s *Storage) UpsertURL(
+	alias string,
+	urlToSave string,
+	owner string,
+	opts ...storage.SaveOption,
+) (created bool, err error) {
+	const op = "storage.sqlite.UpsertURL"
+
+	o := storage.NewSaveOptions(opts...)
+	cacheTTL := cacheTTLSeconds(o.CacheTTL)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := s.now()
+
+	res, err := tx.Exec(`
+	INSERT INTO url(url, alias, owner, created_at, cache_ttl)
+	SELECT ?, ?, ?, ?, ?
+	WHERE `+notReserved+` AND `+underQuota+`
+	ON CONFLICT(alias) DO NOTHING`,
+		urlToSave, alias, owner, now, cacheTTL,
+		alias, owner, now, o.Quota, owner, o.Quota,
+	)
+	if err != nil {
+		return false, dbError(op, "insert", err)
+	}
+
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+
+	if inserted == 0 {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM url WHERE alias = ?)", alias).Scan(&exists); err != nil {
+			return false, dbError(op, "check alias", err)
+		}
+
+		if !exists {
+			err := s.insertBlocked(tx, owner, o.Quota)
+			switch {
+			case errors.Is(err, storage.ErrAliasReserved):
+				return false, storage.NewError(op, storage.KindExists, err)
+			case errors.Is(err, storage.ErrQuotaExceeded):
+				return false, storage.NewError(op, storage.KindForbidden, err)
+			default:
+				return false, dbError(op, "check insert", err)
+			}
+		}
+
+		res, err = tx.Exec(
+			"UPDATE url SET url = ?, cache_ttl = ? WHERE alias = ? AND owner = ?", urlToSave, cacheTTL, alias, owner,
+		)
+		if err != nil {
+			return false, dbError(op, "update", err)
+		}
+
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return false, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+		}
+
+		if updated == 0 {
+			return false, storage.NewError(op, storage.KindForbidden, storage.ErrNotOwner)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, dbError(op, "commit", err)
+	}
+
+	s.writes.Add(1)
+
+	return inserted == 1, nil
+}
+
+// CountByOwner returns the number of urls saved by owner.
+func (s *Storage) CountByOwner(owner string) (int64, error) {
+	const op = "storage.sqlite.CountByOwner"
+
+	stmt, err := s.db.Prepare("SELECT COUNT(*) FROM url WHERE owner = ?")
+	if err != nil {
+		return 0, dbError(op, "prepare statement", err)
+	}
+
+	var n int64
+
+	if err := stmt.QueryRow(owner).Scan(&n); err != nil {
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	return n, nil
+}
+
+// DeleteURL deletes the alias if it is owned by owner. It returns
+// storage.ErrNotOwner if the alias belongs to another user.
+func (s *Storage) DeleteURL(alias string, owner string) error {
+	const op = "storage.sqlite.DeleteURL"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec("DELETE FROM url WHERE alias = ? AND owner = ?", alias, owner)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+
+	if n == 0 {
+		return notOwned(tx, op, alias)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbError(op, "commit", err)
+	}
+
+	s.writes.Add(1)
+
+	return nil
+}
+
+// notOwned tells why a change of alias restricted to its owner matched
+// no rows: storage.ErrURLNotFound if there is no such alias, and
+// storage.ErrNotOwner if it belongs to another user.
+func notOwned(db execer, op string, alias string) error {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM url WHERE alias = ?)", alias).Scan(&exists); err != nil {
+		return dbError(op, "check alias", err)
+	}
+
+	if !exists {
+		return storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+
+	return storage.NewError(op, storage.KindForbidden, storage.ErrNotOwner)
+}
+
+// PurgeExpired deletes the urls which have expired and returns their number.
+// They are not served anyway, so this only reclaims their rows.
+func (s *Storage) PurgeExpired(ctx context.Context) (int64, error) {
+	const op = "storage.sqlite.PurgeExpired"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM url WHERE expires_at IS NOT NULL AND expires_at <= ?", s.now())
+	if err != nil {
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+
+	s.writes.Add(n)
+
+	return n, nil
+}
+
+// ReserveAlias holds alias for owner until ttl passes, so that saving
+// it fails with storage.ErrAliasReserved for anyone else. Reserving
+// it again extends the hold. It fails with storage.ErrURLExists if a url
+// is saved under alias, and with storage.ErrAliasReserved if another
+// owner holds it.
+func (s *Storage) ReserveAlias(alias string, owner string, ttl time.Duration) (expiresAt time.Time, err error) {
+	const op = "storage.sqlite.ReserveAlias"
+
+	now := s.now()
+	expiresAt = now.Add(ttl)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return time.Time{}, dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("DELETE FROM alias_reservation WHERE expires_at <= ?", now); err != nil {
+		return time.Time{}, dbError(op, "delete expired reservations", err)
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM url WHERE alias = ?)", alias).Scan(&exists)
+	if err != nil {
+		return time.Time{}, dbError(op, "check url", err)
+	}
+	if exists {
+		return time.Time{}, storage.NewError(op, storage.KindExists, storage.ErrURLExists)
+	}
+
+	res, err := tx.Exec(`
+	INSERT INTO alias_reservation(alias, owner, expires_at) VALUES(?, ?, ?)
+	ON CONFLICT(alias) DO UPDATE SET expires_at = excluded.expires_at
+	WHERE alias_reservation.owner = excluded.owner`,
+		alias, owner, expiresAt,
+	)
+	if err != nil {
+		return time.Time{}, dbError(op, "insert reservation", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return time.Time{}, storage.NewError(op, storage.KindExists, storage.ErrAliasReserved)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, dbError(op, "commit", err)
+	}
+
+	return expiresAt, nil
+}
+
+// ReleaseAlias drops the reservation of alias by owner. It fails with
+// storage.ErrReservationNotFound if owner doesn't hold alias.
+func (s *Storage) ReleaseAlias(alias string, owner string) error {
+	const op = "storage.sqlite.ReleaseAlias"
+
+	res, err := s.db.Exec(
+		"DELETE FROM alias_reservation WHERE alias = ? AND owner = ? AND expires_at > ?",
+		alias, owner, s.now(),
+	)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return storage.NewError(op, storage.KindNotFound, storage.ErrReservationNotFound)
+	}
+
+	return nil
+}
+
+// UpdateTags adds and removes tags of alias owned by owner and returns its
+// tags, sorted. It fails with storage.ErrTooManyTags if alias would end up
+// with more than maxTags tags and with storage.ErrNotOwner if it belongs
+// to another user.
+func (s *Storage) UpdateTags(alias string, owner string, add []string, remove []string, maxTags int) ([]string, error) {
+	const op = "storage.sqlite.UpdateTags"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var raw string
+
+	err = tx.QueryRow("SELECT tags FROM url WHERE alias = ? AND owner = ?", alias, owner).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notOwned(tx, op, alias)
+	}
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	current, err := decodeTags(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removed[tag] = true
+	}
+
+	tags := make([]string, 0, len(current)+len(add))
+	for _, tag := range append(current, add...) {
+		if !removed[tag] {
+			tags = append(tags, tag)
+		}
+	}
+
+	tags = uniqueTags(tags)
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrTooManyTags)
+	}
+
+	raw, err = encodeTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec("UPDATE url SET tags = ? WHERE alias = ?", raw, alias); err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(op, "commit", err)
+	}
+
+	s.writes.Add(1)
+
+	return tags, nil
+}
+
+func (s *Storage) AuditLog(entry storage.AuditEntry) error {
+	const op = "storage.sqlite.AuditLog"
+
+	stmt, err := s.db.Prepare(
+		"INSERT INTO audit_log(actor, action, alias, result, created_at) VALUES(?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return dbError(op, "prepare statement", err)
+	}
+
+	_, err = stmt.Exec(entry.Actor, entry.Action, entry.Alias, entry.Result, entry.CreatedAt)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	return nil
+}
+
+// PurgeAudit deletes the audit entries created before the given time
+// and returns their number.
+func (s *Storage) PurgeAudit(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.sqlite.PurgeAudit"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM audit_log WHERE created_at < ?", before)
+	if err != nil {
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// AuditEntries returns the latest audit entries, newest first.
+func (s *Storage) AuditEntries(limit int) ([]storage.AuditEntry, error) {
+	entries, _, err := s.QueryAudit(storage.AuditFilter{}, limit, 0)
+
+	return entries, err
+}
+
+// QueryAudit returns a page of the audit entries matching filter, newest
+// first, and the total number of matching entries.
+func (s *Storage) QueryAudit(filter storage.AuditFilter, limit int, offset int) ([]storage.AuditEntry, int, error) {
+	const op = "storage.sqlite.QueryAudit"
+
+	const where = "WHERE (? = '' OR actor = ?) AND (? = '' OR action = ?)"
+
+	args := []interface{}{filter.Actor, filter.Actor, filter.Action, filter.Action}
+
+	var total int
+
+	err := s.db.QueryRow("SELECT COUNT(*) FROM audit_log "+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, dbError(op, "count entries", err)
+	}
+
+	stmt, err := s.db.Prepare(`
+	SELECT id, actor, action, alias, result, created_at
+	FROM audit_log
+	` + where + `
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?`)
+	if err != nil {
+		return nil, 0, dbError(op, "prepare statement", err)
+	}
+
+	rows, err := stmt.Query(append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, dbError(op, "execute statement", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]storage.AuditEntry, 0)
+
+	for rows.Next() {
+		var e storage.AuditEntry
+
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Alias, &e.Result, &e.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return entries, total, nil
+}
+
+// RecordCollision increments the collision counter of the UTC day of at.
+func (s *Storage) RecordCollision(at time.Time) error {
+	const op = "storage.sqlite.RecordCollision"
+
+	stmt, err := s.db.Prepare(`
+	INSERT INTO collision_stats(day, count) VALUES(?, 1)
+	ON CONFLICT(day) DO UPDATE SET count = count + 1`)
+	if err != nil {
+		return dbError(op, "prepare statement", err)
+	}
+
+	if _, err := stmt.Exec(at.UTC().Format(dayLayout)); err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	return nil
+}
+
+// Collisions returns the collision counters of the days since the UTC day
+// of since, oldest first. Days without collisions are omitted.
+func (s *Storage) Collisions(since time.Time) ([]storage.DailyCount, error) {
+	const op = "storage.sqlite.Collisions"
+
+	stmt, err := s.db.Prepare("SELECT day, count FROM collision_stats WHERE day >= ? ORDER BY day")
+	if err != nil {
+		return nil, dbError(op, "prepare statement", err)
+	}
+
+	rows, err := stmt.Query(since.UTC().Format(dayLayout))
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make([]storage.DailyCount, 0)
+
+	for rows.Next() {
+		var c storage.DailyCount
+
+		if err := rows.Scan(&c.Day, &c.Count); err != nil {
+			return nil, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return counts, nil
+}
+
+// GetCacheTTL returns how long clients may cache the redirect of alias.
+func (s *Storage) GetCacheTTL(alias string) (time.Duration, error) {
+	const op = "storage.sqlite.GetCacheTTL"
+
+	stmt, err := s.db.Prepare("SELECT cache_ttl FROM url WHERE alias = ? AND used = 0 AND " + notExpired)
+	if err != nil {
+		return 0, dbError(op, "prepare statement", err)
+	}
+
+	var seconds int64
+
+	err = stmt.QueryRow(alias, s.now()).Scan(&seconds)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+		}
+
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ExpiresAt returns when alias expires, the zero time if it doesn't.
+func (s *Storage) ExpiresAt(alias string) (time.Time, error) {
+	const op = "storage.sqlite.ExpiresAt"
+
+	var expiresAt sql.NullTime
+
+	err := s.db.QueryRow("SELECT expires_at FROM url WHERE alias = ?", alias).Scan(&expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+	if err != nil {
+		return time.Time{}, dbError(op, "execute statement", err)
+	}
+
+	return expiresAt.Time, nil
+}
+
+// GetRedirectRules returns the redirect rules of alias, in the order
+// they are evaluated.
+func (s *Storage) GetRedirectRules(alias string) ([]storage.RedirectRule, error) {
+	const op = "storage.sqlite.GetRedirectRules"
+
+	stmt, err := s.db.Prepare("SELECT redirect_rules FROM url WHERE alias = ? AND used = 0 AND " + notExpired)
+	if err != nil {
+		return nil, dbError(op, "prepare statement", err)
+	}
+
+	var raw string
+
+	err = stmt.QueryRow(alias, s.now()).Scan(&raw)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+		}
+
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	var rules []storage.RedirectRule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("%s: decode redirect rules: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// cacheTTLSeconds converts ttl to the cache_ttl column value.
+func cacheTTLSeconds(ttl time.Duration) int64 {
+	return int64(ttl / time.Second)
+}
+
+// MostClicked returns up to limit clicked urls which can be redirected to,
+// most clicked first. One-time and expiring urls are left out, so that
+// their cached copies can't outlive them.
+func (s *Storage) MostClicked(ctx context.Context, limit int) ([]storage.URL, error) {
+	const op = "storage.sqlite.MostClicked"
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, alias, url, created_at, tags
+	FROM url
+	WHERE clicks > 0 AND one_time = 0 AND used = 0 AND expires_at IS NULL
+	ORDER BY clicks DESC, alias
+	LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, dbError(op, "execute statement", err)
+	}
+
+	urls, err := scanURLs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// RecordClick increments the number of redirects of alias.
+func (s *Storage) RecordClick(alias string) error {
+	const op = "storage.sqlite.RecordClick"
+
+	stmt, err := s.db.Prepare("UPDATE url SET clicks = clicks + 1 WHERE alias = ?")
+	if err != nil {
+		return dbError(op, "prepare statement", err)
+	}
+
+	res, err := stmt.Exec(alias)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.NewError(op, storage.KindNotFound, storage.ErrURLNotFound)
+	}
+
+	return nil
+}
+
+// SetClicks replaces the click counters of the aliases in one transaction,
+// e.g. with the counts of an external analytics source. Aliases which don't
+// exist are skipped and returned.
+func (s *Storage) SetClicks(ctx context.Context, counts []storage.AliasClicks) (notFound []string, err error) {
+	const op = "storage.sqlite.SetClicks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, dbError(op, "begin transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, "UPDATE url SET clicks = ? WHERE alias = ?")
+	if err != nil {
+		return nil, dbError(op, "prepare statement", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range counts {
+		res, err := stmt.ExecContext(ctx, c.Clicks, c.Alias)
+		if err != nil {
+			return nil, dbError(op, "execute statement", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if n == 0 {
+			notFound = append(notFound, c.Alias)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, dbError(op, "commit", err)
+	}
+
+	s.writes.Add(1)
+
+	return notFound, nil
+}
+
+// Summary returns the totals of all urls and the topN most clicked aliases,
+// most clicked first.
+func (s *Storage) Summary(ctx context.Context, topN int) (storage.Summary, error) {
+	const op = "storage.sqlite.Summary"
+
+	var sum storage.Summary
+
+	err := s.db.QueryRowContext(ctx, `
+	SELECT COUNT(*), COALESCE(SUM(clicks), 0), COUNT(CASE WHEN created_at >= ? THEN 1 END)
+	FROM url`,
+		s.now().Add(-24*time.Hour),
+	).Scan(&sum.TotalURLs, &sum.TotalClicks, &sum.CreatedLastDay)
+	if err != nil {
+		return storage.Summary{}, fmt.Errorf("%s: totals: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT alias, clicks
+	FROM url
+	WHERE clicks > 0
+	ORDER BY clicks DESC, alias
+	LIMIT ?`,
+		topN,
+	)
+	if err != nil {
+		return storage.Summary{}, fmt.Errorf("%s: top: %w", op, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	sum.Top = make([]storage.AliasClicks, 0, topN)
+
+	for rows.Next() {
+		var c storage.AliasClicks
+
+		if err := rows.Scan(&c.Alias, &c.Clicks); err != nil {
+			return storage.Summary{}, fmt.Errorf("%s: scan row: %w", op, err)
+		}
+
+		sum.Top = append(sum.Top, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return storage.Summary{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sum, nil
+}
+
+// Hint returns an actionable message for a common cause of err returned
+// by New, such as a missing directory, missing permissions or a read-only
+// filesystem. It returns an empty string if the cause is unknown.
+func Hint(storagePath string, err error) string {
+	path := filePath(storagePath)
+	dir := filepath.Dir(path)
+
+	if _, statErr := os.Stat(dir); errors.Is(statErr, fs.ErrNotExist) {
+		return fmt.Sprintf("directory %q does not exist, create it or enable create_dirs", dir)
+	}
+
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return ""
+	}
+
+	switch {
+	case errors.Is(sqliteErr.SystemErrno, syscall.EROFS):
+		return fmt.Sprintf("filesystem of %q is read-only, mount it writable or change storage_path", path)
+	case errors.Is(sqliteErr.SystemErrno, syscall.EACCES), errors.Is(sqliteErr.SystemErrno, syscall.EPERM):
+		return fmt.Sprintf("no permission to open %q, make it and directory %q writable by the service user", path, dir)
+	case sqliteErr.Code == sqlite3.ErrReadonly:
+		return fmt.Sprintf("%q is read-only, make it and directory %q writable by the service user", path, dir)
+	case sqliteErr.Code == sqlite3.ErrCantOpen:
+		return fmt.Sprintf("can't open %q, check that it is a file and directory %q is accessible", path, dir)
+	}
+
+	return ""
+}
+
+// CreateDir creates the parent directory of the storage file.
+func CreateDir(storagePath string) error {
+	const op = "storage.sqlite.CreateDir"
+
+	if err := os.MkdirAll(filepath.Dir(filePath(storagePath)), 0o750); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// filePath strips the "file:" scheme and the query of a storage path DSN.
+func filePath(storagePath string) string {
+	path := strings.TrimPrefix(storagePath, "file:")
+	path, _, _ = strings.Cut(path, "?")
+
+	return path
+}
+
+// ClaimKey claims the key of actor for the request identified by
+// requestHash, removing keys claimed before expiredBefore and keys still
+// in progress claimed before staleBefore, e.g. by a crashed process.
+// It fails with storage.ErrKeyClaimed if the key is claimed already.
+func (s *Storage) ClaimKey(
+	key string,
+	actor string,
+	requestHash string,
+	at time.Time,
+	expiredBefore time.Time,
+	staleBefore time.Time,
+) error {
+	const op = "storage.sqlite.ClaimKey"
+
+	if _, err := s.db.Exec(
+		"DELETE FROM idempotency_key WHERE created_at < ? OR (status = 0 AND created_at < ?)",
+		expiredBefore.UTC(), staleBefore.UTC(),
+	); err != nil {
+		return dbError(op, "delete expired", err)
+	}
+
+	res, err := s.db.Exec(`
+	INSERT INTO idempotency_key(key, actor, status, header, body, created_at, request_hash)
+	VALUES(?, ?, 0, '{}', x'', ?, ?)
+	ON CONFLICT(key, actor) DO NOTHING`,
+		key, actor, at.UTC(), requestHash,
+	)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return storage.NewError(op, storage.KindExists, storage.ErrKeyClaimed)
+	}
+
+	return nil
+}
+
+// ReleaseKey gives up the claim of the key of actor, unless its
+// response is stored already.
+func (s *Storage) ReleaseKey(key string, actor string) error {
+	const op = "storage.sqlite.ReleaseKey"
+
+	if _, err := s.db.Exec(
+		"DELETE FROM idempotency_key WHERE key = ? AND actor = ? AND status = 0", key, actor,
+	); err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	return nil
+}
+
+// StoredResponse returns the response saved for the key of actor
+// claimed no earlier than since. The status of a key still in
+// progress is zero.
+func (s *Storage) StoredResponse(key string, actor string, since time.Time) (storage.StoredResponse, error) {
+	const op = "storage.sqlite.StoredResponse"
+
+	stmt, err := s.db.Prepare(
+		"SELECT status, header, body, request_hash FROM idempotency_key WHERE key = ? AND actor = ? AND created_at >= ?",
+	)
+	if err != nil {
+		return storage.StoredResponse{}, dbError(op, "prepare statement", err)
+	}
+
+	var (
+		res    storage.StoredResponse
+		header string
+	)
+
+	err = stmt.QueryRow(key, actor, since.UTC()).Scan(&res.Status, &header, &res.Body, &res.RequestHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.StoredResponse{}, storage.NewError(op, storage.KindNotFound, storage.ErrKeyNotFound)
+		}
+
+		return storage.StoredResponse{}, dbError(op, "execute statement", err)
+	}
+
+	if err := json.Unmarshal([]byte(header), &res.Header); err != nil {
+		return storage.StoredResponse{}, fmt.Errorf("%s: decode header: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// StoreResponse saves the response for the key of actor claimed with
+// ClaimKey. It returns storage.ErrKeyNotFound if the claim is gone.
+func (s *Storage) StoreResponse(key string, actor string, res storage.StoredResponse) error {
+	const op = "storage.sqlite.StoreResponse"
+
+	header, err := json.Marshal(res.Header)
+	if err != nil {
+		return fmt.Errorf("%s: encode header: %w", op, err)
+	}
+
+	// a nil body would be stored as NULL
+	body := res.Body
+	if body == nil {
+		body = []byte{}
+	}
+
+	result, err := s.db.Exec(
+		"UPDATE idempotency_key SET status = ?, header = ?, body = ? WHERE key = ? AND actor = ? AND status = 0",
+		res.Status, string(header), body, key, actor,
+	)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return storage.NewError(op, storage.KindNotFound, storage.ErrKeyNotFound)
+	}
+
+	return nil
+}
+
+// dbError wraps err of the given step into a storage.Error, classifying
+// busy and locked database errors as transient.
+func dbError(op, step string, err error) error {
+	kind := storage.KindInternal
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrBusy, sqlite3.ErrLocked:
+			kind = storage.KindTransient
+		case sqlite3.ErrConstraint:
+			if sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+				sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+				kind = storage.KindExists
+			}
+		}
+	}
+
+	return storage.NewError(op+": "+step, kind, err)
+}
+
+// AliasLength returns the length of generated aliases saved with
+// SetAliasLength, zero if none was saved.
+func (s *Storage) AliasLength() (int, error) {
+	const op = "storage.sqlite.AliasLength"
+
+	var length int
+
+	err := s.db.QueryRow("SELECT length FROM alias_length WHERE id = 1").Scan(&length)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, dbError(op, "execute statement", err)
+	}
+
+	return length, nil
+}
+
+// SetAliasLength saves the length of generated aliases. The saved
+// length never shrinks, so that replicas growing it concurrently agree.
+func (s *Storage) SetAliasLength(length int) error {
+	const op = "storage.sqlite.SetAliasLength"
+
+	_, err := s.db.Exec(`
+	INSERT INTO alias_length(id, length) VALUES(1, ?)
+	ON CONFLICT(id) DO UPDATE SET length = MAX(length, excluded.length)`,
+		length,
+	)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	return nil
+}
+
+// lockOwnerSize is the length of the random token telling
+// lock holders apart.
+const lockOwnerSize = 16
+
+// TryLock takes the advisory lock name for ttl, so that a periodic job
+// runs on a single replica sharing the database at a time. It doesn't
+// wait: ok is false if the lock is held by someone else. A lock which
+// is neither extended nor released, e.g. by a crashed replica, is free
+// again after ttl.
+func (s *Storage) TryLock(name string, ttl time.Duration) (ok bool, lock storage.Lock, err error) {
+	const op = "storage.sqlite.TryLock"
+
+	if ttl <= 0 {
+		return false, nil, fmt.Errorf("%s: ttl must be positive, got %v", op, ttl)
+	}
+
+	owner := random.NewRandomString(lockOwnerSize)
+	now := s.now()
+
+	res, err := s.db.Exec(`
+	INSERT INTO job_lock(name, owner, expires_at) VALUES(?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET
+		owner = excluded.owner,
+		expires_at = excluded.expires_at
+	WHERE job_lock.expires_at <= ?`,
+		name, owner, now.Add(ttl), now,
+	)
+	if err != nil {
+		return false, nil, dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, nil, fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return false, nil, nil
+	}
+
+	return true, &jobLock{s: s, name: name, owner: owner}, nil
+}
+
+// jobLock is a lock taken with TryLock. The owner check keeps it from
+// touching a lock taken over after it expired.
+type jobLock struct {
+	s     *Storage
+	name  string
+	owner string
+}
+
+func (l *jobLock) Extend(ttl time.Duration) error {
+	const op = "storage.sqlite.jobLock.Extend"
+
+	now := l.s.now()
+
+	res, err := l.s.db.Exec(
+		"UPDATE job_lock SET expires_at = ? WHERE name = ? AND owner = ? AND expires_at > ?",
+		now.Add(ttl), l.name, l.owner, now,
+	)
+	if err != nil {
+		return dbError(op, "execute statement", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get affected rows: %w", op, err)
+	}
+	if n == 0 {
+		return storage.NewError(op, storage.KindNotFound, storage.ErrLockLost)
+	}
+
+	return nil
+}
+
+func (l *jobLock) Release() {
+	_, err := l.s.db.Exec("DELETE FROM job_lock WHERE name = ? AND owner = ?", l.name, l.owner)
+	if err != nil {
+		l.s.log.Error("failed to release lock", slog.String("name", l.name), sl.Err(err))
+	}
+}
@@ -0,0 +1,1419 @@
+package sqlite_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"url-shortener/internal/lib/base62"
+	"url-shortener/internal/lib/clock"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+)
+
+func newStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"))
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestNew_MigrationLogs(t *testing.T) {
+	migrations := func(logs *bytes.Buffer, msg string) []string {
+		var res []string
+
+		dec := json.NewDecoder(logs)
+		for dec.More() {
+			var entry struct {
+				Msg       string `json:"msg"`
+				Migration string `json:"migration"`
+				Error     string `json:"error"`
+			}
+			require.NoError(t, dec.Decode(&entry))
+
+			if entry.Msg == msg {
+				res = append(res, entry.Migration)
+			}
+		}
+
+		return res
+	}
+
+	newLogger := func(logs *bytes.Buffer) *slog.Logger {
+		return slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	var logs bytes.Buffer
+
+	s, err := sqlite.New(path, sqlite.WithLogger(newLogger(&logs)))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	applied := migrations(&logs, "schema migration applied")
+	assert.Contains(t, applied, "url.password_hash")
+	assert.Contains(t, applied, "url.cache_ttl")
+
+	logs.Reset()
+
+	s, err = sqlite.New(path, sqlite.WithLogger(newLogger(&logs)))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, applied, migrations(&logs, "schema migration skipped"))
+
+	t.Run("Failure", func(t *testing.T) {
+		// a database from before the first migration, opened read-only
+		path := filepath.Join(t.TempDir(), "old.db")
+
+		db, err := sql.Open("sqlite3", path)
+		require.NoError(t, err)
+		_, err = db.Exec(`
+		CREATE TABLE url(id INTEGER PRIMARY KEY, alias TEXT NOT NULL UNIQUE, url TEXT NOT NULL);
+		CREATE INDEX idx_alias ON url(alias);
+		CREATE INDEX idx_url ON url(url);
+		`)
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+
+		var logs bytes.Buffer
+
+		_, err = sqlite.New("file:"+path+"?mode=ro", sqlite.WithLogger(newLogger(&logs)))
+		require.Error(t, err)
+
+		assert.Equal(t, []string{"url.password_hash"}, migrations(&logs, "schema migration failed"))
+	})
+}
+
+func TestStorage_AuditLog(t *testing.T) {
+	s := newStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, s.AuditLog(storage.AuditEntry{
+		Actor: "admin", Action: "save", Alias: "first", Result: "success", CreatedAt: now.Add(-time.Minute),
+	}))
+	require.NoError(t, s.AuditLog(storage.AuditEntry{
+		Actor: "admin", Action: "delete", Alias: "first", Result: "success", CreatedAt: now,
+	}))
+
+	entries, err := s.AuditEntries(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "delete", entries[0].Action)
+	assert.Equal(t, "save", entries[1].Action)
+	assert.True(t, now.Equal(entries[0].CreatedAt))
+
+	entries, err = s.AuditEntries(1)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStorage_PurgeAudit(t *testing.T) {
+	s := newStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	require.NoError(t, s.AuditLog(storage.AuditEntry{
+		Actor: "admin", Action: "save", Alias: "old", Result: "success", CreatedAt: now.Add(-time.Hour),
+	}))
+	require.NoError(t, s.AuditLog(storage.AuditEntry{
+		Actor: "admin", Action: "save", Alias: "new", Result: "success", CreatedAt: now,
+	}))
+
+	n, err := s.PurgeAudit(context.Background(), now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	entries, err := s.AuditEntries(10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "new", entries[0].Alias)
+}
+
+func TestStorage_QueryAudit(t *testing.T) {
+	s := newStorage(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for i, e := range []storage.AuditEntry{
+		{Actor: "alice", Action: "save", Alias: "a"},
+		{Actor: "bob", Action: "save", Alias: "b"},
+		{Actor: "alice", Action: "delete", Alias: "a"},
+		{Actor: "alice", Action: "save", Alias: "c"},
+		{Actor: "bob", Action: "update", Alias: "b"},
+	} {
+		e.Result = "success"
+		e.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, s.AuditLog(e))
+	}
+
+	aliases := func(entries []storage.AuditEntry) []string {
+		res := make([]string, 0, len(entries))
+		for _, e := range entries {
+			res = append(res, e.Action+":"+e.Alias)
+		}
+
+		return res
+	}
+
+	cases := []struct {
+		name        string
+		filter      storage.AuditFilter
+		limit       int
+		offset      int
+		wantEntries []string
+		wantTotal   int
+	}{
+		{
+			name:        "All",
+			limit:       10,
+			wantEntries: []string{"update:b", "save:c", "delete:a", "save:b", "save:a"},
+			wantTotal:   5,
+		},
+		{
+			name:        "Actor",
+			filter:      storage.AuditFilter{Actor: "alice"},
+			limit:       10,
+			wantEntries: []string{"save:c", "delete:a", "save:a"},
+			wantTotal:   3,
+		},
+		{
+			name:        "Action",
+			filter:      storage.AuditFilter{Action: "save"},
+			limit:       10,
+			wantEntries: []string{"save:c", "save:b", "save:a"},
+			wantTotal:   3,
+		},
+		{
+			name:        "Actor and action",
+			filter:      storage.AuditFilter{Actor: "bob", Action: "save"},
+			limit:       10,
+			wantEntries: []string{"save:b"},
+			wantTotal:   1,
+		},
+		{
+			name:        "First page",
+			limit:       2,
+			wantEntries: []string{"update:b", "save:c"},
+			wantTotal:   5,
+		},
+		{
+			name:        "Last partial page",
+			limit:       2,
+			offset:      4,
+			wantEntries: []string{"save:a"},
+			wantTotal:   5,
+		},
+		{
+			name:        "Past the end",
+			limit:       2,
+			offset:      5,
+			wantEntries: []string{},
+			wantTotal:   5,
+		},
+		{
+			name:        "No match",
+			filter:      storage.AuditFilter{Actor: "carol"},
+			limit:       10,
+			wantEntries: []string{},
+			wantTotal:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entries, total, err := s.QueryAudit(tc.filter, tc.limit, tc.offset)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.wantEntries, aliases(entries))
+			assert.Equal(t, tc.wantTotal, total)
+		})
+	}
+}
+
+func TestStorage_Close_CheckpointsWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path + "?_journal_mode=WAL")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err := s.SaveURL("https://example.com", fmt.Sprintf("alias%d", i))
+		require.NoError(t, err)
+	}
+
+	info, err := os.Stat(path + "-wal")
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+
+	require.NoError(t, s.Checkpoint())
+
+	info, err = os.Stat(path + "-wal")
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	require.NoError(t, s.Close())
+
+	// the database is closed, so the checkpoint error must be reported
+	assert.Error(t, s.Close())
+}
+
+func TestStorage_GetURLs(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://a.com", "a")
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://b.com", "b")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://secret.com", "protected", storage.WithPasswordHash("hash"))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://once.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+
+	// protected targets are not revealed
+	urls, err := s.GetURLs([]string{"a", "b", "missing", "protected", "once"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "https://a.com", "b": "https://b.com"}, urls)
+
+	created, err := s.URLsCreated(time.Time{}, time.Time{}, "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, created, 2)
+	assert.Equal(t, "a", created[0].Alias)
+	assert.Equal(t, "b", created[1].Alias)
+
+	urls, err = s.GetURLs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
+
+func TestStorage_IsProtected(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://a.com", "plain")
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://secret.com", "protected", storage.WithPasswordHash("hash"))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://once.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+
+	for alias, want := range map[string]bool{"plain": false, "protected": true, "once": true} {
+		protected, err := s.IsProtected(alias)
+		require.NoError(t, err, alias)
+		assert.Equal(t, want, protected, alias)
+	}
+
+	_, err = s.IsProtected("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_Optimize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path)
+	require.NoError(t, err)
+
+	const n = 2000
+
+	longURL := "https://example.com/" + strings.Repeat("x", 500)
+
+	for i := 0; i < n; i++ {
+		_, err := s.SaveURL(longURL, fmt.Sprintf("alias%d", i))
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, s.DeleteURL(fmt.Sprintf("alias%d", i), ""))
+	}
+
+	assert.Equal(t, int64(2*n), s.Writes())
+
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Optimize(context.Background()))
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+
+	assert.Less(t, after.Size(), before.Size())
+}
+
+func TestStorage_GetAliasesByURL(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://one.com", "one")
+	require.NoError(t, err)
+
+	for _, alias := range []string{"first", "second", "third"} {
+		_, err := s.SaveURL("https://many.com", alias)
+		require.NoError(t, err)
+	}
+
+	cases := []struct {
+		name string
+		url  string
+		want []string
+	}{
+		{name: "No aliases", url: "https://none.com", want: nil},
+		{name: "One alias", url: "https://one.com", want: []string{"one"}},
+		{name: "Multiple aliases", url: "https://many.com", want: []string{"first", "second", "third"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			aliases, err := s.GetAliasesByURL(tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, aliases)
+		})
+	}
+}
+
+func TestStorage_Password(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://open.com", "open")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://closed.com", "closed", storage.WithPasswordHash("hash"))
+	require.NoError(t, err)
+
+	hash, err := s.GetPasswordHash("open")
+	require.NoError(t, err)
+	assert.Empty(t, hash)
+
+	hash, err = s.GetPasswordHash("closed")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", hash)
+
+	_, err = s.GetPasswordHash("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	for want := int64(1); want <= 2; want++ {
+		attempts, err := s.RecordFailedAttempt("closed")
+		require.NoError(t, err)
+		assert.Equal(t, want, attempts)
+	}
+
+	_, err = s.RecordFailedAttempt("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_ConsumeOneTime(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://once.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://regular.com", "regular")
+	require.NoError(t, err)
+
+	url, oneTime, err := s.ConsumeOneTime("once")
+	require.NoError(t, err)
+	assert.True(t, oneTime)
+	assert.Equal(t, "https://once.com", url)
+
+	_, oneTime, err = s.ConsumeOneTime("once")
+	assert.ErrorIs(t, err, storage.ErrURLGone)
+	assert.True(t, oneTime)
+
+	// used aliases are not resolved by the other methods either
+	_, err = s.GetURL("once")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	urls, err := s.GetURLs([]string{"once", "regular"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"regular": "https://regular.com"}, urls)
+
+	url, oneTime, err = s.ConsumeOneTime("regular")
+	require.NoError(t, err)
+	assert.False(t, oneTime)
+	assert.Empty(t, url)
+
+	_, err = s.GetURL("regular")
+	assert.NoError(t, err)
+
+	_, _, err = s.ConsumeOneTime("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_URLsCreated(t *testing.T) {
+	s := newStorage(t)
+
+	start := time.Now()
+
+	_, err := s.SaveURL("https://first.com", "first")
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	mid := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = s.SaveURL("https://second.com", "second")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://third.com", "third")
+	require.NoError(t, err)
+
+	aliases := func(since time.Time, until time.Time, limit int, offset int) []string {
+		urls, err := s.URLsCreated(since, until, "", limit, offset)
+		require.NoError(t, err)
+
+		res := make([]string, 0, len(urls))
+		for _, u := range urls {
+			require.NotNil(t, u.CreatedAt)
+			res = append(res, u.Alias)
+		}
+
+		return res
+	}
+
+	assert.Equal(t, []string{"first", "second", "third"}, aliases(time.Time{}, time.Time{}, 10, 0))
+	assert.Equal(t, []string{"first"}, aliases(start, mid, 10, 0))
+	assert.Equal(t, []string{"second", "third"}, aliases(mid, time.Time{}, 10, 0))
+	assert.Equal(t, []string{"third"}, aliases(mid, time.Time{}, 10, 1))
+	assert.Equal(t, []string{"first", "second"}, aliases(time.Time{}, time.Time{}, 2, 0))
+	assert.Empty(t, aliases(time.Now().Add(time.Hour), time.Time{}, 10, 0))
+}
+
+func TestStorage_Tags(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://a.com", "a", storage.WithTags("summer", "newsletter", "summer"))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://b.com", "b", storage.WithTags("winter"), storage.WithOwner("alice"))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://c.com", "c")
+	require.NoError(t, err)
+
+	tagged := func(tag string) []storage.URL {
+		urls, err := s.URLsCreated(time.Time{}, time.Time{}, tag, 10, 0)
+		require.NoError(t, err)
+
+		return urls
+	}
+
+	urls := tagged("summer")
+	require.Len(t, urls, 1)
+	assert.Equal(t, "a", urls[0].Alias)
+	assert.Equal(t, []string{"newsletter", "summer"}, urls[0].Tags)
+
+	assert.Len(t, tagged(""), 3)
+	assert.Empty(t, tagged("autumn"))
+
+	tags, err := s.UpdateTags("b", "alice", []string{"summer", "sale"}, []string{"winter"}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sale", "summer"}, tags)
+	assert.Len(t, tagged("summer"), 2)
+	assert.Empty(t, tagged("winter"))
+
+	_, err = s.UpdateTags("b", "alice", []string{"x", "y"}, nil, 3)
+	assert.ErrorIs(t, err, storage.ErrTooManyTags)
+
+	tags, err = s.UpdateTags("b", "alice", nil, []string{"sale"}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"summer"}, tags, "rejected update is not applied")
+
+	_, err = s.UpdateTags("missing", "alice", []string{"x"}, nil, 3)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = s.UpdateTags("b", "bob", nil, []string{"summer"}, 3)
+	assert.Equal(t, storage.KindForbidden, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrNotOwner)
+	assert.Len(t, tagged("summer"), 2, "tags of another user are kept")
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+	CREATE TABLE url(id INTEGER PRIMARY KEY, alias TEXT NOT NULL UNIQUE, url TEXT NOT NULL);
+	INSERT INTO url(alias, url) VALUES('old', 'https://old.com');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := sqlite.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	url, err := s.GetURL("old")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", url)
+
+	_, err = s.GetURL("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = sqlite.OpenReadOnly(filepath.Join(t.TempDir(), "missing.db"))
+	assert.Error(t, err, "the database is not created")
+}
+
+func TestStorage_ExpiresAtBoundary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	expiresAt := start.Add(time.Hour)
+
+	_, err = s.SaveURL("https://example.com", "boundary", storage.WithExpiresAt(expiresAt))
+	require.NoError(t, err)
+
+	got, err := s.ExpiresAt("boundary")
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Equal(got))
+
+	clk.Set(expiresAt.Add(-time.Second))
+
+	url, err := s.GetURL("boundary")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", url)
+
+	// the alias expires at exactly expiresAt
+	clk.Set(expiresAt)
+
+	_, err = s.GetURL("boundary")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	urls, err := s.GetURLs([]string{"boundary"})
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
+
+func TestStorage_PurgeExpired(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.com/a", "expiring", storage.WithExpiresAt(start.Add(time.Hour)))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com/b", "later", storage.WithExpiresAt(start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com/c", "forever")
+	require.NoError(t, err)
+
+	n, err := s.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	clk.Set(start.Add(time.Hour))
+
+	n, err = s.PurgeExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// the alias is free again
+	_, err = s.SaveURL("https://example.com/d", "expiring")
+	require.NoError(t, err)
+
+	_, err = s.GetURL("later")
+	assert.NoError(t, err)
+	_, err = s.GetURL("forever")
+	assert.NoError(t, err)
+}
+
+func TestStorage_ActiveFrom(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	activeFrom := start.Add(time.Hour)
+	expiresAt := start.Add(2 * time.Hour)
+
+	_, err = s.SaveURL("https://example.com", "window",
+		storage.WithActiveFrom(activeFrom), storage.WithExpiresAt(expiresAt),
+	)
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://once.com", "once", storage.WithActiveFrom(activeFrom), storage.WithOneTime())
+	require.NoError(t, err)
+
+	clk.Set(activeFrom.Add(-time.Second))
+
+	_, err = s.GetURL("window")
+	assert.ErrorIs(t, err, storage.ErrURLNotActive)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	urls, err := s.GetURLs([]string{"window"})
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+
+	// an embargoed one-time alias is not used up
+	_, oneTime, err := s.ConsumeOneTime("once")
+	require.NoError(t, err)
+	assert.False(t, oneTime)
+
+	// the alias is active from exactly activeFrom
+	clk.Set(activeFrom)
+
+	url, err := s.GetURL("window")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", url)
+
+	url, oneTime, err = s.ConsumeOneTime("once")
+	require.NoError(t, err)
+	assert.True(t, oneTime)
+	assert.Equal(t, "https://once.com", url)
+
+	clk.Set(expiresAt)
+
+	_, err = s.GetURL("window")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+	assert.NotErrorIs(t, err, storage.ErrURLNotActive)
+}
+
+func TestStorage_SaveSequentialURL(t *testing.T) {
+	s := newStorage(t)
+
+	// custom aliases which the sequence must skip
+	for _, alias := range []string{"3", "A"} {
+		_, err := s.SaveURL("https://custom.com", alias)
+		require.NoError(t, err)
+	}
+
+	encode := func(n int64) (string, bool) {
+		// values may be rejected, e.g. by an alias filter
+		return base62.Encode(n), n != 7
+	}
+
+	const writers = 50
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		aliases = make(map[string]bool, writers)
+		errs    []error
+	)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			alias, _, err := s.SaveSequentialURL("https://example.com", encode)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+
+				return
+			}
+
+			aliases[alias] = true
+		}()
+	}
+
+	wg.Wait()
+
+	require.Empty(t, errs)
+	require.Len(t, aliases, writers)
+
+	values := make(map[int64]bool, writers)
+	for alias := range aliases {
+		n, err := base62.Decode(alias)
+		require.NoError(t, err)
+
+		values[n] = true
+	}
+
+	assert.Len(t, values, writers)
+	assert.False(t, values[3], "taken by a custom alias")
+	assert.False(t, values[10], "taken by a custom alias")
+	assert.False(t, values[7], "rejected by encode")
+
+	url, err := s.GetURL("3")
+	require.NoError(t, err)
+	assert.Equal(t, "https://custom.com", url)
+}
+
+func TestStorage_UpsertURL(t *testing.T) {
+	s := newStorage(t)
+
+	created, err := s.UpsertURL("alias", "https://first.com", "alice")
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = s.UpsertURL("alias", "https://second.com", "alice")
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	url, err := s.GetURL("alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://second.com", url)
+
+	_, err = s.UpsertURL("alias", "https://third.com", "bob")
+	assert.ErrorIs(t, err, storage.ErrNotOwner)
+
+	url, err = s.GetURL("alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://second.com", url)
+
+	_, err = s.SaveURL("https://saved.com", "saved", storage.WithOwner("bob"))
+	require.NoError(t, err)
+
+	_, err = s.UpsertURL("saved", "https://other.com", "alice")
+	assert.ErrorIs(t, err, storage.ErrNotOwner)
+}
+
+func TestStorage_CountByOwner(t *testing.T) {
+	s := newStorage(t)
+
+	for _, alias := range []string{"a", "b"} {
+		_, err := s.SaveURL("https://example.com", alias, storage.WithOwner("alice"))
+		require.NoError(t, err)
+	}
+	_, err := s.SaveURL("https://example.com", "c", storage.WithOwner("bob"))
+	require.NoError(t, err)
+
+	n, err := s.CountByOwner("alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	require.NoError(t, s.DeleteURL("a", "alice"))
+
+	n, err = s.CountByOwner("alice")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = s.CountByOwner("nobody")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestStorage_Quota(t *testing.T) {
+	s := newStorage(t)
+
+	quota := []storage.SaveOption{storage.WithOwner("alice"), storage.WithQuota(2)}
+
+	_, err := s.SaveURL("https://example.com", "a", quota...)
+	require.NoError(t, err)
+
+	_, err = s.UpsertURL("b", "https://example.com", "alice", storage.WithQuota(2))
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.com", "c", quota...)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	_, _, err = s.SaveSequentialURL("https://example.com", func(n int64) (string, bool) {
+		return fmt.Sprintf("seq%d", n), true
+	}, quota...)
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	_, err = s.UpsertURL("c", "https://example.com", "alice", storage.WithQuota(2))
+	assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+
+	// updating an existing alias doesn't need quota
+	created, err := s.UpsertURL("b", "https://updated.com", "alice", storage.WithQuota(2))
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	_, err = s.SaveURL("https://example.com", "c", storage.WithOwner("bob"), storage.WithQuota(2))
+	require.NoError(t, err, "quotas are per owner")
+
+	require.NoError(t, s.DeleteURL("a", "alice"))
+
+	_, err = s.SaveURL("https://example.com", "d", quota...)
+	require.NoError(t, err, "deleting frees up the quota")
+}
+
+func TestStorage_Collisions(t *testing.T) {
+	s := newStorage(t)
+
+	events := []string{
+		"2023-05-01T00:00:00Z",
+		"2023-05-01T23:59:59Z",
+		"2023-05-02T03:00:00+05:00", // 2023-05-01 in UTC
+		"2023-05-02T12:00:00Z",
+		"2023-05-04T08:30:00Z",
+		"2023-05-04T09:30:00Z",
+	}
+	for _, e := range events {
+		at, err := time.Parse(time.RFC3339, e)
+		require.NoError(t, err)
+		require.NoError(t, s.RecordCollision(at))
+	}
+
+	counts, err := s.Collisions(time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []storage.DailyCount{
+		{Day: "2023-05-01", Count: 3},
+		{Day: "2023-05-02", Count: 1},
+		{Day: "2023-05-04", Count: 2},
+	}, counts)
+
+	counts, err = s.Collisions(time.Date(2023, 5, 3, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []storage.DailyCount{{Day: "2023-05-04", Count: 2}}, counts)
+
+	counts, err = s.Collisions(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+}
+
+func TestStorage_StoredResponse(t *testing.T) {
+	s := newStorage(t)
+
+	at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	res := storage.StoredResponse{
+		Status:      201,
+		Header:      map[string][]string{"Location": {"http://localhost/abc"}},
+		Body:        []byte(`{"alias":"abc"}`),
+		RequestHash: "hash",
+	}
+
+	require.NoError(t, s.ClaimKey("key", "alice", "hash", at, at.Add(-time.Hour), at.Add(-time.Minute)))
+
+	err := s.ClaimKey("key", "alice", "other", at, at.Add(-time.Hour), at.Add(-time.Minute))
+	assert.ErrorIs(t, err, storage.ErrKeyClaimed)
+
+	pending, err := s.StoredResponse("key", "alice", at.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, pending.Status, "in progress")
+	assert.Equal(t, "hash", pending.RequestHash)
+
+	require.NoError(t, s.StoreResponse("key", "alice", res))
+
+	got, err := s.StoredResponse("key", "alice", at.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, res, got)
+
+	// a stored response is final
+	assert.ErrorIs(t, s.StoreResponse("key", "alice", res), storage.ErrKeyNotFound)
+
+	// keys are scoped by actor
+	_, err = s.StoredResponse("key", "bob", at.Add(-time.Hour))
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	// expired
+	_, err = s.StoredResponse("key", "alice", at.Add(time.Second))
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	// an expired key may be claimed again
+	later := at.Add(2 * time.Hour)
+	require.NoError(t, s.ClaimKey("key", "alice", "new", later, later.Add(-time.Hour), later.Add(-time.Minute)))
+
+	got, err = s.StoredResponse("key", "alice", later.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "new", got.RequestHash)
+}
+
+func TestStorage_ReleaseKey(t *testing.T) {
+	s := newStorage(t)
+
+	at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.ClaimKey("key", "alice", "hash", at, at.Add(-time.Hour), at.Add(-time.Minute)))
+	require.NoError(t, s.ReleaseKey("key", "alice"))
+
+	_, err := s.StoredResponse("key", "alice", at.Add(-time.Hour))
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	// a stored response isn't released
+	require.NoError(t, s.ClaimKey("key", "alice", "hash", at, at.Add(-time.Hour), at.Add(-time.Minute)))
+	require.NoError(t, s.StoreResponse("key", "alice", storage.StoredResponse{Status: 200}))
+	require.NoError(t, s.ReleaseKey("key", "alice"))
+
+	got, err := s.StoredResponse("key", "alice", at.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 200, got.Status)
+}
+
+func TestStorage_ClaimKey_Stale(t *testing.T) {
+	s := newStorage(t)
+
+	at := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, s.ClaimKey("done", "alice", "hash", at, at.Add(-time.Hour), at.Add(-time.Minute)))
+	require.NoError(t, s.StoreResponse("done", "alice", storage.StoredResponse{Status: 200}))
+	require.NoError(t, s.ClaimKey("crashed", "alice", "hash", at, at.Add(-time.Hour), at.Add(-time.Minute)))
+
+	later := at.Add(2 * time.Minute)
+
+	// the claim never completed, e.g. the process crashed
+	require.NoError(t, s.ClaimKey("crashed", "alice", "hash", later, later.Add(-time.Hour), later.Add(-time.Minute)))
+
+	// a stored response is kept until it expires
+	err := s.ClaimKey("done", "alice", "hash", later, later.Add(-time.Hour), later.Add(-time.Minute))
+	assert.ErrorIs(t, err, storage.ErrKeyClaimed)
+}
+
+func TestStorage_Summary(t *testing.T) {
+	s := newStorage(t)
+
+	clicks := map[string]int{"a": 3, "b": 5, "c": 1, "d": 5, "e": 0}
+	for alias, n := range clicks {
+		_, err := s.SaveURL("https://"+alias+".com", alias)
+		require.NoError(t, err)
+
+		for i := 0; i < n; i++ {
+			require.NoError(t, s.RecordClick(alias))
+		}
+	}
+
+	assert.ErrorIs(t, s.RecordClick("missing"), storage.ErrURLNotFound)
+
+	sum, err := s.Summary(context.Background(), 3)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(5), sum.TotalURLs)
+	assert.Equal(t, int64(14), sum.TotalClicks)
+	assert.Equal(t, int64(5), sum.CreatedLastDay)
+	assert.Equal(t, []storage.AliasClicks{
+		{Alias: "b", Clicks: 5},
+		{Alias: "d", Clicks: 5},
+		{Alias: "a", Clicks: 3},
+	}, sum.Top)
+}
+
+func TestStorage_SetClicks(t *testing.T) {
+	s := newStorage(t)
+
+	for _, alias := range []string{"a", "b"} {
+		_, err := s.SaveURL("https://"+alias+".com", alias)
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.RecordClick("a"))
+
+	notFound, err := s.SetClicks(context.Background(), []storage.AliasClicks{
+		{Alias: "a", Clicks: 10},
+		{Alias: "missing", Clicks: 7},
+		{Alias: "b", Clicks: 4},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"missing"}, notFound)
+
+	sum, err := s.Summary(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.AliasClicks{{Alias: "a", Clicks: 10}, {Alias: "b", Clicks: 4}}, sum.Top)
+}
+
+func TestStorage_Summary_Empty(t *testing.T) {
+	s := newStorage(t)
+
+	sum, err := s.Summary(context.Background(), 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, storage.Summary{Top: []storage.AliasClicks{}}, sum)
+}
+
+func TestStorage_ExpiresAt(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://fresh.com", "fresh", storage.WithExpiresAt(time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://stale.com", "stale", storage.WithExpiresAt(time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+
+	url, err := s.GetURL("fresh")
+	require.NoError(t, err)
+	assert.Equal(t, "https://fresh.com", url)
+
+	_, err = s.GetURL("stale")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	urls, err := s.GetURLs([]string{"fresh", "stale"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"fresh": "https://fresh.com"}, urls)
+}
+
+func TestStorage_AliasesMatching(t *testing.T) {
+	s := newStorage(t)
+
+	for _, alias := range []string{"stats", "Stats", "statsd", "admin/panel", "adminx", "other"} {
+		_, err := s.SaveURL("https://example.com/"+alias, alias)
+		require.NoError(t, err)
+	}
+
+	aliases, err := s.AliasesMatching([]string{"stats", "readyz"}, []string{"admin/"}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin/panel", "stats"}, aliases)
+
+	aliases, err = s.AliasesMatching(nil, nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, aliases)
+}
+
+func TestStorage_SearchAliases(t *testing.T) {
+	s := newStorage(t)
+
+	for _, alias := range []string{"summer-sale", "sale", "big_sale", "bigXsale", "100%off", "1000off", "winter"} {
+		_, err := s.SaveURL("https://"+alias+".com", alias)
+		require.NoError(t, err)
+	}
+
+	aliases := func(urls []storage.URL) []string {
+		res := make([]string, 0, len(urls))
+		for _, u := range urls {
+			res = append(res, u.Alias)
+		}
+
+		return res
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{name: "Prefix match", query: "sum", limit: 10, want: []string{"summer-sale"}},
+		{name: "Substring match, prefix first", query: "sale", limit: 10, want: []string{"sale", "bigXsale", "big_sale", "summer-sale"}},
+		{name: "Case insensitive", query: "WIN", limit: 10, want: []string{"winter"}},
+		{name: "Underscore escaped", query: "g_s", limit: 10, want: []string{"big_sale"}},
+		{name: "Percent escaped", query: "0%", limit: 10, want: []string{"100%off"}},
+		{name: "Limit respected", query: "sale", limit: 2, want: []string{"sale", "bigXsale"}},
+		{name: "No match", query: "autumn", limit: 10, want: []string{}},
+	}
+
+	for _, tc := range cases {
+		urls, err := s.SearchAliases(tc.query, tc.limit)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, aliases(urls), tc.name)
+	}
+}
+
+func TestHint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "storage.db")
+
+	missingDir := filepath.Join(dir, "missing", "storage.db")
+	_, err := sqlite.New(missingDir + "?_busy_timeout=5000")
+	require.Error(t, err)
+
+	cases := []struct {
+		name string
+		path string
+		err  error
+		want string
+	}{
+		{
+			name: "Directory missing",
+			path: missingDir + "?_busy_timeout=5000",
+			err:  err,
+			want: fmt.Sprintf("directory %q does not exist, create it or enable create_dirs", filepath.Dir(missingDir)),
+		},
+		{
+			name: "Permission denied",
+			path: path,
+			err:  fmt.Errorf("storage.sqlite.New: %w", sqlite3.Error{Code: sqlite3.ErrCantOpen, SystemErrno: syscall.EACCES}),
+			want: fmt.Sprintf("no permission to open %q, make it and directory %q writable by the service user", path, dir),
+		},
+		{
+			name: "Read-only filesystem",
+			path: path,
+			err:  sqlite3.Error{Code: sqlite3.ErrCantOpen, SystemErrno: syscall.EROFS},
+			want: fmt.Sprintf("filesystem of %q is read-only, mount it writable or change storage_path", path),
+		},
+		{
+			name: "Read-only file",
+			path: path,
+			err:  sqlite3.Error{Code: sqlite3.ErrReadonly},
+			want: fmt.Sprintf("%q is read-only, make it and directory %q writable by the service user", path, dir),
+		},
+		{
+			name: "Unknown cause",
+			path: path,
+			err:  errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, sqlite.Hint(tc.path, tc.err), tc.name)
+	}
+}
+
+func TestCreateDir(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a", "b", "storage.db")
+
+	require.NoError(t, sqlite.CreateDir("file:"+path+"?_busy_timeout=5000"))
+
+	s, err := sqlite.New(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+}
+
+func TestStorage_ErrorKinds(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path + "?_busy_timeout=0")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://once.com", "once", storage.WithOneTime())
+	require.NoError(t, err)
+	_, _, err = s.ConsumeOneTime("once")
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://owned.com", "owned", storage.WithOwner("alice"))
+	require.NoError(t, err)
+
+	_, err = s.GetURL("missing")
+	assert.Equal(t, storage.KindNotFound, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = s.SaveURL("https://other.com", "owned")
+	assert.Equal(t, storage.KindExists, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+
+	_, _, err = s.ConsumeOneTime("once")
+	assert.Equal(t, storage.KindGone, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrURLGone)
+
+	_, err = s.UpsertURL("owned", "https://other.com", "bob")
+	assert.Equal(t, storage.KindForbidden, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrNotOwner)
+
+	err = s.DeleteURL("missing", "alice")
+	assert.Equal(t, storage.KindNotFound, storage.KindOf(err))
+
+	// another connection holding the write lock makes writes transient
+	locker, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer locker.Close()
+
+	conn, err := locker.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.ExecContext(context.Background(), "BEGIN EXCLUSIVE")
+	require.NoError(t, err)
+	defer func() { _, _ = conn.ExecContext(context.Background(), "ROLLBACK") }()
+
+	err = s.DeleteURL("owned", "alice")
+	assert.Equal(t, storage.KindTransient, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrTransient)
+}
+
+func TestStorage_DeleteURL_Owner(t *testing.T) {
+	s := newStorage(t)
+
+	_, err := s.SaveURL("https://example.com", "owned", storage.WithOwner("alice"))
+	require.NoError(t, err)
+
+	err = s.DeleteURL("owned", "bob")
+	assert.Equal(t, storage.KindForbidden, storage.KindOf(err))
+	assert.ErrorIs(t, err, storage.ErrNotOwner)
+
+	got, err := s.GetURL("owned")
+	require.NoError(t, err, "kept for its owner")
+	assert.Equal(t, "https://example.com", got)
+
+	err = s.DeleteURL("missing", "bob")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	require.NoError(t, s.DeleteURL("owned", "alice"))
+
+	_, err = s.GetURL("owned")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_AliasLength(t *testing.T) {
+	s := newStorage(t)
+
+	length, err := s.AliasLength()
+	require.NoError(t, err)
+	assert.Zero(t, length, "not saved yet")
+
+	require.NoError(t, s.SetAliasLength(7))
+	require.NoError(t, s.SetAliasLength(6))
+
+	length, err = s.AliasLength()
+	require.NoError(t, err)
+	assert.Equal(t, 7, length, "never shrinks")
+}
+
+func TestStorage_TryLock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path, sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	// another replica sharing the database
+	other, err := sqlite.New(path, sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	ok, lock, err := s.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, _, err = other.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "the lock is held")
+
+	ok, sweep, err := other.TryLock("sweep", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "locks are independent")
+	sweep.Release()
+
+	// an extended lock outlives its first ttl
+	clk.Advance(30 * time.Second)
+	require.NoError(t, lock.Extend(time.Minute))
+	clk.Advance(45 * time.Second)
+
+	ok, _, err = other.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "the lock is extended")
+
+	lock.Release()
+
+	ok, otherLock, err := other.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok, "the lock is released")
+
+	clk.Advance(time.Minute)
+
+	ok, _, err = s.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "the lock expired")
+
+	// the expired holder must not extend or release the lock taken over
+	assert.ErrorIs(t, otherLock.Extend(time.Minute), storage.ErrLockLost)
+	otherLock.Release()
+
+	ok, _, err = other.TryLock("optimize", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = s.TryLock("optimize", 0)
+	assert.Error(t, err)
+}
+
+func TestStorage_ReserveAlias(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	expiresAt, err := s.ReserveAlias("held", "alice", 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(10*time.Minute), expiresAt)
+
+	_, err = s.ReserveAlias("held", "bob", 10*time.Minute)
+	assert.ErrorIs(t, err, storage.ErrAliasReserved)
+
+	_, err = s.SaveURL("https://example.com", "held", storage.WithOwner("bob"))
+	assert.ErrorIs(t, err, storage.ErrAliasReserved)
+	assert.ErrorIs(t, err, storage.ErrURLExists, "a reserved alias is taken")
+
+	_, err = s.SaveURL("https://example.com", "held")
+	assert.ErrorIs(t, err, storage.ErrAliasReserved, "anonymous saves are blocked too")
+
+	_, err = s.UpsertURL("held", "https://example.com", "bob")
+	assert.ErrorIs(t, err, storage.ErrAliasReserved, "upserts are blocked too")
+
+	// the holder extends and then uses the reservation
+	expiresAt, err = s.ReserveAlias("held", "alice", 20*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(20*time.Minute), expiresAt)
+
+	_, err = s.SaveURL("https://example.com", "held", storage.WithOwner("alice"))
+	require.NoError(t, err)
+
+	_, err = s.ReserveAlias("held", "alice", 10*time.Minute)
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+}
+
+func TestStorage_ReserveAliasExpiry(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.WithClock(clk))
+	require.NoError(t, err)
+
+	_, err = s.ReserveAlias("held", "alice", time.Minute)
+	require.NoError(t, err)
+
+	clk.Advance(time.Minute)
+
+	err = s.ReleaseAlias("held", "alice")
+	assert.ErrorIs(t, err, storage.ErrReservationNotFound, "the reservation expired")
+
+	_, err = s.ReserveAlias("held", "bob", time.Minute)
+	require.NoError(t, err, "an expired reservation is free")
+
+	require.NoError(t, s.ReleaseAlias("held", "bob"))
+
+	_, err = s.SaveURL("https://example.com", "held", storage.WithOwner("alice"))
+	require.NoError(t, err, "a released alias is free")
+}
+
+func TestNew_ConnMaxIdleTime(t *testing.T) {
+	s, err := sqlite.New(
+		filepath.Join(t.TempDir(), "storage.db"),
+		sqlite.WithConnMaxLifetime(time.Hour),
+		sqlite.WithConnMaxIdleTime(time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL("https://example.com", "abc")
+	require.NoError(t, err)
+
+	// the pool is cleaned up once a second at most
+	assert.Eventually(t, func() bool {
+		stats := s.Stats()
+
+		return stats.MaxIdleTimeClosed > 0 && stats.OpenConnections == 0
+	}, 3*time.Second, 50*time.Millisecond)
+
+	url, err := s.GetURL("abc")
+	require.NoError(t, err, "a new connection is opened")
+	assert.Equal(t, "https://example.com", url)
+}
+
+func TestStorage_GetRedirectRules(t *testing.T) {
+	s := newStorage(t)
+
+	rules := []storage.RedirectRule{
+		{Device: "ios", URL: "https://apps.apple.com/app/id1"},
+		{Language: "de", Country: "AT", URL: "https://example.at"},
+	}
+
+	_, err := s.SaveURL("https://example.com", "app", storage.WithRedirectRules(rules...))
+	require.NoError(t, err)
+	_, err = s.SaveURL("https://example.com", "plain")
+	require.NoError(t, err)
+
+	got, err := s.GetRedirectRules("app")
+	require.NoError(t, err)
+	assert.Equal(t, rules, got)
+
+	got, err = s.GetRedirectRules("plain")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = s.GetRedirectRules("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
@@ -0,0 +1,118 @@
+package static
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+
+	"url-shortener/internal/lib/urlnorm"
+	"url-shortener/internal/storage"
+)
+
+// URLStorage is the storage of the dynamic aliases.
+type URLStorage interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+	GetURL(alias string) (string, error)
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+	DeleteURL(alias string, owner string) error
+}
+
+// Storage serves the aliases of a mapping file from memory, ahead of the
+// wrapped storage, e.g. vanity links managed in git. The file is a YAML
+// map of aliases to urls:
+//
+//	docs: https://example.com/docs
+//
+// Static aliases can't be saved again, the other writes only affect the
+// wrapped storage.
+type Storage struct {
+	URLStorage
+	path string
+	urls atomic.Pointer[map[string]string]
+}
+
+// New loads the mapping file at path.
+func New(path string, s URLStorage) (*Storage, error) {
+	const op = "storage.static.New"
+
+	st := &Storage{URLStorage: s, path: path}
+
+	if err := st.Reload(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return st, nil
+}
+
+// Reload re-reads the mapping file. The previous mappings are kept on error.
+func (s *Storage) Reload() error {
+	const op = "storage.static.Reload"
+
+	urls, err := load(s.path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.urls.Store(&urls)
+
+	return nil
+}
+
+// Len returns the number of static aliases.
+func (s *Storage) Len() int {
+	return len(*s.urls.Load())
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	if u, ok := (*s.urls.Load())[alias]; ok {
+		return u, nil
+	}
+
+	return s.URLStorage.GetURL(alias)
+}
+
+func (s *Storage) SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error) {
+	const op = "storage.static.SaveURL"
+
+	if _, ok := (*s.urls.Load())[alias]; ok {
+		return 0, storage.NewError(op, storage.KindExists, storage.ErrURLExists)
+	}
+
+	return s.URLStorage.SaveURL(urlToSave, alias, opts...)
+}
+
+func load(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]string
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	urls := make(map[string]string, len(raw))
+
+	for alias, rawURL := range raw {
+		if alias == "" {
+			return nil, fmt.Errorf("empty alias in %s", path)
+		}
+
+		if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("alias %q: invalid url %q", alias, rawURL)
+		}
+
+		normalized, err := urlnorm.Normalize(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("alias %q: %w", alias, err)
+		}
+
+		urls[alias] = normalized
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,101 @@
+package static_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/sqlite"
+	"url-shortener/internal/storage/static"
+)
+
+func writeMappings(t *testing.T, path string, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func newStorage(t *testing.T, mappings string) (*static.Storage, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	dynamic, err := sqlite.New(filepath.Join(dir, "storage.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = dynamic.Close() })
+
+	path := filepath.Join(dir, "aliases.yaml")
+	writeMappings(t, path, mappings)
+
+	s, err := static.New(path, dynamic)
+	require.NoError(t, err)
+
+	return s, path
+}
+
+func TestStorage_StaticAndDynamic(t *testing.T) {
+	s, _ := newStorage(t, `
+docs: https://Example.com:443/docs
+blog: https://blog.example.com
+`)
+	assert.Equal(t, 2, s.Len())
+
+	url, err := s.GetURL("docs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/docs", url)
+
+	_, err = s.SaveURL("https://dynamic.com", "dynamic")
+	require.NoError(t, err)
+
+	url, err = s.GetURL("dynamic")
+	require.NoError(t, err)
+	assert.Equal(t, "https://dynamic.com", url)
+
+	_, err = s.SaveURL("https://other.com", "docs")
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+
+	_, err = s.GetURL("missing")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_Reload(t *testing.T) {
+	s, path := newStorage(t, "docs: https://example.com/docs\n")
+
+	writeMappings(t, path, "docs: https://example.com/v2/docs\nnew: https://new.com\n")
+	require.NoError(t, s.Reload())
+
+	url, err := s.GetURL("docs")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/v2/docs", url)
+
+	url, err = s.GetURL("new")
+	require.NoError(t, err)
+	assert.Equal(t, "https://new.com", url)
+
+	writeMappings(t, path, "broken: not a url\n")
+	assert.Error(t, s.Reload())
+
+	_, err = s.GetURL("new")
+	assert.NoError(t, err, "previous mappings are kept")
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"not a map":  "- https://example.com\n",
+		"bad scheme": "js: javascript:alert(1)\n",
+		"no host":    "rel: /docs\n",
+	} {
+		path := filepath.Join(t.TempDir(), "aliases.yaml")
+		writeMappings(t, path, content)
+
+		_, err := static.New(path, nil)
+		assert.Error(t, err, name)
+	}
+
+	_, err := static.New(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(t, err)
+}
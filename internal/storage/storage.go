@@ -1,8 +1,238 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 var (
 	ErrURLNotFound = errors.New("url not found")
 	ErrURLExists   = errors.New("url exists")
+	// ErrURLGone is returned for a one-time url which was already used.
+	ErrURLGone = errors.New("url gone")
+	// ErrURLNotActive is returned for a url before its activation time.
+	// It is of KindNotFound, so it matches ErrURLNotFound too.
+	ErrURLNotActive = errors.New("url is not active yet")
+	// ErrNotOwner is returned when changing a url saved by another user.
+	ErrNotOwner = errors.New("url is owned by another user")
+	// ErrKeyNotFound is returned for an unknown or expired idempotency key.
+	ErrKeyNotFound = errors.New("idempotency key not found")
+	// ErrKeyClaimed is returned when claiming an idempotency key which
+	// another request already claimed.
+	ErrKeyClaimed = errors.New("idempotency key already claimed")
+	// ErrTooManyTags is returned when a url would exceed its tags limit.
+	ErrTooManyTags = errors.New("too many tags")
+	// ErrAliasReserved is returned when saving an alias reserved by
+	// another user. It is of KindExists, so it matches ErrURLExists too.
+	ErrAliasReserved = errors.New("alias is reserved")
+	// ErrReservationNotFound is returned when releasing an alias which
+	// is not reserved by the user.
+	ErrReservationNotFound = errors.New("reservation not found")
+	// ErrQuotaExceeded is returned when saving a url would exceed the
+	// quota of its owner. It is of KindForbidden, so it matches ErrNotOwner too.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrLockLost is returned when extending a lock which expired and
+	// may have been taken over.
+	ErrLockLost = errors.New("lock lost")
 )
+
+// SaveOptions are optional properties of a saved url.
+type SaveOptions struct {
+	// PasswordHash is a bcrypt hash of the password required
+	// to follow the alias. Empty value means no password.
+	PasswordHash string
+	// OneTime makes the alias usable for a single redirect.
+	OneTime bool
+	// Owner is the user who saved the url.
+	Owner string
+	// ExpiresAt is the time the alias stops working. Nil means never.
+	ExpiresAt *time.Time
+	// ActiveFrom is the time the alias starts working. Nil means at once.
+	ActiveFrom *time.Time
+	// CacheTTL is how long clients may cache the redirect.
+	// Zero means they must revalidate it.
+	CacheTTL time.Duration
+	// Tags group urls, e.g. by campaign.
+	Tags []string
+	// RedirectRules send some clients to other targets than the url.
+	RedirectRules []RedirectRule
+	// Quota is the number of urls Owner may own. Saving a new url beyond
+	// it fails with ErrQuotaExceeded. Zero means unlimited.
+	Quota int64
+}
+
+// RedirectRule sends clients matching all of its non-empty conditions
+// to URL instead of the url of the alias.
+type RedirectRule struct {
+	// Device is "mobile", "desktop", "ios" or "android".
+	Device string `json:"device,omitempty"`
+	// Language is the primary language preferred by the client, e.g. "de".
+	Language string `json:"language,omitempty"`
+	// Country is the ISO 3166 code set by a CDN, e.g. "DE".
+	Country string `json:"country,omitempty"`
+	URL     string `json:"url"`
+}
+
+type SaveOption func(*SaveOptions)
+
+// WithPasswordHash protects the alias with a password.
+func WithPasswordHash(hash string) SaveOption {
+	return func(o *SaveOptions) {
+		o.PasswordHash = hash
+	}
+}
+
+// WithOneTime makes the alias expire after the first redirect.
+func WithOneTime() SaveOption {
+	return func(o *SaveOptions) {
+		o.OneTime = true
+	}
+}
+
+// WithOwner records the user who saved the url.
+func WithOwner(owner string) SaveOption {
+	return func(o *SaveOptions) {
+		o.Owner = owner
+	}
+}
+
+// WithQuota limits the number of urls the owner may own, see SaveOptions.Quota.
+func WithQuota(n int64) SaveOption {
+	return func(o *SaveOptions) {
+		o.Quota = n
+	}
+}
+
+// WithExpiresAt makes the alias stop working at t.
+func WithExpiresAt(t time.Time) SaveOption {
+	return func(o *SaveOptions) {
+		t := t.UTC()
+		o.ExpiresAt = &t
+	}
+}
+
+// WithActiveFrom makes the alias start working at t.
+func WithActiveFrom(t time.Time) SaveOption {
+	return func(o *SaveOptions) {
+		t := t.UTC()
+		o.ActiveFrom = &t
+	}
+}
+
+// WithCacheTTL lets clients cache the redirect for ttl.
+func WithCacheTTL(ttl time.Duration) SaveOption {
+	return func(o *SaveOptions) {
+		o.CacheTTL = ttl
+	}
+}
+
+// WithTags tags the url.
+func WithTags(tags ...string) SaveOption {
+	return func(o *SaveOptions) {
+		o.Tags = tags
+	}
+}
+
+// WithRedirectRules sends clients matching rules to their targets.
+func WithRedirectRules(rules ...RedirectRule) SaveOption {
+	return func(o *SaveOptions) {
+		o.RedirectRules = rules
+	}
+}
+
+// NewSaveOptions applies opts to zero SaveOptions.
+func NewSaveOptions(opts ...SaveOption) SaveOptions {
+	var o SaveOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// URL is a saved url. CreatedAt is nil for urls saved before
+// the creation time was recorded.
+type URL struct {
+	ID        int64      `json:"id"`
+	Alias     string     `json:"alias"`
+	URL       string     `json:"url"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+}
+
+// DailyCount is a counter aggregated per UTC day.
+type DailyCount struct {
+	Day   string `json:"day"` // 2006-01-02
+	Count int64  `json:"count"`
+}
+
+// AliasClicks is the number of redirects of an alias.
+type AliasClicks struct {
+	Alias  string `json:"alias"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Summary is an aggregate of all saved urls.
+type Summary struct {
+	TotalURLs      int64         `json:"total_urls"`
+	TotalClicks    int64         `json:"total_clicks"`
+	CreatedLastDay int64         `json:"created_last_24h"`
+	Top            []AliasClicks `json:"top"`
+}
+
+// Lock is an advisory lock shared by the replicas.
+type Lock interface {
+	// Extend keeps the lock for ttl from now. It fails with ErrLockLost
+	// if the lock expired meanwhile.
+	Extend(ttl time.Duration) error
+	// Release gives up the lock.
+	Release()
+}
+
+// StoredResponse is a response saved for an idempotency key.
+// Status is zero while the request is in progress.
+type StoredResponse struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+	// RequestHash identifies the request the key was claimed for.
+	RequestHash string
+}
+
+// AuditEntry is a record of a mutating operation.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Alias     string    `json:"alias"`
+	Result    string    `json:"result"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Empty fields match any entry.
+type AuditFilter struct {
+	Actor  string
+	Action string
+}
+
+// ContextBinder is implemented by storage decorators which need
+// the request context, e.g. to start tracing spans.
+type ContextBinder interface {
+	WithContext(ctx context.Context) interface{}
+}
+
+// WithContext returns s bound to ctx if s is a ContextBinder
+// and s itself otherwise.
+func WithContext[T any](ctx context.Context, s T) T {
+	b, ok := any(s).(ContextBinder)
+	if !ok {
+		return s
+	}
+
+	if bound, ok := b.WithContext(ctx).(T); ok {
+		return bound
+	}
+
+	return s
+}
@@ -0,0 +1,91 @@
+package traced
+
+import (
+	"context"
+
+	"url-shortener/internal/lib/tracing"
+	"url-shortener/internal/storage"
+)
+
+// URLStorage is the storage wrapped by the tracer.
+type URLStorage interface {
+	SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error)
+	GetURL(alias string) (string, error)
+	UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error)
+	DeleteURL(alias string, owner string) error
+}
+
+// Storage starts a span for every storage call. Bind it to the request
+// with WithContext to make the spans children of the request span.
+type Storage struct {
+	URLStorage
+	tracer *tracing.Tracer
+	ctx    context.Context
+}
+
+func New(s URLStorage, tracer *tracing.Tracer) *Storage {
+	return &Storage{
+		URLStorage: s,
+		tracer:     tracer,
+		ctx:        context.Background(),
+	}
+}
+
+// WithContext implements storage.ContextBinder.
+func (s *Storage) WithContext(ctx context.Context) interface{} {
+	return &Storage{
+		URLStorage: s.URLStorage,
+		tracer:     s.tracer,
+		ctx:        ctx,
+	}
+}
+
+func (s *Storage) SaveURL(urlToSave string, alias string, opts ...storage.SaveOption) (int64, error) {
+	span := s.start("storage.SaveURL", alias)
+	defer span.End()
+
+	id, err := s.URLStorage.SaveURL(urlToSave, alias, opts...)
+	span.RecordError(err)
+
+	return id, err
+}
+
+func (s *Storage) GetURL(alias string) (string, error) {
+	span := s.start("storage.GetURL", alias)
+	defer span.End()
+
+	url, err := s.URLStorage.GetURL(alias)
+	span.RecordError(err)
+
+	return url, err
+}
+
+func (s *Storage) UpsertURL(alias string, urlToSave string, owner string, opts ...storage.SaveOption) (bool, error) {
+	span := s.start("storage.UpsertURL", alias)
+	defer span.End()
+
+	created, err := s.URLStorage.UpsertURL(alias, urlToSave, owner, opts...)
+	span.RecordError(err)
+
+	return created, err
+}
+
+func (s *Storage) DeleteURL(alias string, owner string) error {
+	span := s.start("storage.DeleteURL", alias)
+	defer span.End()
+
+	err := s.URLStorage.DeleteURL(alias, owner)
+	span.RecordError(err)
+
+	return err
+}
+
+func (s *Storage) start(name string, alias string) *tracing.Span {
+	_, span := s.tracer.Start(s.ctx, name, tracing.SpanKindClient)
+
+	span.SetAttribute("db.system", "sqlite")
+	span.SetAttribute("db.operation", name)
+	span.SetAttribute("url.alias", alias)
+
+	return span
+}